@@ -0,0 +1,333 @@
+// Package lockedmapcheck defines an Analyzer that reports misuse of the
+// LockedMap values handed to locked callbacks of a SyncMap.
+package lockedmapcheck
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
+)
+
+const doc = `report misuse of syncmap.LockedMap inside locked callbacks
+
+The callbacks passed to SyncMap.DoLocked, DoLockedWithResult, DoRLocked,
+RangeMut and similar functions run while the map's lock is held. The analyzer reports:
+
+  - calls to methods of the same SyncMap made from inside such a callback,
+    which deadlock unless the map was created WithReentrantLocking, as the
+    lock is not re-entrant by default (methods that never take the lock,
+    such as Len, are not reported);
+  - LockedMap values that escape the callback (assigned to outer variables,
+    fields or elements, returned, sent on channels or used by goroutines),
+    because using them after the callback returns bypasses the lock.`
+
+const pkgPath = "github.com/antst/go-syncmap"
+
+// Analyzer reports misuse of syncmap.LockedMap inside locked callbacks.
+var Analyzer = &analysis.Analyzer{
+	Name:     "lockedmapcheck",
+	Doc:      doc,
+	URL:      "https://pkg.go.dev/github.com/antst/go-syncmap/analysis/lockedmapcheck",
+	Requires: []*analysis.Analyzer{inspect.Analyzer},
+	Run:      run,
+}
+
+func run(pass *analysis.Pass) (any, error) {
+	insp := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+
+	insp.Preorder(
+		[]ast.Node{(*ast.CallExpr)(nil)}, func(n ast.Node) {
+			call := n.(*ast.CallExpr)
+
+			targets := lockedTargets(pass, call)
+			if len(targets) == 0 {
+				return
+			}
+
+			for _, arg := range call.Args {
+				lit, ok := ast.Unparen(arg).(*ast.FuncLit)
+				if !ok {
+					continue
+				}
+
+				params := lockedMapParams(pass, lit)
+				if len(params) == 0 {
+					continue
+				}
+
+				checkDeadlock(pass, lit, targets)
+				checkEscape(pass, lit, params)
+			}
+		},
+	)
+
+	return nil, nil
+}
+
+// lockedTargets returns the SyncMap expressions locked by call, if call is a method
+// or function of the syncmap package that operates on SyncMaps.
+func lockedTargets(pass *analysis.Pass, call *ast.CallExpr) []ast.Expr {
+	var targets []ast.Expr
+
+	switch fun := ast.Unparen(call.Fun).(type) {
+	case *ast.SelectorExpr:
+		if sel, ok := pass.TypesInfo.Selections[fun]; ok {
+			if sel.Kind() == types.MethodVal && isSyncMap(sel.Recv()) {
+				targets = append(targets, fun.X)
+			}
+			break
+		}
+		if !isSyncMapPkgFunc(pass, fun.Sel) {
+			return nil
+		}
+	case *ast.Ident:
+		if !isSyncMapPkgFunc(pass, fun) {
+			return nil
+		}
+	case *ast.IndexExpr, *ast.IndexListExpr:
+		// explicitly instantiated generic function, e.g. syncmap.DoLocked[K, V, R](...)
+		var x ast.Expr
+		if ie, ok := fun.(*ast.IndexExpr); ok {
+			x = ie.X
+		} else {
+			x = fun.(*ast.IndexListExpr).X
+		}
+		var id *ast.Ident
+		switch x := ast.Unparen(x).(type) {
+		case *ast.Ident:
+			id = x
+		case *ast.SelectorExpr:
+			id = x.Sel
+		}
+		if id == nil || !isSyncMapPkgFunc(pass, id) {
+			return nil
+		}
+	default:
+		return nil
+	}
+
+	for _, arg := range call.Args {
+		if tv, ok := pass.TypesInfo.Types[arg]; ok && isSyncMap(tv.Type) {
+			targets = append(targets, arg)
+		}
+	}
+
+	return targets
+}
+
+// lockedMapParams returns the parameters of lit that hold LockedMap values.
+func lockedMapParams(pass *analysis.Pass, lit *ast.FuncLit) map[types.Object]bool {
+	params := make(map[types.Object]bool)
+
+	for _, field := range lit.Type.Params.List {
+		for _, name := range field.Names {
+			obj := pass.TypesInfo.Defs[name]
+			if obj == nil {
+				continue
+			}
+			t := obj.Type()
+			if s, ok := t.(*types.Slice); ok {
+				t = s.Elem()
+			}
+			if isNamed(t, "LockedMap") || isNamed(t, "ReadOnlyLockedMap") {
+				params[obj] = true
+			}
+		}
+	}
+
+	return params
+}
+
+func checkDeadlock(pass *analysis.Pass, lit *ast.FuncLit, targets []ast.Expr) {
+	ast.Inspect(
+		lit.Body, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.GoStmt:
+				// a goroutine merely blocks until the lock is released
+				return false
+			case *ast.CallExpr:
+				for _, t := range callTargets(pass, n) {
+					for _, target := range targets {
+						if sameExpr(pass, t, target) {
+							pass.Reportf(
+								n.Pos(),
+								"call on SyncMap %s inside its own locked callback will deadlock unless the map was created WithReentrantLocking; use the LockedMap instead",
+								types.ExprString(target),
+							)
+							return true
+						}
+					}
+				}
+			}
+			return true
+		},
+	)
+}
+
+// lockFree lists the SyncMap methods that never acquire the lock of the map,
+// and may thus be called from inside its locked callbacks.
+var lockFree = map[string]bool{
+	"Len":       true,
+	"Name":      true,
+	"Labels":    true,
+	"Version":   true,
+	"Ready":     true,
+	"IsReady":   true,
+	"MarkReady": true,
+}
+
+// callTargets returns the SyncMaps that call operates on and locks: the receiver of a
+// SyncMap method, or the SyncMap arguments of a syncmap package function.
+func callTargets(pass *analysis.Pass, call *ast.CallExpr) []ast.Expr {
+	if fun, ok := ast.Unparen(call.Fun).(*ast.SelectorExpr); ok {
+		if sel, ok := pass.TypesInfo.Selections[fun]; ok {
+			if sel.Kind() == types.MethodVal && isSyncMap(sel.Recv()) && !lockFree[fun.Sel.Name] {
+				return []ast.Expr{fun.X}
+			}
+			return nil
+		}
+	}
+
+	return lockedTargets(pass, call)
+}
+
+func checkEscape(pass *analysis.Pass, lit *ast.FuncLit, params map[types.Object]bool) {
+	refers := func(e ast.Expr) bool {
+		return refersTo(pass, e, params)
+	}
+	report := func(pos token.Pos, how string) {
+		pass.Reportf(pos, "LockedMap escapes its locked callback (%s); it must not be used after the callback returns", how)
+	}
+
+	var visit func(n ast.Node, nested bool) bool
+	visit = func(n ast.Node, nested bool) bool {
+		switch n := n.(type) {
+		case *ast.FuncLit:
+			ast.Inspect(
+				n.Body, func(m ast.Node) bool {
+					return visit(m, true)
+				},
+			)
+			return false
+		case *ast.GoStmt:
+			if usesAny(pass, n.Call, params) {
+				report(n.Pos(), "used by a goroutine")
+			}
+			return false
+		case *ast.ReturnStmt:
+			if nested {
+				return true
+			}
+			for _, r := range n.Results {
+				if refers(r) {
+					report(r.Pos(), "returned")
+				}
+			}
+		case *ast.SendStmt:
+			if refers(n.Value) {
+				report(n.Value.Pos(), "sent on a channel")
+			}
+		case *ast.AssignStmt:
+			if len(n.Lhs) != len(n.Rhs) {
+				return true
+			}
+			for i, rhs := range n.Rhs {
+				if !refers(rhs) {
+					continue
+				}
+				switch lhs := ast.Unparen(n.Lhs[i]).(type) {
+				case *ast.Ident:
+					obj := pass.TypesInfo.ObjectOf(lhs)
+					if obj != nil && n.Tok != token.DEFINE && !within(obj.Pos(), lit) {
+						report(lhs.Pos(), "assigned to outer variable "+lhs.Name)
+					}
+				case *ast.SelectorExpr, *ast.IndexExpr, *ast.StarExpr:
+					report(lhs.Pos(), "stored in "+types.ExprString(lhs))
+				}
+			}
+		}
+		return true
+	}
+
+	ast.Inspect(
+		lit.Body, func(n ast.Node) bool {
+			return visit(n, false)
+		},
+	)
+}
+
+// refersTo reports whether the value of e is (or directly contains) one of params.
+func refersTo(pass *analysis.Pass, e ast.Expr, params map[types.Object]bool) bool {
+	switch e := ast.Unparen(e).(type) {
+	case *ast.Ident:
+		return params[pass.TypesInfo.Uses[e]]
+	case *ast.IndexExpr:
+		return refersTo(pass, e.X, params)
+	case *ast.UnaryExpr:
+		return refersTo(pass, e.X, params)
+	case *ast.KeyValueExpr:
+		return refersTo(pass, e.Value, params)
+	case *ast.CompositeLit:
+		for _, elt := range e.Elts {
+			if refersTo(pass, elt, params) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// usesAny reports whether any identifier within n refers to one of params.
+func usesAny(pass *analysis.Pass, n ast.Node, params map[types.Object]bool) bool {
+	found := false
+	ast.Inspect(
+		n, func(n ast.Node) bool {
+			if id, ok := n.(*ast.Ident); ok && params[pass.TypesInfo.Uses[id]] {
+				found = true
+			}
+			return !found
+		},
+	)
+	return found
+}
+
+func sameExpr(pass *analysis.Pass, a, b ast.Expr) bool {
+	a, b = ast.Unparen(a), ast.Unparen(b)
+	if ia, ok := a.(*ast.Ident); ok {
+		if ib, ok := b.(*ast.Ident); ok {
+			oa, ob := pass.TypesInfo.ObjectOf(ia), pass.TypesInfo.ObjectOf(ib)
+			return oa != nil && oa == ob
+		}
+		return false
+	}
+	return types.ExprString(a) == types.ExprString(b)
+}
+
+func within(pos token.Pos, lit *ast.FuncLit) bool {
+	return lit.Pos() <= pos && pos < lit.End()
+}
+
+func isSyncMapPkgFunc(pass *analysis.Pass, id *ast.Ident) bool {
+	fn, ok := pass.TypesInfo.Uses[id].(*types.Func)
+	return ok && fn.Pkg() != nil && fn.Pkg().Path() == pkgPath
+}
+
+func isSyncMap(t types.Type) bool {
+	if p, ok := t.(*types.Pointer); ok {
+		t = p.Elem()
+	}
+	return isNamed(t, "SyncMap")
+}
+
+func isNamed(t types.Type, name string) bool {
+	n, ok := types.Unalias(t).(*types.Named)
+	if !ok {
+		return false
+	}
+	obj := n.Origin().Obj()
+	return obj.Name() == name && obj.Pkg() != nil && obj.Pkg().Path() == pkgPath
+}
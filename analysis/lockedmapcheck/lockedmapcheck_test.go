@@ -0,0 +1,13 @@
+package lockedmapcheck_test
+
+import (
+	"testing"
+
+	"golang.org/x/tools/go/analysis/analysistest"
+
+	"github.com/antst/go-syncmap/analysis/lockedmapcheck"
+)
+
+func TestAnalyzer(t *testing.T) {
+	analysistest.Run(t, analysistest.TestData(), lockedmapcheck.Analyzer, "a")
+}
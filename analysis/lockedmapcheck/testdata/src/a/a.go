@@ -0,0 +1,80 @@
+package a
+
+import (
+	syncmap "github.com/antst/go-syncmap"
+)
+
+type holder struct {
+	lm syncmap.LockedMap[string, int]
+}
+
+func deadlocks() {
+	sm := syncmap.New[string, int](10)
+	other := syncmap.New[string, int](10)
+
+	sm.DoLocked(
+		func(m syncmap.LockedMap[string, int]) {
+			m.Store("a", 1)
+			sm.Store("b", 2) // want `call on SyncMap sm inside its own locked callback will deadlock unless the map was created WithReentrantLocking`
+			other.Store("c", 3)
+			go func() {
+				sm.Store("d", 4)
+			}()
+		},
+	)
+
+	sm.DoRLocked(
+		func(m syncmap.ReadOnlyLockedMap[string, int]) {
+			m.Load("a")
+			sm.Store("b", 2) // want `call on SyncMap sm inside its own locked callback will deadlock unless the map was created WithReentrantLocking`
+		},
+	)
+
+	sm.RangeMut(
+		func(m syncmap.LockedMap[string, int], key string, value int) bool {
+			_, ok := sm.Load("a") // want `call on SyncMap sm inside its own locked callback will deadlock unless the map was created WithReentrantLocking`
+			return ok
+		},
+	)
+
+	sm.RangeMut(
+		func(m syncmap.LockedMap[string, int], key string, value int) bool {
+			// Len does not acquire the lock
+			return sm.Len() > 0
+		},
+	)
+}
+
+func escapes(ch chan syncmap.LockedMap[string, int]) {
+	sm := syncmap.New[string, int](10)
+	var outer syncmap.LockedMap[string, int]
+	h := &holder{}
+
+	sm.DoLocked(
+		func(m syncmap.LockedMap[string, int]) {
+			local := m
+			local.Store("a", 1)
+			outer = m   // want `LockedMap escapes its locked callback \(assigned to outer variable outer\)`
+			h.lm = m    // want `LockedMap escapes its locked callback \(stored in h.lm\)`
+			ch <- m     // want `LockedMap escapes its locked callback \(sent on a channel\)`
+			go func() { // want `LockedMap escapes its locked callback \(used by a goroutine\)`
+				m.Store("b", 2)
+			}()
+		},
+	)
+
+	_ = sm.DoLockedWithResult(
+		func(m syncmap.LockedMap[string, int]) any {
+			return m // want `LockedMap escapes its locked callback \(returned\)`
+		},
+	)
+
+	outer.Store("c", 3)
+
+	_ = sm.DoLockedWithResult(
+		func(m syncmap.LockedMap[string, int]) any {
+			v, _ := m.Load("a")
+			return v
+		},
+	)
+}
@@ -0,0 +1,23 @@
+// Package syncmap is a minimal stub of github.com/antst/go-syncmap for analyzer tests.
+package syncmap
+
+type SyncMap[K comparable, V any] struct{ data map[K]V }
+
+type ReadOnlyLockedMap[K comparable, V any] interface {
+	Load(key K) (V, bool)
+}
+
+type LockedMap[K comparable, V any] interface {
+	Load(key K) (V, bool)
+	Store(key K, value V)
+}
+
+func New[K comparable, V any](size int) *SyncMap[K, V] { return nil }
+
+func (m *SyncMap[K, V]) Load(k K) (V, bool)                                      { var v V; return v, false }
+func (m *SyncMap[K, V]) Store(k K, v V)                                          {}
+func (m *SyncMap[K, V]) Len() int                                                { return 0 }
+func (m *SyncMap[K, V]) DoLocked(f func(LockedMap[K, V]))                        {}
+func (m *SyncMap[K, V]) DoLockedWithResult(f func(LockedMap[K, V]) any) any      { return nil }
+func (m *SyncMap[K, V]) RangeMut(f func(m LockedMap[K, V], key K, value V) bool) {}
+func (m *SyncMap[K, V]) DoRLocked(f func(ReadOnlyLockedMap[K, V]))               {}
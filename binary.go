@@ -0,0 +1,144 @@
+package syncmap
+
+import (
+	"encoding"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/antst/go-syncmap/keycodec"
+)
+
+// to complain if SyncMap does not implement the interfaces
+var (
+	_ encoding.BinaryMarshaler   = (*SyncMap[string, any])(nil)
+	_ encoding.BinaryUnmarshaler = (*SyncMap[string, any])(nil)
+)
+
+// The binary encoding of a map is the magic bytes and the version of the format,
+// followed by the number of entries as a uvarint, then every key and value as a uvarint length
+// followed by the bytes produced by the codecs set WithBinaryCodec.
+const (
+	binaryMagic   = "SMAP"
+	binaryVersion = 1
+)
+
+// WithBinaryCodec sets the codecs used by MarshalBinary and UnmarshalBinary to encode the keys and values
+// of the map, e.g. keycodec.String[string]() and keycodec.Int[int](). Any keycodec.Codec can be used,
+// including codecs for the values that are not order-preserving.
+func WithBinaryCodec[K comparable, V any](keys keycodec.Codec[K], values keycodec.Codec[V]) Option[K, V] {
+	return func(m *SyncMap[K, V]) {
+		m.binaryKeys = keys
+		m.binaryValues = values
+	}
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler with a compact, versioned format
+// using the codecs set WithBinaryCodec, for fast snapshots of large maps where JSON is too slow.
+// It returns ErrNoBinaryCodec if the map was created without WithBinaryCodec.
+// The entries are encoded under a read lock, so the codecs must not use the map.
+func (m *SyncMap[K, V]) MarshalBinary() ([]byte, error) {
+	if m.binaryKeys == nil || m.binaryValues == nil {
+		return nil, ErrNoBinaryCodec
+	}
+
+	m.rlock()
+	defer m.runlock()
+
+	n := m.count()
+	buf := make([]byte, 0, len(binaryMagic)+1+binary.MaxVarintLen64+n*16)
+	buf = append(buf, binaryMagic...)
+	buf = append(buf, binaryVersion)
+	buf = binary.AppendUvarint(buf, uint64(n))
+
+	var scratch []byte
+	for k, v := range m.entries() {
+		scratch = m.binaryKeys.Append(scratch[:0], k)
+		buf = binary.AppendUvarint(buf, uint64(len(scratch)))
+		buf = append(buf, scratch...)
+
+		scratch = m.binaryValues.Append(scratch[:0], v)
+		buf = binary.AppendUvarint(buf, uint64(len(scratch)))
+		buf = append(buf, scratch...)
+	}
+	return buf, nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler, replacing the contents of the map with
+// the entries encoded by MarshalBinary. It returns ErrNoBinaryCodec if the map was created without
+// WithBinaryCodec, and an error wrapping ErrBinaryFormat if the data is not valid.
+// The data is decoded before the map is locked, and the contents are then replaced atomically,
+// under a single write lock, as with ReplaceAll: if the data is not valid, the map is left unchanged.
+// The pairs rejected by the map are skipped and reported in the returned error.
+func (m *SyncMap[K, V]) UnmarshalBinary(b []byte) error {
+	if m.binaryKeys == nil || m.binaryValues == nil {
+		return ErrNoBinaryCodec
+	}
+
+	data, err := decodeBinary(b, m.binaryKeys, m.binaryValues)
+	if err != nil {
+		return err
+	}
+
+	m.lock()
+	defer m.unlock()
+
+	return m.replace(data)
+}
+
+// decodeBinary decodes the entries encoded by MarshalBinary.
+func decodeBinary[K comparable, V any](b []byte, keys keycodec.Codec[K], values keycodec.Codec[V]) (map[K]V, error) {
+	if len(b) < len(binaryMagic)+1 || string(b[:len(binaryMagic)]) != binaryMagic {
+		return nil, fmt.Errorf("%w: bad header", ErrBinaryFormat)
+	}
+	if version := b[len(binaryMagic)]; version != binaryVersion {
+		return nil, fmt.Errorf("%w: unsupported version %d", ErrBinaryFormat, version)
+	}
+	b = b[len(binaryMagic)+1:]
+
+	n, size := binary.Uvarint(b)
+	if size <= 0 {
+		return nil, fmt.Errorf("%w: bad entry count", ErrBinaryFormat)
+	}
+	b = b[size:]
+
+	// every entry takes at least 2 bytes, do not trust the count for the allocation
+	data := make(map[K]V, min(n, uint64(len(b)/2)))
+	for i := uint64(0); i < n; i++ {
+		var kb, vb []byte
+		var err error
+		if kb, b, err = nextBinaryField(b); err != nil {
+			return nil, fmt.Errorf("%w: key of entry %d: %v", ErrBinaryFormat, i, err)
+		}
+		if vb, b, err = nextBinaryField(b); err != nil {
+			return nil, fmt.Errorf("%w: value of entry %d: %v", ErrBinaryFormat, i, err)
+		}
+
+		k, err := keycodec.Decode(keys, kb)
+		if err != nil {
+			return nil, fmt.Errorf("%w: key of entry %d: %w", ErrBinaryFormat, i, err)
+		}
+		v, err := keycodec.Decode(values, vb)
+		if err != nil {
+			return nil, fmt.Errorf("%w: value of entry %d: %w", ErrBinaryFormat, i, err)
+		}
+		data[k] = v
+	}
+	if len(b) != 0 {
+		return nil, fmt.Errorf("%w: %d trailing bytes", ErrBinaryFormat, len(b))
+	}
+	return data, nil
+}
+
+// nextBinaryField splits the length-prefixed field at the beginning of b from the bytes that follow it.
+func nextBinaryField(b []byte) (field, rest []byte, err error) {
+	n, size := binary.Uvarint(b)
+	if size <= 0 {
+		return nil, b, errors.New("bad length")
+	}
+	b = b[size:]
+	if n > uint64(len(b)) {
+		return nil, b, fmt.Errorf("length %d exceeds the %d remaining bytes", n, len(b))
+	}
+	return b[:n], b[n:], nil
+}
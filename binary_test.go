@@ -0,0 +1,117 @@
+package syncmap
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/antst/go-syncmap/keycodec"
+)
+
+func TestSyncMapBinary(t *testing.T) {
+	newMap := func() *SyncMap[string, int] {
+		return New[string, int](10, WithBinaryCodec[string, int](keycodec.String[string](), keycodec.Int[int]()))
+	}
+
+	t.Run(
+		"RoundTrip", func(t *testing.T) {
+			sm := newMap()
+			for i := range 1000 {
+				sm.Store(string(rune('a'+i%26))+string(rune('A'+i/26)), i-500)
+			}
+
+			b, err := sm.MarshalBinary()
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+
+			restored := newMap()
+			restored.Store("stale", 1)
+			if err := restored.UnmarshalBinary(b); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if !Equal(sm, restored) {
+				t.Errorf("Expected the contents to be replaced, got %d entries", restored.Len())
+			}
+		},
+	)
+
+	t.Run(
+		"Empty", func(t *testing.T) {
+			b, err := newMap().MarshalBinary()
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			restored := newMap()
+			if err := restored.UnmarshalBinary(b); err != nil || restored.Len() != 0 {
+				t.Errorf("Expected an empty map, got %d entries and %v", restored.Len(), err)
+			}
+		},
+	)
+
+	t.Run(
+		"NoCodec", func(t *testing.T) {
+			sm := New[string, int](10)
+			if _, err := sm.MarshalBinary(); !errors.Is(err, ErrNoBinaryCodec) {
+				t.Errorf("Expected ErrNoBinaryCodec, got %v", err)
+			}
+			if err := sm.UnmarshalBinary(nil); !errors.Is(err, ErrNoBinaryCodec) {
+				t.Errorf("Expected ErrNoBinaryCodec, got %v", err)
+			}
+		},
+	)
+
+	t.Run(
+		"Invalid", func(t *testing.T) {
+			sm := newMap()
+			sm.Store("a", 1)
+			b, _ := sm.MarshalBinary()
+
+			version := append([]byte(nil), b...)
+			version[len(binaryMagic)] = 99
+			for name, data := range map[string][]byte{
+				"Header":    []byte("JSON{}"),
+				"Version":   version,
+				"Truncated": b[:len(b)-1],
+				"Trailing":  append(append([]byte(nil), b...), 0),
+				"Count":     append([]byte(binaryMagic+"\x01"), 0xff),
+			} {
+				restored := newMap()
+				restored.Store("kept", 1)
+				if err := restored.UnmarshalBinary(data); !errors.Is(err, ErrBinaryFormat) {
+					t.Errorf("Expected ErrBinaryFormat for %s, got %v", name, err)
+				}
+				if _, ok := restored.Load("kept"); !ok || restored.Len() != 1 {
+					t.Errorf("Expected the map to be unchanged for %s", name)
+				}
+			}
+		},
+	)
+
+	t.Run(
+		"InvalidValue", func(t *testing.T) {
+			sm := New[string, string](10, WithBinaryCodec[string, string](keycodec.String[string](), keycodec.String[string]()))
+			sm.Store("a", "short")
+			b, _ := sm.MarshalBinary()
+
+			restored := newMap()
+			err := restored.UnmarshalBinary(b)
+			if !errors.Is(err, ErrBinaryFormat) || !errors.Is(err, keycodec.ErrInvalidEncoding) {
+				t.Errorf("Expected ErrBinaryFormat wrapping the codec error, got %v", err)
+			}
+		},
+	)
+}
+
+func BenchmarkSyncMapMarshalBinary(b *testing.B) {
+	sm := New[int, int](100_000, WithBinaryCodec[int, int](keycodec.Int[int](), keycodec.Int[int]()))
+	for i := range 100_000 {
+		sm.Store(i, i)
+	}
+
+	b.ResetTimer()
+	for range b.N {
+		if _, err := sm.MarshalBinary(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
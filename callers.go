@@ -0,0 +1,137 @@
+package syncmap
+
+import (
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// pkgPrefix is the prefix of the fully qualified names of functions in this package.
+const pkgPrefix = "github.com/antst/go-syncmap."
+
+// CallerStat is the number of sampled calls of one SyncMap operation made from one call site.
+type CallerStat struct {
+	// Op is the name of the SyncMap method or package function that was called, e.g. "Load".
+	Op string
+	// Function is the fully qualified name of the calling function.
+	Function string
+	File     string
+	Line     int
+	// Samples is the number of sampled calls. Multiply by the sampling interval to estimate the total.
+	Samples uint64
+}
+
+// WithCallerAttribution enables sampling of the call sites that operate on the SyncMap:
+// one in every `every` lock acquisitions records the calling operation and call site,
+// and the aggregated results are available from CallerStats.
+// This shows which code paths generate the most load on a map shared by many packages.
+// Capturing call stacks is expensive, so every should be large enough for hot maps.
+func WithCallerAttribution[K comparable, V any](every int) Option[K, V] {
+	return func(m *SyncMap[K, V]) {
+		if every < 1 {
+			every = 1
+		}
+		m.callers = &callerStats{every: uint64(every), sites: make(map[callSite]uint64)}
+	}
+}
+
+// CallerStats returns the sampled call sites of a SyncMap created WithCallerAttribution,
+// ordered by the number of samples, most frequent first.
+// It returns nil if caller attribution is not enabled.
+func (m *SyncMap[K, V]) CallerStats() []CallerStat {
+	if m.callers == nil {
+		return nil
+	}
+	return m.callers.snapshot()
+}
+
+type callSite struct {
+	op       string
+	function string
+	file     string
+	line     int
+}
+
+type callerStats struct {
+	every uint64
+	calls atomic.Uint64
+
+	mu    sync.Mutex
+	sites map[callSite]uint64
+}
+
+// attribute samples the call site of the operation acquiring a lock, if caller attribution is enabled.
+func (m *SyncMap[K, V]) attribute() {
+	if m.callers == nil || m.callers.calls.Add(1)%m.callers.every != 0 {
+		return
+	}
+	m.callers.record()
+}
+
+func (c *callerStats) record() {
+	pcs := make([]uintptr, 32)
+	n := runtime.Callers(3, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+
+	// the innermost frames are within this package; the last of them is the operation,
+	// the first frame outside of it (test files of this package included) is the caller.
+	var site callSite
+	for {
+		frame, more := frames.Next()
+		if strings.HasPrefix(frame.Function, pkgPrefix) && !strings.HasSuffix(frame.File, "_test.go") {
+			site.op = opName(frame.Function)
+		} else {
+			site.function, site.file, site.line = frame.Function, frame.File, frame.Line
+			break
+		}
+		if !more {
+			break
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.sites[site]++
+}
+
+func (c *callerStats) snapshot() []CallerStat {
+	c.mu.Lock()
+	stats := make([]CallerStat, 0, len(c.sites))
+	for site, samples := range c.sites {
+		stats = append(
+			stats, CallerStat{
+				Op: site.op, Function: site.function, File: site.file, Line: site.line, Samples: samples,
+			},
+		)
+	}
+	c.mu.Unlock()
+
+	sort.Slice(
+		stats, func(i, j int) bool {
+			if stats[i].Samples != stats[j].Samples {
+				return stats[i].Samples > stats[j].Samples
+			}
+			return stats[i].Function < stats[j].Function
+		},
+	)
+
+	return stats
+}
+
+// opName extracts the method or function name from a fully qualified function name,
+// e.g. "github.com/antst/go-syncmap.(*SyncMap[...]).Load" becomes "Load".
+// Closures are named after their enclosing function.
+func opName(function string) string {
+	name := strings.TrimPrefix(function, pkgPrefix)
+	if strings.HasPrefix(name, "(") {
+		if i := strings.Index(name, ")."); i >= 0 {
+			name = name[i+2:]
+		}
+	}
+	if i := strings.IndexAny(name, "[."); i >= 0 {
+		name = name[:i]
+	}
+	return name
+}
@@ -0,0 +1,70 @@
+package syncmap
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWithCallerAttribution(t *testing.T) {
+	sm := New[string, int](10, WithCallerAttribution[string, int](1))
+
+	for i := 0; i < 3; i++ {
+		sm.Store("key1", i)
+	}
+	sm.Load("key1")
+	Sum(sm)
+
+	stats := sm.CallerStats()
+	if len(stats) != 3 {
+		t.Fatalf("Expected 3 call sites, got %d: %+v", len(stats), stats)
+	}
+
+	if stats[0].Op != "Store" || stats[0].Samples != 3 {
+		t.Errorf("Expected 3 samples of Store first, got %+v", stats[0])
+	}
+
+	ops := make(map[string]bool)
+	for _, s := range stats {
+		ops[s.Op] = true
+		if !strings.HasSuffix(s.Function, "TestWithCallerAttribution") {
+			t.Errorf("Expected the test function as caller, got %s", s.Function)
+		}
+		if !strings.HasSuffix(s.File, "callers_test.go") || s.Line == 0 {
+			t.Errorf("Unexpected call site %s:%d", s.File, s.Line)
+		}
+	}
+	if !ops["Load"] || !ops["Sum"] {
+		t.Errorf("Expected Load and Sum to be attributed, got %+v", stats)
+	}
+
+	if New[string, int](10).CallerStats() != nil {
+		t.Error("Expected no stats when attribution is disabled")
+	}
+}
+
+func TestWithCallerAttributionSampling(t *testing.T) {
+	sm := New[string, int](10, WithCallerAttribution[string, int](10))
+	for i := 0; i < 100; i++ {
+		sm.Load("key1")
+	}
+
+	stats := sm.CallerStats()
+	if len(stats) != 1 || stats[0].Samples != 10 {
+		t.Errorf("Expected 10 samples, got %+v", stats)
+	}
+}
+
+func TestOpName(t *testing.T) {
+	cases := map[string]string{
+		"github.com/antst/go-syncmap.(*SyncMap[...]).Load":                           "Load",
+		"github.com/antst/go-syncmap.(*SyncMap[go.shape.string,go.shape.int]).Store": "Store",
+		"github.com/antst/go-syncmap.Sum[...]":                                       "Sum",
+		"github.com/antst/go-syncmap.Reduce[...].func1":                              "Reduce",
+		"github.com/antst/go-syncmap.SnapshotGroup":                                  "SnapshotGroup",
+	}
+	for in, expected := range cases {
+		if actual := opName(in); actual != expected {
+			t.Errorf("opName(%q): expected %q, got %q", in, expected, actual)
+		}
+	}
+}
@@ -0,0 +1,20 @@
+// Command syncmapvet runs the lockedmapcheck analyzer.
+//
+// It can be used standalone:
+//
+//	syncmapvet ./...
+//
+// or as a vet tool:
+//
+//	go vet -vettool=$(which syncmapvet) ./...
+package main
+
+import (
+	"golang.org/x/tools/go/analysis/singlechecker"
+
+	"github.com/antst/go-syncmap/analysis/lockedmapcheck"
+)
+
+func main() {
+	singlechecker.Main(lockedmapcheck.Analyzer)
+}
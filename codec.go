@@ -0,0 +1,43 @@
+package syncmap
+
+import (
+	"maps"
+)
+
+// Codec is a serialization format for the contents of a map, see MarshalWith.
+// Its methods have the signatures of the Marshal and Unmarshal functions of most encoding packages,
+// so that they are easily adapted. The msgpack and cbor packages under codec/ provide
+// MessagePack and CBOR implementations without dependencies.
+type Codec interface {
+	// Encode returns the encoding of v, which is a map[K]V.
+	Encode(v any) ([]byte, error)
+	// Decode decodes data into v, which is a pointer to a map[K]V.
+	Decode(data []byte, v any) error
+}
+
+// MarshalWith returns the encoding of the entries of the map in the format of codec,
+// to exchange snapshots with services that cannot consume gob, for instance.
+// The entries are copied under a read lock and encoded once it is released.
+func (m *SyncMap[K, V]) MarshalWith(codec Codec) ([]byte, error) {
+	m.rlock()
+	data := maps.Collect(m.entries())
+	m.runlock()
+
+	return codec.Encode(data)
+}
+
+// UnmarshalWith replaces the contents of the map with the entries decoded from data in the format of codec.
+// The data is decoded before the map is locked, and the contents are then replaced atomically,
+// under a single write lock, as with ReplaceAll: if the data is not valid, the map is left unchanged.
+// The pairs rejected by the map are skipped and reported in the returned error.
+func (m *SyncMap[K, V]) UnmarshalWith(codec Codec, data []byte) error {
+	var decoded map[K]V
+	if err := codec.Decode(data, &decoded); err != nil {
+		return err
+	}
+
+	m.lock()
+	defer m.unlock()
+
+	return m.replace(decoded)
+}
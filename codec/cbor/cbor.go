@@ -0,0 +1,261 @@
+package cbor
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/antst/go-syncmap/codec/internal/wire"
+)
+
+// ErrInvalid is returned when decoding data that is not valid CBOR or uses unsupported features.
+var ErrInvalid = errors.New("cbor: invalid data")
+
+// The major types of CBOR data items.
+const (
+	majorUint   = 0 << 5
+	majorNegInt = 1 << 5
+	majorBytes  = 2 << 5
+	majorText   = 3 << 5
+	majorArray  = 4 << 5
+	majorMap    = 5 << 5
+	majorTag    = 6 << 5
+	majorSimple = 7 << 5
+)
+
+// Codec encodes values as CBOR. Its zero value is ready to use.
+type Codec struct{}
+
+// Encode returns the CBOR encoding of v.
+func (Codec) Encode(v any) ([]byte, error) {
+	return Marshal(v)
+}
+
+// Decode decodes the CBOR data into v, which must be a non-nil pointer.
+func (Codec) Decode(data []byte, v any) error {
+	return Unmarshal(data, v)
+}
+
+// Marshal returns the CBOR encoding of v.
+func Marshal(v any) ([]byte, error) {
+	var w writer
+	if err := wire.Encode(&w, v, "cbor"); err != nil {
+		return nil, fmt.Errorf("cbor: %w", err)
+	}
+	return w.buf, nil
+}
+
+// Unmarshal decodes the CBOR data into v, which must be a non-nil pointer.
+// data must hold exactly one data item.
+func Unmarshal(data []byte, v any) error {
+	r := reader{buf: data}
+	if err := wire.Decode(&r, v, "cbor"); err != nil {
+		if errors.Is(err, ErrInvalid) {
+			return err
+		}
+		return fmt.Errorf("cbor: %w", err)
+	}
+	if len(r.buf) != 0 {
+		return fmt.Errorf("%w: %d trailing bytes", ErrInvalid, len(r.buf))
+	}
+	return nil
+}
+
+type writer struct {
+	buf []byte
+}
+
+func (w *writer) Nil() {
+	w.buf = append(w.buf, majorSimple|22)
+}
+
+func (w *writer) Bool(v bool) {
+	if v {
+		w.buf = append(w.buf, majorSimple|21)
+	} else {
+		w.buf = append(w.buf, majorSimple|20)
+	}
+}
+
+func (w *writer) Int(v int64) {
+	// negative integers are encoded as -1-n
+	w.head(majorNegInt, uint64(-1-v))
+}
+
+func (w *writer) Uint(v uint64) {
+	w.head(majorUint, v)
+}
+
+func (w *writer) Float32(v float32) {
+	w.buf = binary.BigEndian.AppendUint32(append(w.buf, majorSimple|26), math.Float32bits(v))
+}
+
+func (w *writer) Float64(v float64) {
+	w.buf = binary.BigEndian.AppendUint64(append(w.buf, majorSimple|27), math.Float64bits(v))
+}
+
+func (w *writer) String(v string) {
+	w.head(majorText, uint64(len(v)))
+	w.buf = append(w.buf, v...)
+}
+
+func (w *writer) Bytes(v []byte) {
+	w.head(majorBytes, uint64(len(v)))
+	w.buf = append(w.buf, v...)
+}
+
+func (w *writer) ArrayHeader(n int) {
+	w.head(majorArray, uint64(n))
+}
+
+func (w *writer) MapHeader(n int) {
+	w.head(majorMap, uint64(n))
+}
+
+// head appends the initial bytes of a data item of the given major type with the argument n,
+// in the shortest form, which is the preferred serialization of RFC 8949.
+func (w *writer) head(major byte, n uint64) {
+	switch {
+	case n < 24:
+		w.buf = append(w.buf, major|byte(n))
+	case n <= math.MaxUint8:
+		w.buf = append(w.buf, major|24, byte(n))
+	case n <= math.MaxUint16:
+		w.buf = binary.BigEndian.AppendUint16(append(w.buf, major|25), uint16(n))
+	case n <= math.MaxUint32:
+		w.buf = binary.BigEndian.AppendUint32(append(w.buf, major|26), uint32(n))
+	default:
+		w.buf = binary.BigEndian.AppendUint64(append(w.buf, major|27), n)
+	}
+}
+
+type reader struct {
+	buf []byte
+}
+
+func (r *reader) Remaining() int {
+	return len(r.buf)
+}
+
+func (r *reader) Next() (wire.Token, error) {
+	for {
+		b, err := r.take(1)
+		if err != nil {
+			return wire.Token{}, err
+		}
+		major, info := b[0]&0xe0, b[0]&0x1f
+
+		if major == majorSimple {
+			return r.simple(info)
+		}
+		n, err := r.argument(info)
+		if err != nil {
+			return wire.Token{}, err
+		}
+
+		switch major {
+		case majorUint:
+			return wire.Token{Kind: wire.Uint, Uint: n}, nil
+		case majorNegInt:
+			if n > math.MaxInt64 {
+				return wire.Token{}, fmt.Errorf("%w: negative integer overflows int64", ErrInvalid)
+			}
+			return wire.Token{Kind: wire.Int, Int: -1 - int64(n)}, nil
+		case majorBytes, majorText:
+			if n > uint64(len(r.buf)) {
+				return wire.Token{}, fmt.Errorf("%w: unexpected end of data", ErrInvalid)
+			}
+			kind := wire.Bytes
+			if major == majorText {
+				kind = wire.String
+			}
+			v, _ := r.take(int(n))
+			return wire.Token{Kind: kind, Bytes: v}, nil
+		case majorArray, majorMap:
+			if n > math.MaxInt32 {
+				return wire.Token{}, fmt.Errorf("%w: length %d is too large", ErrInvalid, n)
+			}
+			if major == majorArray {
+				return wire.Token{Kind: wire.Array, Len: int(n)}, nil
+			}
+			return wire.Token{Kind: wire.Map, Len: int(n)}, nil
+		case majorTag:
+			// tags only give a meaning to the data item that follows, which is decoded as is
+			continue
+		}
+	}
+}
+
+// argument reads the argument of a data item whose additional information is info.
+func (r *reader) argument(info byte) (uint64, error) {
+	switch {
+	case info < 24:
+		return uint64(info), nil
+	case info <= 27:
+		b, err := r.take(1 << (info - 24))
+		if err != nil {
+			return 0, err
+		}
+		var n uint64
+		for _, c := range b {
+			n = n<<8 | uint64(c)
+		}
+		return n, nil
+	case info == 31:
+		return 0, fmt.Errorf("%w: indefinite lengths are not supported", ErrInvalid)
+	}
+	return 0, fmt.Errorf("%w: reserved additional information %d", ErrInvalid, info)
+}
+
+// simple reads the simple value or float whose additional information is info.
+func (r *reader) simple(info byte) (wire.Token, error) {
+	switch info {
+	case 20, 21:
+		return wire.Token{Kind: wire.Bool, Bool: info == 21}, nil
+	case 22, 23:
+		// null and undefined
+		return wire.Token{Kind: wire.Nil}, nil
+	case 25:
+		n, err := r.argument(info)
+		return wire.Token{Kind: wire.Float, Float: float16(uint16(n))}, err
+	case 26:
+		n, err := r.argument(info)
+		return wire.Token{Kind: wire.Float, Float: float64(math.Float32frombits(uint32(n)))}, err
+	case 27:
+		n, err := r.argument(info)
+		return wire.Token{Kind: wire.Float, Float: math.Float64frombits(n)}, err
+	}
+	return wire.Token{}, fmt.Errorf("%w: unsupported simple value %d", ErrInvalid, info)
+}
+
+// float16 converts an IEEE 754 half-precision float to a float64.
+func float16(h uint16) float64 {
+	sign := 1.0
+	if h&0x8000 != 0 {
+		sign = -1
+	}
+	exp := int(h>>10) & 0x1f
+	mant := float64(h & 0x3ff)
+
+	switch exp {
+	case 0:
+		return sign * math.Ldexp(mant, -24)
+	case 0x1f:
+		if mant == 0 {
+			return math.Inf(int(sign))
+		}
+		return math.NaN()
+	}
+	return sign * math.Ldexp(mant+1024, exp-25)
+}
+
+// take consumes the next n bytes.
+func (r *reader) take(n int) ([]byte, error) {
+	if n > len(r.buf) {
+		return nil, fmt.Errorf("%w: unexpected end of data", ErrInvalid)
+	}
+	b := r.buf[:n]
+	r.buf = r.buf[n:]
+	return b, nil
+}
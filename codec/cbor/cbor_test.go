@@ -0,0 +1,132 @@
+package cbor
+
+import (
+	"encoding/hex"
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+)
+
+// The vectors below are taken from Appendix A of RFC 8949.
+
+func TestMarshal(t *testing.T) {
+	for _, tc := range []struct {
+		v        any
+		expected string
+	}{
+		{0, "00"},
+		{23, "17"},
+		{24, "1818"},
+		{100, "1864"},
+		{1000, "1903e8"},
+		{1000000, "1a000f4240"},
+		{uint64(1000000000000), "1b000000e8d4a51000"},
+		{uint64(math.MaxUint64), "1bffffffffffffffff"},
+		{-1, "20"},
+		{-100, "3863"},
+		{-1000, "3903e7"},
+		{1.1, "fb3ff199999999999a"},
+		{float32(100000), "fa47c35000"},
+		{false, "f4"},
+		{true, "f5"},
+		{nil, "f6"},
+		{"", "60"},
+		{"a", "6161"},
+		{"IETF", "6449455446"},
+		{"ü", "62c3bc"},
+		{[]byte{1, 2, 3, 4}, "4401020304"},
+		{[]int{}, "80"},
+		{[]int{1, 2, 3}, "83010203"},
+		{[]any{1, []int{2, 3}, []int{4, 5}}, "8301820203820405"},
+		{map[string]int{"a": 1}, "a1616101"},
+		{struct {
+			B []int `cbor:"b"`
+		}{[]int{2, 3}}, "a16162820203"},
+	} {
+		b, err := Marshal(tc.v)
+		if err != nil {
+			t.Fatalf("Expected no error for %v, got %v", tc.v, err)
+		}
+		if hex.EncodeToString(b) != tc.expected {
+			t.Errorf("Expected %s for %#v, got %x", tc.expected, tc.v, b)
+		}
+	}
+}
+
+func TestUnmarshal(t *testing.T) {
+	for _, tc := range []struct {
+		data     string
+		expected any
+	}{
+		{"1bffffffffffffffff", uint64(math.MaxUint64)},
+		{"f93c00", 1.0},
+		{"f97bff", 65504.0},
+		{"f90001", 5.960464477539063e-8},
+		{"f9fc00", math.Inf(-1)},
+		{"fa47c35000", 100000.0},
+		{"f7", nil}, // undefined
+		{"c074323031332d30332d32315432303a30343a30305a", "2013-03-21T20:04:00Z"},
+		{"a201020304", map[any]any{int64(1): int64(2), int64(3): int64(4)}},
+		{"a26161016162820203", map[string]any{"a": int64(1), "b": []any{int64(2), int64(3)}}},
+	} {
+		b, _ := hex.DecodeString(tc.data)
+		var v any
+		err := Unmarshal(b, &v)
+		if err != nil {
+			t.Fatalf("Expected no error for %s, got %v", tc.data, err)
+		}
+		if !reflect.DeepEqual(v, tc.expected) {
+			t.Errorf("Expected %#v for %s, got %#v", tc.expected, tc.data, v)
+		}
+	}
+
+	var f float64
+	if err := Unmarshal([]byte{0xf9, 0x7e, 0x00}, &f); err != nil || !math.IsNaN(f) {
+		t.Errorf("Expected NaN, got %v and %v", f, err)
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	type item struct {
+		ID    int64  `cbor:"id"`
+		Label string `cbor:"label"`
+		Data  []byte `cbor:"data"`
+		Next  *item  `cbor:"next"`
+	}
+
+	in := map[string]item{
+		"a": {ID: -5, Label: "first", Data: []byte{0xff}, Next: &item{ID: 1 << 40}},
+		"b": {},
+	}
+	b, err := (Codec{}).Encode(in)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	var out map[string]item
+	if err := (Codec{}).Decode(b, &out); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !reflect.DeepEqual(in, out) {
+		t.Errorf("Expected %+v, got %+v", in, out)
+	}
+}
+
+func TestUnmarshalErrors(t *testing.T) {
+	for name, data := range map[string]string{
+		"Empty":      "",
+		"Truncated":  "1903",
+		"String":     "644945",
+		"Trailing":   "0101",
+		"Indefinite": "9fff",
+		"Reserved":   "1c",
+		"Simple":     "f8ff",
+		"Overflow":   "3bffffffffffffffff",
+	} {
+		b, _ := hex.DecodeString(data)
+		var v any
+		if err := Unmarshal(b, &v); !errors.Is(err, ErrInvalid) {
+			t.Errorf("Expected ErrInvalid for %s, got %v", name, err)
+		}
+	}
+}
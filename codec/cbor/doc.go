@@ -0,0 +1,13 @@
+// Package cbor implements the CBOR serialization format (RFC 8949),
+// for exchanging SyncMap snapshots with services that are not written in Go, see syncmap.SyncMap.MarshalWith.
+//
+// It has no dependencies and supports the types that have a natural CBOR representation:
+// booleans, integers, floats, strings, byte slices, slices, arrays, maps, pointers, and structs,
+// which are encoded as maps of their exported fields, named after their `cbor` tag if they have one.
+// Types implementing encoding.TextMarshaler, such as time.Time, are encoded as text strings.
+// Lengths are always definite and encoded in the shortest form. When decoding, tags are ignored,
+// and indefinite-length items are not supported.
+//
+// Values decoded into interfaces are int64 (or uint64 if they do not fit), float64, string, []byte, []any,
+// and map[string]any (or map[any]any if some keys are not strings).
+package cbor
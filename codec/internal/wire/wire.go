@@ -0,0 +1,453 @@
+// Package wire maps Go values to and from the data model shared by self-describing binary formats
+// such as MessagePack and CBOR: nil, booleans, integers, floats, strings, byte strings, arrays and maps.
+// The formats only implement the encoding of these primitives, see Writer and Reader.
+package wire
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+)
+
+// maxDepth bounds the nesting of decoded values, so that malicious input cannot exhaust the stack.
+const maxDepth = 1000
+
+// ErrUnsupportedType is returned when encoding a value of a type that has no representation in the data model.
+var ErrUnsupportedType = errors.New("unsupported type")
+
+// Writer appends the encoding of primitives to a buffer.
+type Writer interface {
+	Nil()
+	Bool(v bool)
+	Int(v int64)
+	Uint(v uint64)
+	Float32(v float32)
+	Float64(v float64)
+	String(v string)
+	Bytes(v []byte)
+	// ArrayHeader starts an array of n values, which are written next.
+	ArrayHeader(n int)
+	// MapHeader starts a map of n pairs, whose keys and values are written next, alternately.
+	MapHeader(n int)
+}
+
+// Kind is the kind of a Token.
+type Kind int
+
+const (
+	Nil Kind = iota
+	Bool
+	Int
+	Uint
+	Float
+	String
+	Bytes
+	Array
+	Map
+)
+
+// Token is a primitive read by a Reader.
+type Token struct {
+	Kind Kind
+	// Bool holds the value of a Bool token.
+	Bool bool
+	// Int holds the value of an Int token, which is only used for negative integers.
+	Int int64
+	// Uint holds the value of a Uint token.
+	Uint uint64
+	// Float holds the value of a Float token.
+	Float float64
+	// Bytes holds the contents of a String or Bytes token. It may alias the input of the Reader.
+	Bytes []byte
+	// Len is the number of values of an Array token or of pairs of a Map token.
+	Len int
+}
+
+// Reader reads the primitives of an encoded value one after another.
+type Reader interface {
+	// Next returns the next primitive. The values of arrays and the pairs of maps follow their header.
+	Next() (Token, error)
+	// Remaining returns the number of bytes left, which bounds the length of arrays and maps.
+	Remaining() int
+}
+
+// Encode writes v to w. tag is the name of the struct tag that renames fields, e.g. "msgpack".
+func Encode(w Writer, v any, tag string) error {
+	return encode(w, reflect.ValueOf(v), tag)
+}
+
+var textMarshaler = reflect.TypeFor[encoding.TextMarshaler]()
+
+func encode(w Writer, rv reflect.Value, tag string) error {
+	if !rv.IsValid() {
+		w.Nil()
+		return nil
+	}
+	if rv.Type().Implements(textMarshaler) && (rv.Kind() != reflect.Pointer || !rv.IsNil()) {
+		text, err := rv.Interface().(encoding.TextMarshaler).MarshalText()
+		if err != nil {
+			return err
+		}
+		w.String(string(text))
+		return nil
+	}
+
+	switch rv.Kind() {
+	case reflect.Bool:
+		w.Bool(rv.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if i := rv.Int(); i < 0 {
+			w.Int(i)
+		} else {
+			w.Uint(uint64(i))
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		w.Uint(rv.Uint())
+	case reflect.Float32:
+		w.Float32(float32(rv.Float()))
+	case reflect.Float64:
+		w.Float64(rv.Float())
+	case reflect.String:
+		w.String(rv.String())
+	case reflect.Pointer, reflect.Interface:
+		if rv.IsNil() {
+			w.Nil()
+			return nil
+		}
+		return encode(w, rv.Elem(), tag)
+	case reflect.Slice:
+		if rv.IsNil() {
+			w.Nil()
+			return nil
+		}
+		if rv.Type().Elem().Kind() == reflect.Uint8 {
+			w.Bytes(rv.Bytes())
+			return nil
+		}
+		fallthrough
+	case reflect.Array:
+		w.ArrayHeader(rv.Len())
+		for i := range rv.Len() {
+			if err := encode(w, rv.Index(i), tag); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		if rv.IsNil() {
+			w.Nil()
+			return nil
+		}
+		w.MapHeader(rv.Len())
+		for it := rv.MapRange(); it.Next(); {
+			if err := encode(w, it.Key(), tag); err != nil {
+				return err
+			}
+			if err := encode(w, it.Value(), tag); err != nil {
+				return err
+			}
+		}
+	case reflect.Struct:
+		fields := fieldsOf(rv.Type(), tag)
+		w.MapHeader(len(fields))
+		for _, f := range fields {
+			w.String(f.name)
+			if err := encode(w, rv.Field(f.index), tag); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("%w: %s", ErrUnsupportedType, rv.Type())
+	}
+	return nil
+}
+
+// Decode reads a value from r into v, which must be a non-nil pointer.
+// tag is the name of the struct tag that renames fields, e.g. "msgpack".
+func Decode(r Reader, v any, tag string) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return fmt.Errorf("decode into %T: not a non-nil pointer", v)
+	}
+	d := decoder{r: r, tag: tag}
+	return d.decode(rv.Elem(), 0)
+}
+
+type decoder struct {
+	r   Reader
+	tag string
+}
+
+var textUnmarshaler = reflect.TypeFor[encoding.TextUnmarshaler]()
+
+func (d *decoder) decode(rv reflect.Value, depth int) error {
+	if depth > maxDepth {
+		return fmt.Errorf("values nested deeper than %d levels", maxDepth)
+	}
+
+	t, err := d.r.Next()
+	if err != nil {
+		return err
+	}
+	if (t.Kind == Array || t.Kind == Map) && t.Len > d.r.Remaining() {
+		return fmt.Errorf("length %d exceeds the %d remaining bytes", t.Len, d.r.Remaining())
+	}
+	return d.assign(rv, t, depth)
+}
+
+// assign stores the value starting with the token t into rv.
+func (d *decoder) assign(rv reflect.Value, t Token, depth int) error {
+	if t.Kind == Nil {
+		rv.SetZero()
+		return nil
+	}
+	if rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			rv.Set(reflect.New(rv.Type().Elem()))
+		}
+		return d.assign(rv.Elem(), t, depth)
+	}
+	if t.Kind == String && rv.CanAddr() && rv.Addr().Type().Implements(textUnmarshaler) {
+		return rv.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText(t.Bytes)
+	}
+
+	switch rv.Kind() {
+	case reflect.Interface:
+		if rv.NumMethod() != 0 {
+			break
+		}
+		v, err := d.generic(t, depth)
+		if err != nil {
+			return err
+		}
+		if v == nil {
+			rv.SetZero()
+		} else {
+			rv.Set(reflect.ValueOf(v))
+		}
+		return nil
+	case reflect.Bool:
+		if t.Kind == Bool {
+			rv.SetBool(t.Bool)
+			return nil
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, ok := int64(0), false
+		switch t.Kind {
+		case Int:
+			i, ok = t.Int, true
+		case Uint:
+			i, ok = int64(t.Uint), t.Uint <= math.MaxInt64
+		}
+		if ok && !rv.OverflowInt(i) {
+			rv.SetInt(i)
+			return nil
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if t.Kind == Uint && !rv.OverflowUint(t.Uint) {
+			rv.SetUint(t.Uint)
+			return nil
+		}
+	case reflect.Float32, reflect.Float64:
+		switch t.Kind {
+		case Float:
+			rv.SetFloat(t.Float)
+			return nil
+		case Int:
+			rv.SetFloat(float64(t.Int))
+			return nil
+		case Uint:
+			rv.SetFloat(float64(t.Uint))
+			return nil
+		}
+	case reflect.String:
+		if t.Kind == String || t.Kind == Bytes {
+			rv.SetString(string(t.Bytes))
+			return nil
+		}
+	case reflect.Slice:
+		if rv.Type().Elem().Kind() == reflect.Uint8 && (t.Kind == Bytes || t.Kind == String) {
+			rv.SetBytes(append([]byte{}, t.Bytes...))
+			return nil
+		}
+		if t.Kind == Array {
+			s := reflect.MakeSlice(rv.Type(), t.Len, t.Len)
+			for i := range t.Len {
+				if err := d.decode(s.Index(i), depth+1); err != nil {
+					return err
+				}
+			}
+			rv.Set(s)
+			return nil
+		}
+	case reflect.Array:
+		if t.Kind == Array && t.Len == rv.Len() {
+			for i := range t.Len {
+				if err := d.decode(rv.Index(i), depth+1); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+	case reflect.Map:
+		if t.Kind == Map {
+			m := reflect.MakeMapWithSize(rv.Type(), t.Len)
+			k := reflect.New(rv.Type().Key()).Elem()
+			v := reflect.New(rv.Type().Elem()).Elem()
+			for range t.Len {
+				k.SetZero()
+				v.SetZero()
+				if err := d.decode(k, depth+1); err != nil {
+					return err
+				}
+				if err := d.decode(v, depth+1); err != nil {
+					return err
+				}
+				m.SetMapIndex(k, v)
+			}
+			rv.Set(m)
+			return nil
+		}
+	case reflect.Struct:
+		if t.Kind == Map {
+			return d.assignStruct(rv, t.Len, depth)
+		}
+	}
+	return fmt.Errorf("cannot decode %s into %s", kindNames[t.Kind], rv.Type())
+}
+
+// assignStruct decodes the n pairs of a map into the fields of the struct rv, skipping unknown fields.
+func (d *decoder) assignStruct(rv reflect.Value, n int, depth int) error {
+	fields := fieldsOf(rv.Type(), d.tag)
+	for range n {
+		var name string
+		if err := d.decode(reflect.ValueOf(&name).Elem(), depth+1); err != nil {
+			return err
+		}
+
+		index := -1
+		for _, f := range fields {
+			if f.name == name {
+				index = f.index
+				break
+			}
+			if index < 0 && strings.EqualFold(f.name, name) {
+				index = f.index
+			}
+		}
+		if index < 0 {
+			var skipped any
+			if err := d.decode(reflect.ValueOf(&skipped).Elem(), depth+1); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := d.decode(rv.Field(index), depth+1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// generic returns the value starting with the token t as the Go value that holds it in an interface:
+// int64, uint64 for integers that do not fit in an int64, float64, string, []byte, []any,
+// and map[string]any, or map[any]any if some keys are not strings.
+func (d *decoder) generic(t Token, depth int) (any, error) {
+	switch t.Kind {
+	case Nil:
+		return nil, nil
+	case Bool:
+		return t.Bool, nil
+	case Int:
+		return t.Int, nil
+	case Uint:
+		if t.Uint <= math.MaxInt64 {
+			return int64(t.Uint), nil
+		}
+		return t.Uint, nil
+	case Float:
+		return t.Float, nil
+	case String:
+		return string(t.Bytes), nil
+	case Bytes:
+		return append([]byte{}, t.Bytes...), nil
+	case Array:
+		s := make([]any, t.Len)
+		for i := range s {
+			if err := d.decode(reflect.ValueOf(&s[i]).Elem(), depth+1); err != nil {
+				return nil, err
+			}
+		}
+		return s, nil
+	case Map:
+		m := make(map[any]any, t.Len)
+		strs := true
+		for range t.Len {
+			var k, v any
+			if err := d.decode(reflect.ValueOf(&k).Elem(), depth+1); err != nil {
+				return nil, err
+			}
+			if err := d.decode(reflect.ValueOf(&v).Elem(), depth+1); err != nil {
+				return nil, err
+			}
+			if k != nil && !reflect.TypeOf(k).Comparable() {
+				return nil, fmt.Errorf("map key of type %T is not comparable", k)
+			}
+			_, isStr := k.(string)
+			strs = strs && isStr
+			m[k] = v
+		}
+		if !strs {
+			return m, nil
+		}
+		sm := make(map[string]any, len(m))
+		for k, v := range m {
+			sm[k.(string)] = v
+		}
+		return sm, nil
+	}
+	return nil, fmt.Errorf("unknown token kind %d", t.Kind)
+}
+
+var kindNames = [...]string{
+	Nil:    "nil",
+	Bool:   "boolean",
+	Int:    "negative integer",
+	Uint:   "integer",
+	Float:  "float",
+	String: "string",
+	Bytes:  "byte string",
+	Array:  "array",
+	Map:    "map",
+}
+
+type field struct {
+	name  string
+	index int
+}
+
+// fieldsOf returns the exported fields of the struct type t, named after their tag if they have one.
+// Fields tagged "-" are skipped.
+func fieldsOf(t reflect.Type, tag string) []field {
+	fields := make([]field, 0, t.NumField())
+	for i := range t.NumField() {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name := f.Name
+		if value, ok := f.Tag.Lookup(tag); ok {
+			value, _, _ = strings.Cut(value, ",")
+			if value == "-" {
+				continue
+			}
+			if value != "" {
+				name = value
+			}
+		}
+		fields = append(fields, field{name: name, index: i})
+	}
+	return fields
+}
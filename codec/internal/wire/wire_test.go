@@ -0,0 +1,166 @@
+package wire
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// tokens records the primitives written by Encode and replays them to Decode.
+type tokens struct {
+	list []Token
+}
+
+func (ts *tokens) Nil()          { ts.list = append(ts.list, Token{Kind: Nil}) }
+func (ts *tokens) Bool(v bool)   { ts.list = append(ts.list, Token{Kind: Bool, Bool: v}) }
+func (ts *tokens) Int(v int64)   { ts.list = append(ts.list, Token{Kind: Int, Int: v}) }
+func (ts *tokens) Uint(v uint64) { ts.list = append(ts.list, Token{Kind: Uint, Uint: v}) }
+func (ts *tokens) Float32(v float32) {
+	ts.list = append(ts.list, Token{Kind: Float, Float: float64(v)})
+}
+func (ts *tokens) Float64(v float64) { ts.list = append(ts.list, Token{Kind: Float, Float: v}) }
+func (ts *tokens) String(v string)   { ts.list = append(ts.list, Token{Kind: String, Bytes: []byte(v)}) }
+func (ts *tokens) Bytes(v []byte)    { ts.list = append(ts.list, Token{Kind: Bytes, Bytes: v}) }
+func (ts *tokens) ArrayHeader(n int) { ts.list = append(ts.list, Token{Kind: Array, Len: n}) }
+func (ts *tokens) MapHeader(n int)   { ts.list = append(ts.list, Token{Kind: Map, Len: n}) }
+
+func (ts *tokens) Next() (Token, error) {
+	if len(ts.list) == 0 {
+		return Token{}, errors.New("no more tokens")
+	}
+	t := ts.list[0]
+	ts.list = ts.list[1:]
+	return t, nil
+}
+
+func (ts *tokens) Remaining() int {
+	return len(ts.list)
+}
+
+type point struct {
+	X, Y    int
+	Label   string `test:"label"`
+	Ignored string `test:"-"`
+	hidden  int
+}
+
+type record struct {
+	Name    string
+	Tags    []string
+	Point   *point
+	Weights map[string]float64
+	Raw     []byte
+	Any     any
+	When    time.Time
+	Grid    [2]int8
+}
+
+func TestRoundTrip(t *testing.T) {
+	when := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	in := record{
+		Name:    "r",
+		Tags:    []string{"a", "b"},
+		Point:   &point{X: -1, Y: 2, Label: "p", Ignored: "x", hidden: 3},
+		Weights: map[string]float64{"w": 0.5},
+		Raw:     []byte{1, 2},
+		Any:     map[string]any{"n": -3, "list": []any{"x", true}},
+		When:    when,
+		Grid:    [2]int8{-128, 127},
+	}
+
+	var ts tokens
+	if err := Encode(&ts, in, "test"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	var out record
+	if err := Decode(&ts, &out, "test"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	expected := in
+	expected.Point = &point{X: -1, Y: 2, Label: "p"}
+	expected.Any = map[string]any{"n": int64(-3), "list": []any{"x", true}}
+	if !reflect.DeepEqual(out, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, out)
+	}
+}
+
+func TestFieldNames(t *testing.T) {
+	var ts tokens
+	if err := Encode(&ts, point{X: 1, Label: "p", Ignored: "x"}, "test"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	var names []string
+	for _, tok := range ts.list {
+		if tok.Kind == String {
+			names = append(names, string(tok.Bytes))
+		}
+	}
+	if strings.Join(names, ",") != "X,Y,label,p" {
+		t.Errorf("Expected the exported fields named after their tags, got %v", names)
+	}
+
+	// fields are matched case-insensitively and unknown fields are skipped
+	ts = tokens{}
+	Encode(&ts, map[string]any{"x": 1, "LABEL": "p", "other": []any{1, 2}}, "test")
+	var p point
+	if err := Decode(&ts, &p, "test"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if p.X != 1 || p.Label != "p" {
+		t.Errorf("Expected the fields to be matched, got %+v", p)
+	}
+}
+
+func TestGenericMaps(t *testing.T) {
+	var ts tokens
+	Encode(&ts, map[int]string{1: "a"}, "test")
+	var v any
+	if err := Decode(&ts, &v, "test"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !reflect.DeepEqual(v, map[any]any{int64(1): "a"}) {
+		t.Errorf("Expected a map[any]any, got %#v", v)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	var ts tokens
+	if err := Encode(&ts, make(chan int), "test"); !errors.Is(err, ErrUnsupportedType) {
+		t.Errorf("Expected ErrUnsupportedType, got %v", err)
+	}
+
+	var n int
+	if err := Decode(&ts, n, "test"); err == nil {
+		t.Error("Expected an error when decoding into a non-pointer")
+	}
+
+	ts = tokens{list: []Token{{Kind: Uint, Uint: 300}}}
+	var small int8
+	if err := Decode(&ts, &small, "test"); err == nil {
+		t.Error("Expected an error on overflow")
+	}
+
+	ts = tokens{list: []Token{{Kind: String, Bytes: []byte("x")}}}
+	if err := Decode(&ts, &n, "test"); err == nil || !strings.Contains(err.Error(), "cannot decode string into int") {
+		t.Errorf("Expected a type mismatch error, got %v", err)
+	}
+
+	ts = tokens{}
+	for range maxDepth + 1 {
+		ts.ArrayHeader(1)
+	}
+	ts.Nil()
+	var deep any
+	if err := Decode(&ts, &deep, "test"); err == nil {
+		t.Error("Expected an error for values nested too deeply")
+	}
+
+	ts = tokens{list: []Token{{Kind: Array, Len: 1000}}}
+	var list []int
+	if err := Decode(&ts, &list, "test"); err == nil {
+		t.Error("Expected an error for a length exceeding the input")
+	}
+}
@@ -0,0 +1,12 @@
+// Package msgpack implements the MessagePack serialization format (https://msgpack.org),
+// for exchanging SyncMap snapshots with services that are not written in Go, see syncmap.SyncMap.MarshalWith.
+//
+// It has no dependencies and supports the types that have a natural MessagePack representation:
+// booleans, integers, floats, strings, byte slices, slices, arrays, maps, pointers, and structs,
+// which are encoded as maps of their exported fields, named after their `msgpack` tag if they have one.
+// Types implementing encoding.TextMarshaler, such as time.Time, are encoded as strings.
+// Extension types are not supported.
+//
+// Values decoded into interfaces are int64 (or uint64 if they do not fit), float64, string, []byte, []any,
+// and map[string]any (or map[any]any if some keys are not strings).
+package msgpack
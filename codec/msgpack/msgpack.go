@@ -0,0 +1,239 @@
+package msgpack
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/antst/go-syncmap/codec/internal/wire"
+)
+
+// ErrInvalid is returned when decoding data that is not valid MessagePack or uses unsupported extension types.
+var ErrInvalid = errors.New("msgpack: invalid data")
+
+// Codec encodes values as MessagePack. Its zero value is ready to use.
+type Codec struct{}
+
+// Encode returns the MessagePack encoding of v.
+func (Codec) Encode(v any) ([]byte, error) {
+	return Marshal(v)
+}
+
+// Decode decodes the MessagePack data into v, which must be a non-nil pointer.
+func (Codec) Decode(data []byte, v any) error {
+	return Unmarshal(data, v)
+}
+
+// Marshal returns the MessagePack encoding of v.
+func Marshal(v any) ([]byte, error) {
+	var w writer
+	if err := wire.Encode(&w, v, "msgpack"); err != nil {
+		return nil, fmt.Errorf("msgpack: %w", err)
+	}
+	return w.buf, nil
+}
+
+// Unmarshal decodes the MessagePack data into v, which must be a non-nil pointer.
+// data must hold exactly one value.
+func Unmarshal(data []byte, v any) error {
+	r := reader{buf: data}
+	if err := wire.Decode(&r, v, "msgpack"); err != nil {
+		if errors.Is(err, ErrInvalid) {
+			return err
+		}
+		return fmt.Errorf("msgpack: %w", err)
+	}
+	if len(r.buf) != 0 {
+		return fmt.Errorf("%w: %d trailing bytes", ErrInvalid, len(r.buf))
+	}
+	return nil
+}
+
+type writer struct {
+	buf []byte
+}
+
+func (w *writer) Nil() {
+	w.buf = append(w.buf, 0xc0)
+}
+
+func (w *writer) Bool(v bool) {
+	if v {
+		w.buf = append(w.buf, 0xc3)
+	} else {
+		w.buf = append(w.buf, 0xc2)
+	}
+}
+
+func (w *writer) Int(v int64) {
+	switch {
+	case v >= -32:
+		w.buf = append(w.buf, byte(v))
+	case v >= math.MinInt8:
+		w.buf = append(w.buf, 0xd0, byte(v))
+	case v >= math.MinInt16:
+		w.buf = binary.BigEndian.AppendUint16(append(w.buf, 0xd1), uint16(v))
+	case v >= math.MinInt32:
+		w.buf = binary.BigEndian.AppendUint32(append(w.buf, 0xd2), uint32(v))
+	default:
+		w.buf = binary.BigEndian.AppendUint64(append(w.buf, 0xd3), uint64(v))
+	}
+}
+
+func (w *writer) Uint(v uint64) {
+	switch {
+	case v <= math.MaxInt8:
+		w.buf = append(w.buf, byte(v))
+	case v <= math.MaxUint8:
+		w.buf = append(w.buf, 0xcc, byte(v))
+	case v <= math.MaxUint16:
+		w.buf = binary.BigEndian.AppendUint16(append(w.buf, 0xcd), uint16(v))
+	case v <= math.MaxUint32:
+		w.buf = binary.BigEndian.AppendUint32(append(w.buf, 0xce), uint32(v))
+	default:
+		w.buf = binary.BigEndian.AppendUint64(append(w.buf, 0xcf), v)
+	}
+}
+
+func (w *writer) Float32(v float32) {
+	w.buf = binary.BigEndian.AppendUint32(append(w.buf, 0xca), math.Float32bits(v))
+}
+
+func (w *writer) Float64(v float64) {
+	w.buf = binary.BigEndian.AppendUint64(append(w.buf, 0xcb), math.Float64bits(v))
+}
+
+func (w *writer) String(v string) {
+	w.header(len(v), 0xa0, 32, 0xd9, 0xda, 0xdb)
+	w.buf = append(w.buf, v...)
+}
+
+func (w *writer) Bytes(v []byte) {
+	w.header(len(v), 0, 0, 0xc4, 0xc5, 0xc6)
+	w.buf = append(w.buf, v...)
+}
+
+func (w *writer) ArrayHeader(n int) {
+	w.header(n, 0x90, 16, 0, 0xdc, 0xdd)
+}
+
+func (w *writer) MapHeader(n int) {
+	w.header(n, 0x80, 16, 0, 0xde, 0xdf)
+}
+
+// header appends the header of a value of length n in the smallest format available: fix|n if n is below fixLimit,
+// otherwise the format with an 8, 16 or 32-bit length. f8 is 0 for arrays and maps, which have no 8-bit format.
+func (w *writer) header(n int, fix byte, fixLimit int, f8, f16, f32 byte) {
+	switch {
+	case n < fixLimit:
+		w.buf = append(w.buf, fix|byte(n))
+	case n <= math.MaxUint8 && f8 != 0:
+		w.buf = append(w.buf, f8, byte(n))
+	case n <= math.MaxUint16:
+		w.buf = binary.BigEndian.AppendUint16(append(w.buf, f16), uint16(n))
+	default:
+		w.buf = binary.BigEndian.AppendUint32(append(w.buf, f32), uint32(n))
+	}
+}
+
+type reader struct {
+	buf []byte
+}
+
+func (r *reader) Remaining() int {
+	return len(r.buf)
+}
+
+func (r *reader) Next() (wire.Token, error) {
+	b, err := r.take(1)
+	if err != nil {
+		return wire.Token{}, err
+	}
+
+	switch c := b[0]; {
+	case c <= 0x7f:
+		return wire.Token{Kind: wire.Uint, Uint: uint64(c)}, nil
+	case c >= 0xe0:
+		return wire.Token{Kind: wire.Int, Int: int64(int8(c))}, nil
+	case c&0xe0 == 0xa0:
+		return r.bytes(wire.String, int(c&0x1f))
+	case c&0xf0 == 0x90:
+		return wire.Token{Kind: wire.Array, Len: int(c & 0x0f)}, nil
+	case c&0xf0 == 0x80:
+		return wire.Token{Kind: wire.Map, Len: int(c & 0x0f)}, nil
+	}
+
+	switch c := b[0]; c {
+	case 0xc0:
+		return wire.Token{Kind: wire.Nil}, nil
+	case 0xc2, 0xc3:
+		return wire.Token{Kind: wire.Bool, Bool: c == 0xc3}, nil
+	case 0xcc, 0xcd, 0xce, 0xcf:
+		n, err := r.uint(1 << (c - 0xcc))
+		return wire.Token{Kind: wire.Uint, Uint: n}, err
+	case 0xd0, 0xd1, 0xd2, 0xd3:
+		size := 1 << (c - 0xd0)
+		n, err := r.uint(size)
+		// sign-extend the value read on size bytes
+		shift := 64 - 8*size
+		i := int64(n<<shift) >> shift
+		if i >= 0 {
+			return wire.Token{Kind: wire.Uint, Uint: uint64(i)}, err
+		}
+		return wire.Token{Kind: wire.Int, Int: i}, err
+	case 0xca:
+		n, err := r.uint(4)
+		return wire.Token{Kind: wire.Float, Float: float64(math.Float32frombits(uint32(n)))}, err
+	case 0xcb:
+		n, err := r.uint(8)
+		return wire.Token{Kind: wire.Float, Float: math.Float64frombits(n)}, err
+	case 0xd9, 0xda, 0xdb:
+		n, err := r.uint(1 << (c - 0xd9))
+		if err != nil {
+			return wire.Token{}, err
+		}
+		return r.bytes(wire.String, int(n))
+	case 0xc4, 0xc5, 0xc6:
+		n, err := r.uint(1 << (c - 0xc4))
+		if err != nil {
+			return wire.Token{}, err
+		}
+		return r.bytes(wire.Bytes, int(n))
+	case 0xdc, 0xdd:
+		n, err := r.uint(2 << (c - 0xdc))
+		return wire.Token{Kind: wire.Array, Len: int(n)}, err
+	case 0xde, 0xdf:
+		n, err := r.uint(2 << (c - 0xde))
+		return wire.Token{Kind: wire.Map, Len: int(n)}, err
+	}
+	return wire.Token{}, fmt.Errorf("%w: unsupported format 0x%02x", ErrInvalid, b[0])
+}
+
+// take consumes the next n bytes.
+func (r *reader) take(n int) ([]byte, error) {
+	if n > len(r.buf) {
+		return nil, fmt.Errorf("%w: unexpected end of data", ErrInvalid)
+	}
+	b := r.buf[:n]
+	r.buf = r.buf[n:]
+	return b, nil
+}
+
+// uint consumes a big-endian unsigned integer of size bytes.
+func (r *reader) uint(size int) (uint64, error) {
+	b, err := r.take(size)
+	if err != nil {
+		return 0, err
+	}
+	var n uint64
+	for _, c := range b {
+		n = n<<8 | uint64(c)
+	}
+	return n, nil
+}
+
+func (r *reader) bytes(kind wire.Kind, n int) (wire.Token, error) {
+	b, err := r.take(n)
+	return wire.Token{Kind: kind, Bytes: b}, err
+}
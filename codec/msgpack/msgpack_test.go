@@ -0,0 +1,151 @@
+package msgpack
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMarshal(t *testing.T) {
+	for _, tc := range []struct {
+		v        any
+		expected string
+	}{
+		{nil, "c0"},
+		{false, "c2"},
+		{true, "c3"},
+		{0, "00"},
+		{127, "7f"},
+		{128, "cc80"},
+		{256, "cd0100"},
+		{70000, "ce00011170"},
+		{uint64(math.MaxUint64), "cfffffffffffffffff"},
+		{-1, "ff"},
+		{-32, "e0"},
+		{-33, "d0df"},
+		{-200, "d1ff38"},
+		{-40000, "d2ffff63c0"},
+		{int64(math.MinInt64), "d38000000000000000"},
+		{float32(1.5), "ca3fc00000"},
+		{1.5, "cb3ff8000000000000"},
+		{"a", "a161"},
+		{"", "a0"},
+		{[]byte{1, 2}, "c4020102"},
+		{[]int{1, 2}, "920102"},
+		{map[string]int{"a": 1}, "81a16101"},
+		{struct {
+			A int `msgpack:"a"`
+		}{1}, "81a16101"},
+	} {
+		b, err := Marshal(tc.v)
+		if err != nil {
+			t.Fatalf("Expected no error for %v, got %v", tc.v, err)
+		}
+		if hex.EncodeToString(b) != tc.expected {
+			t.Errorf("Expected %s for %#v, got %x", tc.expected, tc.v, b)
+		}
+	}
+}
+
+func TestLengths(t *testing.T) {
+	for _, tc := range []struct {
+		n      int
+		header string
+	}{
+		{31, "bf"},
+		{32, "d920"},
+		{256, "da0100"},
+		{70000, "db00011170"},
+	} {
+		b, _ := Marshal(strings.Repeat("x", tc.n))
+		if !strings.HasPrefix(hex.EncodeToString(b), tc.header) {
+			t.Errorf("Expected the header %s for a string of %d bytes, got %x", tc.header, tc.n, b[:5])
+		}
+		var s string
+		if err := Unmarshal(b, &s); err != nil || len(s) != tc.n {
+			t.Errorf("Expected a string of %d bytes, got %d and %v", tc.n, len(s), err)
+		}
+	}
+
+	for _, tc := range []struct {
+		n      int
+		header string
+	}{
+		{15, "9f"},
+		{16, "dc0010"},
+		{70000, "dd00011170"},
+	} {
+		b, _ := Marshal(make([]bool, tc.n))
+		if !strings.HasPrefix(hex.EncodeToString(b), tc.header) {
+			t.Errorf("Expected the header %s for an array of %d values, got %x", tc.header, tc.n, b[:5])
+		}
+		var s []bool
+		if err := Unmarshal(b, &s); err != nil || len(s) != tc.n {
+			t.Errorf("Expected an array of %d values, got %d and %v", tc.n, len(s), err)
+		}
+	}
+
+	b, _ := Marshal(bytes.Repeat([]byte{1}, 300))
+	if !strings.HasPrefix(hex.EncodeToString(b), "c5012c") {
+		t.Errorf("Expected a bin 16 header, got %x", b[:3])
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	type user struct {
+		Name   string `msgpack:"name"`
+		Age    uint8
+		Scores []float64
+		Extra  map[string]any
+	}
+
+	in := map[string]user{
+		"alice": {Name: "Alice", Age: 30, Scores: []float64{1.5, -2}, Extra: map[string]any{"admin": true, "level": int64(-3)}},
+		"bob":   {Name: "Bob"},
+	}
+	b, err := Marshal(in)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	var out map[string]user
+	if err := Unmarshal(b, &out); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !reflect.DeepEqual(in, out) {
+		t.Errorf("Expected %+v, got %+v", in, out)
+	}
+
+	var generic any
+	if err := (Codec{}).Decode(b, &generic); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if name := generic.(map[string]any)["alice"].(map[string]any)["name"]; name != "Alice" {
+		t.Errorf("Expected Alice, got %v", name)
+	}
+}
+
+func TestUnmarshalErrors(t *testing.T) {
+	for name, data := range map[string]string{
+		"Empty":     "",
+		"Truncated": "cd01",
+		"String":    "a36162",
+		"Trailing":  "0101",
+		"Reserved":  "c1",
+		"Extension": "d40100",
+	} {
+		b, _ := hex.DecodeString(data)
+		var v any
+		if err := Unmarshal(b, &v); !errors.Is(err, ErrInvalid) {
+			t.Errorf("Expected ErrInvalid for %s, got %v", name, err)
+		}
+	}
+
+	var small int8
+	if err := Unmarshal([]byte{0xcc, 0xff}, &small); err == nil || !strings.HasPrefix(err.Error(), "msgpack: ") {
+		t.Errorf("Expected an overflow error, got %v", err)
+	}
+}
@@ -0,0 +1,56 @@
+package syncmap
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/antst/go-syncmap/codec/cbor"
+	"github.com/antst/go-syncmap/codec/msgpack"
+)
+
+// jsonCodec adapts encoding/json to the Codec interface.
+type jsonCodec struct{}
+
+func (jsonCodec) Encode(v any) ([]byte, error)    { return json.Marshal(v) }
+func (jsonCodec) Decode(data []byte, v any) error { return json.Unmarshal(data, v) }
+
+func TestSyncMapCodec(t *testing.T) {
+	type session struct {
+		User  string
+		Roles []string
+	}
+
+	for name, codec := range map[string]Codec{"MessagePack": msgpack.Codec{}, "CBOR": cbor.Codec{}, "JSON": jsonCodec{}} {
+		t.Run(
+			name, func(t *testing.T) {
+				sm := New[string, session](10)
+				sm.Store("s1", session{User: "alice", Roles: []string{"admin"}})
+				sm.Store("s2", session{User: "bob"})
+
+				b, err := sm.MarshalWith(codec)
+				if err != nil {
+					t.Fatalf("Expected no error, got %v", err)
+				}
+
+				restored := New[string, session](10)
+				restored.Store("s3", session{})
+				if err := restored.UnmarshalWith(codec, b); err != nil {
+					t.Fatalf("Expected no error, got %v", err)
+				}
+				if restored.Len() != 2 {
+					t.Errorf("Expected the contents to be replaced, got %d entries", restored.Len())
+				}
+				if v, ok := restored.Load("s1"); !ok || v.User != "alice" || len(v.Roles) != 1 {
+					t.Errorf("Expected the session of alice, got (%+v, %v)", v, ok)
+				}
+
+				if err := restored.UnmarshalWith(codec, b[:len(b)-1]); err == nil {
+					t.Error("Expected an error for truncated data")
+				}
+				if restored.Len() != 2 {
+					t.Errorf("Expected the map to be unchanged, got %d entries", restored.Len())
+				}
+			},
+		)
+	}
+}
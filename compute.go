@@ -0,0 +1,93 @@
+package syncmap
+
+import (
+	"fmt"
+)
+
+// LoadOrCompute returns the existing value for the key if present. Otherwise, it calls fn to compute
+// the value, stores it and returns it. fn runs at most once at a time per key: concurrent callers
+// missing the same key wait for the computation in progress and share its result, instead of
+// stampeding the source of the values.
+// Errors are returned to all the waiting callers and are not cached: the next call computes again.
+// If a value was stored for the key while fn was running, that value wins and is returned.
+// fn is called without holding the lock, so it may use the map.
+func (m *SyncMap[K, V]) LoadOrCompute(k K, fn func() (V, error)) (V, error) {
+	return m.loadOrCompute(m.key(k), fn)
+}
+
+// loadOrCompute implements LoadOrCompute for the normalized key k.
+func (m *SyncMap[K, V]) loadOrCompute(k K, fn func() (V, error)) (V, error) {
+	if v, ok := m.lookup(k); ok {
+		return v, nil
+	}
+
+	m.computeMu.Lock()
+	if c, ok := m.computing[k]; ok {
+		m.computeMu.Unlock()
+		<-c.done
+		return c.v, c.err
+	}
+	c := &computation[V]{done: make(chan struct{})}
+	if m.computing == nil {
+		m.computing = make(map[K]*computation[V])
+	}
+	m.computing[k] = c
+	m.computeMu.Unlock()
+
+	defer func() {
+		if r := recover(); r != nil {
+			c.err = fmt.Errorf("%w: %v", ErrComputePanicked, r)
+			m.finishComputation(k, c)
+			panic(r)
+		}
+		m.finishComputation(k, c)
+	}()
+
+	// the value may have been stored since the first lookup
+	if v, ok := m.lookup(k); ok {
+		c.v = v
+		return v, nil
+	}
+
+	v, err := fn()
+	if err != nil {
+		c.err = err
+		return v, err
+	}
+
+	c.v, c.err = m.storeComputed(k, v)
+	return c.v, c.err
+}
+
+// computation is a call to the function of LoadOrCompute in progress, shared by the callers waiting for it.
+type computation[V any] struct {
+	// closed once v and err are set
+	done chan struct{}
+	v    V
+	err  error
+}
+
+// storeComputed stores the computed value v unless a value was stored for k in the meantime,
+// and returns the value associated with the key.
+// Computed values are not persisted by the writer of the map, as they typically come from the same store.
+func (m *SyncMap[K, V]) storeComputed(k K, v V) (V, error) {
+	m.lock()
+	defer m.unlock()
+
+	if old, ok := m.load(k); ok {
+		return old, nil
+	}
+	if _, err := m.put(k, v, false); err != nil {
+		var zero V
+		return zero, err
+	}
+	return v, nil
+}
+
+// finishComputation releases the callers waiting for c.
+func (m *SyncMap[K, V]) finishComputation(k K, c *computation[V]) {
+	m.computeMu.Lock()
+	delete(m.computing, k)
+	m.computeMu.Unlock()
+	close(c.done)
+}
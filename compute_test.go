@@ -0,0 +1,118 @@
+package syncmap
+
+import (
+	"errors"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// computations returns the number of LoadOrCompute computations in progress.
+func (m *SyncMap[K, V]) computations() int {
+	m.computeMu.Lock()
+	defer m.computeMu.Unlock()
+	return len(m.computing)
+}
+
+func TestSyncMapLoadOrCompute(t *testing.T) {
+	t.Run(
+		"Singleflight", func(t *testing.T) {
+			sm := New[string, int](10)
+			var calls atomic.Int32
+			release := make(chan struct{})
+
+			var wg sync.WaitGroup
+			results := make([]int, 10)
+			for i := range results {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					v, err := sm.LoadOrCompute(
+						"k", func() (int, error) {
+							calls.Add(1)
+							<-release
+							return 42, nil
+						},
+					)
+					if err != nil {
+						t.Errorf("Expected no error, got %v", err)
+					}
+					results[i] = v
+				}()
+			}
+
+			// let the callers pile up on the computation in progress
+			for sm.computations() == 0 {
+				runtime.Gosched()
+			}
+			close(release)
+			wg.Wait()
+
+			if calls.Load() != 1 {
+				t.Errorf("Expected a single computation, got %d", calls.Load())
+			}
+			for _, v := range results {
+				if v != 42 {
+					t.Errorf("Expected 42, got %d", v)
+				}
+			}
+			if v, ok := sm.Load("k"); !ok || v != 42 {
+				t.Errorf("Expected (42, true), got (%v, %v)", v, ok)
+			}
+		},
+	)
+
+	t.Run(
+		"Hit", func(t *testing.T) {
+			sm := New[string, int](10)
+			sm.Store("k", 1)
+			v, err := sm.LoadOrCompute(
+				"k", func() (int, error) {
+					t.Error("Expected no computation for a present key")
+					return 0, nil
+				},
+			)
+			if v != 1 || err != nil {
+				t.Errorf("Expected (1, nil), got (%v, %v)", v, err)
+			}
+		},
+	)
+
+	t.Run(
+		"ErrorsAreNotCached", func(t *testing.T) {
+			sm := New[string, int](10)
+			errSource := errors.New("source unavailable")
+			if _, err := sm.LoadOrCompute("k", func() (int, error) { return 0, errSource }); !errors.Is(err, errSource) {
+				t.Errorf("Expected %v, got %v", errSource, err)
+			}
+			if sm.Len() != 0 {
+				t.Error("Expected nothing to be stored on error")
+			}
+			if v, err := sm.LoadOrCompute("k", func() (int, error) { return 2, nil }); v != 2 || err != nil {
+				t.Errorf("Expected (2, nil), got (%v, %v)", v, err)
+			}
+		},
+	)
+
+	t.Run(
+		"Panic", func(t *testing.T) {
+			sm := New[string, int](10)
+			func() {
+				defer func() {
+					if r := recover(); r != "boom" {
+						t.Errorf("Expected the panic to propagate, got %v", r)
+					}
+				}()
+				_, _ = sm.LoadOrCompute("k", func() (int, error) { panic("boom") })
+			}()
+
+			if sm.computations() != 0 {
+				t.Error("Expected the computation to be released")
+			}
+			if v, err := sm.LoadOrCompute("k", func() (int, error) { return 1, nil }); v != 1 || err != nil {
+				t.Errorf("Expected (1, nil), got (%v, %v)", v, err)
+			}
+		},
+	)
+}
@@ -0,0 +1,80 @@
+package syncmap
+
+import (
+	"fmt"
+)
+
+// WithMaxCost bounds the SyncMap by the total cost of its entries instead of their number:
+// cost returns the cost of an entry (e.g. the size of its value in bytes), and when a write makes
+// the total exceed maxCost, entries are evicted, by default the least recently used first
+// (see WithEvictionPolicy), until it is within budget again. The entry just written is never evicted
+// to make room for itself, and a value costing more than maxCost on its own is rejected with ErrValueTooLarge.
+// Evicted entries are reported as OpEvict events and handed to the Disposer, if any.
+// cost is called once per write, under the write lock, so it should be cheap.
+func WithMaxCost[K comparable, V any](maxCost int64, cost func(k K, v V) int64) Option[K, V] {
+	return func(m *SyncMap[K, V]) {
+		m.maxCost = maxCost
+		m.cost = cost
+		if m.policy == nil {
+			m.policy = newLRUPolicy[K]()
+		}
+	}
+}
+
+// TotalCost returns the total cost of the entries of a SyncMap created WithMaxCost.
+// It acquires a read lock to ensure thread-safe access to the underlying data.
+func (m *SyncMap[K, V]) TotalCost() int64 {
+	m.rlock()
+	defer m.runlock()
+
+	return m.totalCost
+}
+
+// The methods below assume that the caller holds the write lock.
+
+// costOf returns the cost of storing v for k, or an error if it exceeds the budget of the map on its own.
+func (m *SyncMap[K, V]) costOf(k K, v V) (int64, error) {
+	if m.cost == nil {
+		return 0, nil
+	}
+
+	cost := m.cost(k, v)
+	if cost > m.maxCost {
+		return 0, fmt.Errorf("%w: cost %d, budget is %d", ErrValueTooLarge, cost, m.maxCost)
+	}
+	return cost, nil
+}
+
+// charge records cost as the cost of the entry of k, replacing its previous cost.
+func (m *SyncMap[K, V]) charge(k K, cost int64) {
+	if m.cost == nil {
+		return
+	}
+
+	if m.costs == nil {
+		m.costs = make(map[K]int64)
+	}
+	m.totalCost += cost - m.costs[k]
+	m.costs[k] = cost
+}
+
+// refund forgets the cost of the entry of k, which was removed.
+func (m *SyncMap[K, V]) refund(k K) {
+	if m.cost == nil {
+		return
+	}
+
+	m.totalCost -= m.costs[k]
+	delete(m.costs, k)
+}
+
+// evictOverBudget evicts entries other than k until the total cost is within budget.
+func (m *SyncMap[K, V]) evictOverBudget(k K) {
+	for m.cost != nil && m.totalCost > m.maxCost {
+		victim, ok := m.victim()
+		if !ok || victim == k {
+			return
+		}
+		m.evict(victim, EvictionCapacity)
+	}
+}
@@ -0,0 +1,86 @@
+package syncmap
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestWithMaxCost(t *testing.T) {
+	byteCost := func(k string, v []byte) int64 {
+		return int64(len(v))
+	}
+
+	t.Run(
+		"Budget", func(t *testing.T) {
+			var evicted []string
+			sm := New[string, []byte](
+				10,
+				WithMaxCost(100, byteCost),
+				WithEvictionCallback(
+					func(k string, v []byte, reason EvictionReason) {
+						evicted = append(evicted, k)
+					},
+				),
+			)
+			sm.Store("a", make([]byte, 40))
+			sm.Store("b", make([]byte, 40))
+			sm.Load("a")
+			sm.Store("c", make([]byte, 10))
+			if sm.TotalCost() != 90 || len(evicted) != 0 {
+				t.Errorf("Expected a total cost of 90 and no eviction, got %d and %v", sm.TotalCost(), evicted)
+			}
+
+			sm.Store("d", make([]byte, 60)) // evicts b, then a
+			if !slices.Equal(evicted, []string{"b", "a"}) {
+				t.Errorf("Expected [b a] to be evicted, got %v", evicted)
+			}
+			if sm.TotalCost() != 70 || sm.Len() != 2 {
+				t.Errorf("Expected a total cost of 70 with 2 entries, got %d with %d", sm.TotalCost(), sm.Len())
+			}
+
+			// overwriting an entry replaces its cost
+			sm.Store("c", make([]byte, 30))
+			if sm.TotalCost() != 90 {
+				t.Errorf("Expected a total cost of 90, got %d", sm.TotalCost())
+			}
+			sm.Remove("c")
+			if sm.TotalCost() != 60 {
+				t.Errorf("Expected a total cost of 60, got %d", sm.TotalCost())
+			}
+			sm.Purge()
+			if sm.TotalCost() != 0 {
+				t.Errorf("Expected a total cost of 0, got %d", sm.TotalCost())
+			}
+		},
+	)
+
+	t.Run(
+		"NeverEvictsTheWrittenEntry", func(t *testing.T) {
+			sm := New[string, []byte](10, WithMaxCost(100, byteCost))
+			sm.Store("a", make([]byte, 60))
+			sm.Store("b", make([]byte, 30))
+			sm.Load("a")
+			sm.Store("b", make([]byte, 90)) // b is the least recently used, but it is the entry being written
+			if _, ok := sm.Load("b"); !ok {
+				t.Error("Expected the written entry to stay")
+			}
+			if _, ok := sm.Load("a"); ok || sm.TotalCost() != 90 {
+				t.Errorf("Expected a to be evicted, got a total cost of %d", sm.TotalCost())
+			}
+		},
+	)
+
+	t.Run(
+		"TooLarge", func(t *testing.T) {
+			sm := New[string, []byte](10, WithMaxCost(100, byteCost))
+			sm.Store("a", make([]byte, 10))
+			if err := sm.TryStore("b", make([]byte, 101)); !errors.Is(err, ErrValueTooLarge) {
+				t.Errorf("Expected ErrValueTooLarge, got %v", err)
+			}
+			if sm.Len() != 1 || sm.TotalCost() != 10 {
+				t.Errorf("Expected the map to be unchanged, got %d entries with a total cost of %d", sm.Len(), sm.TotalCost())
+			}
+		},
+	)
+}
@@ -0,0 +1,112 @@
+package syncmap
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+)
+
+// WriteCSV writes the entries of the map to w as CSV, one record per entry, with the fields returned by format,
+// so that the contents of the map can be inspected in a spreadsheet. The records are sorted, so that dumps
+// of the same contents are identical. If header is set, a first record names the columns:
+// key and value, or value1, value2, ... if format returns more than two fields.
+// The records are formatted under a read lock and written once it is released.
+func (m *SyncMap[K, V]) WriteCSV(w io.Writer, header bool, format func(k K, v V) []string) error {
+	m.rlock()
+	records := make([][]string, 0, m.count())
+	for k, v := range m.entries() {
+		records = append(records, format(k, v))
+	}
+	m.runlock()
+
+	slices.SortFunc(records, slices.Compare)
+
+	cw := csv.NewWriter(w)
+	if header {
+		n := 2
+		if len(records) > 0 {
+			n = max(n, len(records[0]))
+		}
+		if err := cw.Write(csvHeader(n)); err != nil {
+			return err
+		}
+	}
+	if err := cw.WriteAll(records); err != nil {
+		return err
+	}
+	return cw.Error()
+}
+
+// csvHeader returns the names of n columns written by WriteCSV.
+func csvHeader(n int) []string {
+	if n == 2 {
+		return []string{"key", "value"}
+	}
+	names := []string{"key"}
+	for i := 1; i < n; i++ {
+		names = append(names, "value"+strconv.Itoa(i))
+	}
+	return names
+}
+
+// ReadCSV creates a SyncMap with the given options, holding the entries read from r by MergeCSV.
+// It returns a nil map and the error of MergeCSV, if any.
+func ReadCSV[K comparable, V any](
+	r io.Reader, header bool, parse func(record []string) (K, V, error), opts ...Option[K, V],
+) (*SyncMap[K, V], error) {
+	m := New[K, V](0, opts...)
+	if _, err := m.MergeCSV(r, header, parse); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// MergeCSV stores the entries read from r as CSV into the map, e.g. to re-import corrections of a dump
+// made with WriteCSV, and returns how many were stored. Every record is converted to an entry by parse;
+// the first record is skipped if header is set. Records may have different numbers of fields.
+// The whole input is read and parsed before the map is locked, and the entries are then stored
+// under a single write lock: if a record cannot be read or parsed, the map is left unchanged
+// and the error reports the line of the record. The pairs rejected by the map are skipped
+// and reported in the returned error.
+func (m *SyncMap[K, V]) MergeCSV(r io.Reader, header bool, parse func(record []string) (K, V, error)) (int, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+
+	var entries []Entry[K, V]
+	for first := true; ; first = false {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return 0, err
+		}
+		if first && header {
+			continue
+		}
+
+		k, v, err := parse(record)
+		if err != nil {
+			line, _ := cr.FieldPos(0)
+			return 0, fmt.Errorf("line %d: %w", line, err)
+		}
+		entries = append(entries, Entry[K, V]{Key: k, Value: v})
+	}
+
+	m.lock()
+	defer m.unlock()
+
+	n := 0
+	var errs []error
+	for _, e := range entries {
+		if err := m.store(m.key(e.Key), e.Value); err != nil {
+			errs = append(errs, fmt.Errorf("key %v: %w", e.Key, err))
+			continue
+		}
+		n++
+	}
+	return n, errors.Join(errs...)
+}
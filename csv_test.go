@@ -0,0 +1,118 @@
+package syncmap
+
+import (
+	"bytes"
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestSyncMapCSV(t *testing.T) {
+	format := func(k string, v int) []string { return []string{k, strconv.Itoa(v)} }
+	parse := func(record []string) (string, int, error) {
+		if len(record) != 2 {
+			return "", 0, errors.New("expected 2 fields")
+		}
+		v, err := strconv.Atoi(record[1])
+		return record[0], v, err
+	}
+
+	t.Run(
+		"RoundTrip", func(t *testing.T) {
+			sm := New[string, int](10)
+			sm.Store("b", 2)
+			sm.Store("a, with comma", 1)
+			sm.Store("c", 3)
+
+			var buf bytes.Buffer
+			if err := sm.WriteCSV(&buf, true, format); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			expected := "key,value\n\"a, with comma\",1\nb,2\nc,3\n"
+			if buf.String() != expected {
+				t.Errorf("Expected %q, got %q", expected, buf.String())
+			}
+
+			restored, err := ReadCSV(&buf, true, parse)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if !Equal(sm, restored) {
+				t.Errorf("Expected the map to round-trip, got %d entries", restored.Len())
+			}
+		},
+	)
+
+	t.Run(
+		"Header", func(t *testing.T) {
+			sm := New[string, int](10)
+			var buf bytes.Buffer
+			sm.WriteCSV(&buf, true, format)
+			if buf.String() != "key,value\n" {
+				t.Errorf("Expected only the header, got %q", buf.String())
+			}
+
+			sm.Store("a", 1)
+			buf.Reset()
+			sm.WriteCSV(&buf, true, func(k string, v int) []string { return []string{k, "x", "y"} })
+			if buf.String() != "key,value1,value2\na,x,y\n" {
+				t.Errorf("Expected numbered value columns, got %q", buf.String())
+			}
+
+			buf.Reset()
+			sm.WriteCSV(&buf, false, format)
+			if buf.String() != "a,1\n" {
+				t.Errorf("Expected no header, got %q", buf.String())
+			}
+		},
+	)
+
+	t.Run(
+		"Merge", func(t *testing.T) {
+			sm := New[string, int](10)
+			sm.Store("a", 1)
+			sm.Store("b", 2)
+
+			n, err := sm.MergeCSV(strings.NewReader("b,20\nc,30\n"), false, parse)
+			if err != nil || n != 2 {
+				t.Fatalf("Expected 2 entries stored, got %d and %v", n, err)
+			}
+			if v, _ := sm.Load("b"); v != 20 || sm.Len() != 3 {
+				t.Errorf("Expected b to be corrected and c added, got b=%d and %d entries", v, sm.Len())
+			}
+		},
+	)
+
+	t.Run(
+		"Invalid", func(t *testing.T) {
+			sm := New[string, int](10)
+			sm.Store("a", 1)
+
+			_, err := sm.MergeCSV(strings.NewReader("key,value\nb,2\nc,x\n"), true, parse)
+			if err == nil || !strings.HasPrefix(err.Error(), "line 3: ") {
+				t.Errorf("Expected an error on line 3, got %v", err)
+			}
+			if _, err := sm.MergeCSV(strings.NewReader("b,\"2\n"), false, parse); err == nil {
+				t.Error("Expected an error for malformed CSV")
+			}
+			if sm.Len() != 1 {
+				t.Errorf("Expected the map to be unchanged, got %d entries", sm.Len())
+			}
+
+			if m, err := ReadCSV(strings.NewReader("a\n"), false, parse); m != nil || err == nil {
+				t.Error("Expected no map and an error")
+			}
+		},
+	)
+
+	t.Run(
+		"Rejected", func(t *testing.T) {
+			sm := New[string, int](10, WithZeroKeyForbidden[string, int]())
+			n, err := sm.MergeCSV(strings.NewReader(",1\na,2\n"), false, parse)
+			if n != 1 || !errors.Is(err, ErrZeroKey) {
+				t.Errorf("Expected 1 entry stored and ErrZeroKey, got %d and %v", n, err)
+			}
+		},
+	)
+}
@@ -0,0 +1,133 @@
+package syncmap
+
+import (
+	"bytes"
+	"runtime"
+	"strconv"
+	"sync"
+	"sync/atomic"
+)
+
+// errSelfDeadlock is the panic message of a SyncMap created WithDeadlockDetection
+// when a goroutine tries to lock a map it already holds locked.
+const errSelfDeadlock = "syncmap: SyncMap method called while locked by same goroutine"
+
+// WithDeadlockDetection makes the SyncMap record which goroutines hold its lock, and panic with
+// "SyncMap method called while locked by same goroutine" when one of them tries to lock it again,
+// for example by calling sm.Load inside sm.DoLocked, instead of hanging forever.
+// Identifying the calling goroutine is slow, so this is a debugging aid not meant for production.
+func WithDeadlockDetection[K comparable, V any]() Option[K, V] {
+	return func(m *SyncMap[K, V]) {
+		if m.holders == nil {
+			m.holders = &lockHolders{readers: make(map[int64]int)}
+		}
+	}
+}
+
+// WithReentrantLocking makes the lock of the SyncMap re-entrant: a goroutine holding the write lock
+// may call any method of the map, including DoLocked, which then reuses the lock it already holds.
+// This allows composing helpers that lock the map internally inside a DoLocked callback.
+// Likewise, a goroutine holding the read lock may take it again, but not upgrade it to the write lock:
+// trying to do so panics as with WithDeadlockDetection.
+// Callbacks queued by nested calls, such as the Disposer, run when the outermost lock is released.
+// Like WithDeadlockDetection, it has to identify the calling goroutine on every lock acquisition, which is slow.
+func WithReentrantLocking[K comparable, V any]() Option[K, V] {
+	return func(m *SyncMap[K, V]) {
+		if m.holders == nil {
+			m.holders = &lockHolders{readers: make(map[int64]int)}
+		}
+		m.holders.reentrant = true
+	}
+}
+
+// lockHolders tracks the goroutines holding the lock of a SyncMap.
+type lockHolders struct {
+	// whether a holder may lock again, see WithReentrantLocking
+	reentrant bool
+
+	// id of the goroutine holding the write lock, 0 if none
+	writer atomic.Int64
+	// number of nested acquisitions by the writer, only accessed by it
+	depth int
+
+	mu      sync.Mutex
+	readers map[int64]int
+}
+
+// enter is called before goroutine id acquires the lock. It reports whether the acquisition is nested
+// in a lock the goroutine already holds, in which case the lock must not be acquired again.
+// It panics if the goroutine already holds the lock and re-entrancy is not allowed.
+func (h *lockHolders) enter(id int64, write bool) (nested bool) {
+	if h.writer.Load() == id {
+		if !h.reentrant {
+			panic(errSelfDeadlock)
+		}
+		h.depth++
+		return true
+	}
+
+	h.mu.Lock()
+	reading := h.readers[id] > 0
+	nested = reading && h.reentrant && !write
+	if nested {
+		h.readers[id]++
+	}
+	h.mu.Unlock()
+
+	if reading && !nested {
+		panic(errSelfDeadlock)
+	}
+	return nested
+}
+
+func (h *lockHolders) acquiredRead(id int64) {
+	h.mu.Lock()
+	h.readers[id]++
+	h.mu.Unlock()
+}
+
+// leaveWrite is called by the writer before releasing the write lock.
+// It reports whether the release is nested, in which case the lock must not be released.
+func (h *lockHolders) leaveWrite() (nested bool) {
+	if h.depth > 0 {
+		h.depth--
+		return true
+	}
+	h.writer.Store(0)
+	return false
+}
+
+// leaveRead is called before releasing the read lock.
+// It reports whether the release is nested, in which case the lock must not be released.
+func (h *lockHolders) leaveRead() (nested bool) {
+	id := goroutineID()
+	if h.writer.Load() == id && h.depth > 0 {
+		h.depth--
+		return true
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.readers[id]--; h.readers[id] > 0 {
+		return true
+	}
+	delete(h.readers, id)
+	return false
+}
+
+// goroutineID returns the id of the calling goroutine, parsed from its stack trace header
+// ("goroutine 42 [running]:").
+func goroutineID() int64 {
+	var buf [64]byte
+	header := buf[:runtime.Stack(buf[:], false)]
+	header = bytes.TrimPrefix(header, []byte("goroutine "))
+	if i := bytes.IndexByte(header, ' '); i >= 0 {
+		header = header[:i]
+	}
+
+	id, err := strconv.ParseInt(string(header), 10, 64)
+	if err != nil {
+		panic("syncmap: cannot parse goroutine id: " + err.Error())
+	}
+	return id
+}
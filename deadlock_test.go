@@ -0,0 +1,254 @@
+package syncmap
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"testing"
+)
+
+func TestWithDeadlockDetection(t *testing.T) {
+	expectPanic := func(t *testing.T, f func()) {
+		t.Helper()
+		defer func() {
+			if r := recover(); r != errSelfDeadlock {
+				t.Errorf("Expected panic %q, got %v", errSelfDeadlock, r)
+			}
+		}()
+		f()
+	}
+
+	t.Run(
+		"LoadInsideDoLocked", func(t *testing.T) {
+			sm := New[string, int](10, WithDeadlockDetection[string, int]())
+			expectPanic(
+				t, func() {
+					sm.DoLocked(
+						func(m LockedMap[string, int]) {
+							sm.Load("key1")
+						},
+					)
+				},
+			)
+
+			// the lock must have been released by the panicking DoLocked
+			sm.Store("key1", 1)
+			if v, ok := sm.Load("key1"); !ok || v != 1 {
+				t.Errorf("Expected (1, true), got (%v, %v)", v, ok)
+			}
+		},
+	)
+
+	t.Run(
+		"StoreInsideDoRLocked", func(t *testing.T) {
+			sm := New[string, int](10, WithDeadlockDetection[string, int]())
+			expectPanic(
+				t, func() {
+					sm.DoRLocked(
+						func(m ReadOnlyLockedMap[string, int]) {
+							sm.Store("key1", 1)
+						},
+					)
+				},
+			)
+		},
+	)
+
+	t.Run(
+		"DoLockedContextInsideRangeMut", func(t *testing.T) {
+			sm := New[string, int](10, WithDeadlockDetection[string, int]())
+			sm.Store("key1", 1)
+			expectPanic(
+				t, func() {
+					sm.RangeMut(
+						func(m LockedMap[string, int], key string, value int) bool {
+							_ = sm.DoLockedContext(
+								context.Background(), func(LockedMap[string, int]) error {
+									return nil
+								},
+							)
+							return true
+						},
+					)
+				},
+			)
+		},
+	)
+
+	t.Run(
+		"SeveralMapsInsideDoLocked", func(t *testing.T) {
+			sm := New[string, int](10, WithDeadlockDetection[string, int]())
+			other := New[string, int](10, WithDeadlockDetection[string, int]())
+			expectPanic(
+				t, func() {
+					sm.DoLocked(
+						func(m LockedMap[string, int]) {
+							Equal(sm, other)
+						},
+					)
+				},
+			)
+			expectPanic(
+				t, func() {
+					sm.DoLocked(
+						func(m LockedMap[string, int]) {
+							SnapshotGroup(other, sm)
+						},
+					)
+				},
+			)
+
+			// the read lock of the other map must have been released
+			other.Store("key1", 1)
+			if !Equal(sm, New[string, int](10)) {
+				t.Error("Expected sm to be empty")
+			}
+		},
+	)
+
+	t.Run(
+		"OtherGoroutinesWait", func(t *testing.T) {
+			sm := New[string, int](10, WithDeadlockDetection[string, int]())
+			var wg sync.WaitGroup
+			for i := 0; i < 10; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					sm.DoLocked(
+						func(m LockedMap[string, int]) {
+							v, _ := m.Load("counter")
+							m.Store("counter", v+1)
+						},
+					)
+					sm.Load("counter")
+				}()
+			}
+			wg.Wait()
+
+			if v, _ := sm.Load("counter"); v != 10 {
+				t.Errorf("Expected counter 10, got %d", v)
+			}
+		},
+	)
+
+	t.Run(
+		"CallbacksAfterUnlock", func(t *testing.T) {
+			var sm *SyncMap[string, int]
+			sm = New[string, int](
+				10,
+				WithDeadlockDetection[string, int](),
+				WithDisposer[string, int](
+					func(k string, v int) {
+						sm.LenLocked()
+					},
+				),
+			)
+			sm.Store("key1", 1)
+			sm.Remove("key1")
+		},
+	)
+}
+
+func TestWithReentrantLocking(t *testing.T) {
+	t.Run(
+		"NestedDoLocked", func(t *testing.T) {
+			var disposed []string
+			sm := New[string, int](
+				10,
+				WithReentrantLocking[string, int](),
+				WithDisposer[string, int](
+					func(k string, v int) {
+						disposed = append(disposed, k)
+					},
+				),
+			)
+			increment := func(key string) {
+				sm.DoLocked(
+					func(m LockedMap[string, int]) {
+						v, _ := m.Load(key)
+						m.Store(key, v+1)
+					},
+				)
+			}
+
+			sm.DoLocked(
+				func(m LockedMap[string, int]) {
+					increment("counter")
+					increment("counter")
+					if v, _ := sm.Load("counter"); v != 2 {
+						t.Errorf("Expected 2, got %v", v)
+					}
+					sm.Store("tmp", 1)
+					sm.Remove("tmp")
+					if len(disposed) != 0 {
+						t.Error("Expected the Disposer to wait for the outermost lock to be released")
+					}
+					m.Store("outer", 1)
+				},
+			)
+
+			if !slices.Equal(disposed, []string{"tmp"}) {
+				t.Errorf("Expected [tmp] to be disposed, got %v", disposed)
+			}
+			if v, _ := sm.Load("outer"); v != 1 {
+				t.Error("Expected the outer LockedMap to stay usable after nested calls")
+			}
+		},
+	)
+
+	t.Run(
+		"NestedRead", func(t *testing.T) {
+			sm := New[string, int](10, WithReentrantLocking[string, int]())
+			sm.Store("key1", 1)
+			sm.DoRLocked(
+				func(m ReadOnlyLockedMap[string, int]) {
+					if v, ok := sm.Load("key1"); !ok || v != 1 {
+						t.Errorf("Expected (1, true), got (%v, %v)", v, ok)
+					}
+				},
+			)
+			// the read lock must be fully released
+			sm.Store("key2", 2)
+		},
+	)
+
+	t.Run(
+		"UpgradePanics", func(t *testing.T) {
+			sm := New[string, int](10, WithReentrantLocking[string, int]())
+			defer func() {
+				if r := recover(); r != errSelfDeadlock {
+					t.Errorf("Expected panic %q, got %v", errSelfDeadlock, r)
+				}
+			}()
+			sm.DoRLocked(
+				func(m ReadOnlyLockedMap[string, int]) {
+					sm.Store("key1", 1)
+				},
+			)
+		},
+	)
+
+	t.Run(
+		"Concurrent", func(t *testing.T) {
+			sm := New[string, int](10, WithReentrantLocking[string, int]())
+			var wg sync.WaitGroup
+			for i := 0; i < 10; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					sm.DoLocked(
+						func(m LockedMap[string, int]) {
+							v, _ := sm.Load("counter")
+							sm.Store("counter", v+1)
+						},
+					)
+				}()
+			}
+			wg.Wait()
+
+			if v, _ := sm.Load("counter"); v != 10 {
+				t.Errorf("Expected counter 10, got %d", v)
+			}
+		},
+	)
+}
@@ -0,0 +1,49 @@
+package syncmap
+
+import (
+	"errors"
+)
+
+var (
+	// ErrNilMap is returned when an operation is attempted on a nil *SyncMap.
+	ErrNilMap = errors.New("syncmap: nil map")
+
+	// ErrZeroKey is returned when the zero value of the key type is stored
+	// in a map created WithZeroKeyForbidden.
+	ErrZeroKey = errors.New("syncmap: zero key is forbidden")
+
+	// ErrValueTooLarge is returned when a value larger than the limit set WithMaxValueSize is stored.
+	ErrValueTooLarge = errors.New("syncmap: value too large")
+
+	// ErrTransform is returned when a ValueTransformer set WithValueTransformers rejects a value.
+	ErrTransform = errors.New("syncmap: value rejected by transformer")
+
+	// ErrComputePanicked is returned by LoadOrCompute to the callers waiting for a computation that panicked.
+	ErrComputePanicked = errors.New("syncmap: compute function panicked")
+
+	// ErrWriteQueueFull is returned when a write is made while the write-behind queue of the map is full,
+	// or reported to the write error handler if one is set, see WithWriter.
+	ErrWriteQueueFull = errors.New("syncmap: write-behind queue is full")
+
+	// ErrWriterClosed is returned when a write is made after the write-behind writer of the map was closed.
+	ErrWriterClosed = errors.New("syncmap: writer is closed")
+
+	// ErrNotFound is returned by the loader set WithLoader for keys that do not exist.
+	ErrNotFound = errors.New("syncmap: key not found")
+
+	// ErrNoBinaryCodec is returned by MarshalBinary and UnmarshalBinary for maps created without WithBinaryCodec.
+	ErrNoBinaryCodec = errors.New("syncmap: no binary codec")
+
+	// ErrBinaryFormat is returned by UnmarshalBinary for data that is not a binary encoding of a map
+	// or was encoded with an unsupported version of the format.
+	ErrBinaryFormat = errors.New("syncmap: invalid binary encoding")
+
+	// ErrNoJournal is returned by Replay for maps created without WithJournal.
+	ErrNoJournal = errors.New("syncmap: no journal")
+
+	// ErrNotReady is returned by TryLoad while a map created WithReadyGate is not hydrated yet.
+	ErrNotReady = errors.New("syncmap: map is not ready")
+
+	// ErrDuplicateValue is returned by Invert when several keys have the same value.
+	ErrDuplicateValue = errors.New("syncmap: duplicate value")
+)
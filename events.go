@@ -0,0 +1,164 @@
+package syncmap
+
+import (
+	"slices"
+	"strconv"
+	"time"
+)
+
+// Op is the kind of change described by an Event.
+type Op int
+
+const (
+	// OpStore is the write of a value for a key, new or existing.
+	OpStore Op = iota + 1
+	// OpDelete is the explicit removal of a key.
+	OpDelete
+	// OpEvict is the removal of a key to make room for others.
+	OpEvict
+	// OpExpire is the removal of a key whose time to live has elapsed.
+	OpExpire
+	// OpPurge is the removal of all the keys at once. Its Event has no key nor values.
+	OpPurge
+)
+
+func (op Op) String() string {
+	switch op {
+	case OpStore:
+		return "Store"
+	case OpDelete:
+		return "Delete"
+	case OpEvict:
+		return "Evict"
+	case OpExpire:
+		return "Expire"
+	case OpPurge:
+		return "Purge"
+	default:
+		return "Op(" + strconv.Itoa(int(op)) + ")"
+	}
+}
+
+// Event describes a change made to a SyncMap.
+type Event[K comparable, V any] struct {
+	Op  Op
+	Key K
+	// Old is the value of the key before the change, if HasOld.
+	Old    V
+	HasOld bool
+	// New is the value of the key after an OpStore.
+	New V
+	// Version is the version of the map right after the change, see SyncMap.Version.
+	Version uint64
+	// Time is when the change was made, according to the clock of the map (see WithClock and WithEventClock).
+	Time time.Time
+}
+
+// WithEventHook sets a function that is called with an Event for every change made to the map,
+// whichever method (or LockedMap) made it. Writes skipped by the change detector produce no event.
+// The hook is called after the lock has been released, so it may use the map; as a consequence,
+// hooks of concurrent writers may be called in any order, which Event.Version allows to restore.
+func WithEventHook[K comparable, V any](hook func(e Event[K, V])) Option[K, V] {
+	return func(m *SyncMap[K, V]) {
+		m.onEvent = hook
+	}
+}
+
+// WithEventHistory makes the SyncMap keep its last size events in memory, available from RecentEvents,
+// so that recent changes can be inspected without having set up an event hook in advance.
+func WithEventHistory[K comparable, V any](size int) Option[K, V] {
+	return func(m *SyncMap[K, V]) {
+		if size > 0 {
+			m.history = &eventRing[K, V]{events: make([]Event[K, V], 0, size)}
+		}
+	}
+}
+
+// RecentEvents returns up to n of the most recent events of a SyncMap created WithEventHistory,
+// oldest first. If n is less than 1, all the kept events are returned.
+// It returns nil if the event history is not enabled.
+// It acquires a read lock to ensure thread-safe access to the underlying data.
+func (m *SyncMap[K, V]) RecentEvents(n int) []Event[K, V] {
+	if m.history == nil {
+		return nil
+	}
+
+	m.rlock()
+	defer m.runlock()
+
+	return m.history.last(n)
+}
+
+// eventRing is a bounded buffer of the most recent events, overwriting the oldest ones when full.
+type eventRing[K comparable, V any] struct {
+	events []Event[K, V]
+	// index of the oldest event once the buffer is full
+	next int
+}
+
+func (r *eventRing[K, V]) add(e Event[K, V]) {
+	if len(r.events) < cap(r.events) {
+		r.events = append(r.events, e)
+		return
+	}
+	r.events[r.next] = e
+	r.next = (r.next + 1) % len(r.events)
+}
+
+func (r *eventRing[K, V]) last(n int) []Event[K, V] {
+	ordered := append(slices.Clone(r.events[r.next:]), r.events[:r.next]...)
+	if n > 0 && n < len(ordered) {
+		ordered = ordered[len(ordered)-n:]
+	}
+	return ordered
+}
+
+// WithClock sets the clock of the map, time.Now by default. It measures time for everything the map does:
+// TTLs and their expiration (see StoreWithTTL), sliding expiration, entry metadata, negative caching,
+// and the timestamps of events unless WithEventClock is set. It is used to control time in tests,
+// or to strip the monotonic clock reading (e.g. time.Now().Round(0)).
+// now is called under the write lock, under the read lock and without any lock, concurrently:
+// it must be safe for concurrent use.
+func WithClock[K comparable, V any](now func() time.Time) Option[K, V] {
+	return func(m *SyncMap[K, V]) {
+		m.now = now
+	}
+}
+
+// WithEventClock sets the function producing the timestamps of events, the clock of the map by default
+// (see WithClock), without changing how the map measures time for TTLs and the like.
+// It is called under the write lock, in the order of the changes, so a clock that never goes backwards
+// (such as a hybrid logical clock) yields timestamps ordered like the versions of the events.
+func WithEventClock[K comparable, V any](now func() time.Time) Option[K, V] {
+	return func(m *SyncMap[K, V]) {
+		m.eventNow = now
+	}
+}
+
+// emit records e in the event history and queues the event hook, if any, to be called with e
+// once the lock is released.
+func (m *SyncMap[K, V]) emit(e Event[K, V]) {
+	m.record(e)
+	if m.history == nil && m.onEvent == nil {
+		return
+	}
+
+	if m.eventNow != nil {
+		e.Time = m.eventNow()
+	} else {
+		e.Time = m.clock()
+	}
+	if m.history != nil {
+		m.history.add(e)
+	}
+	if m.onEvent == nil {
+		return
+	}
+
+	onEvent := m.onEvent
+	m.deferCallback(
+		func() {
+			onEvent(e)
+		},
+	)
+}
@@ -0,0 +1,163 @@
+package syncmap
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestOpString(t *testing.T) {
+	ops := []Op{OpStore, OpDelete, OpEvict, OpExpire, OpPurge, Op(42)}
+	var names []string
+	for _, op := range ops {
+		names = append(names, op.String())
+	}
+	if !slices.Equal(names, []string{"Store", "Delete", "Evict", "Expire", "Purge", "Op(42)"}) {
+		t.Errorf("Unexpected names %v", names)
+	}
+}
+
+func TestWithEventHook(t *testing.T) {
+	var events []Event[string, int]
+	var sm *SyncMap[string, int]
+	sm = New[string, int](
+		10,
+		WithChangeDetector[string, int](
+			func(old, new int) bool {
+				return old == new
+			},
+		),
+		WithEventHook[string, int](
+			func(e Event[string, int]) {
+				// the hook runs outside the lock
+				sm.LenLocked()
+				if e.Time.IsZero() {
+					t.Errorf("Expected event %v to have a timestamp", e)
+				}
+				e.Time = time.Time{}
+				events = append(events, e)
+			},
+		),
+	)
+
+	sm.Store("key1", 1)
+	sm.Store("key1", 2)
+	sm.Store("key1", 2)
+	sm.DoLocked(
+		func(m LockedMap[string, int]) {
+			m.Remove("key1")
+			m.Store("key2", 3)
+		},
+	)
+	sm.LoadAndDelete("missing")
+	sm.Purge()
+
+	expected := []Event[string, int]{
+		{Op: OpStore, Key: "key1", New: 1, Version: 1},
+		{Op: OpStore, Key: "key1", Old: 1, HasOld: true, New: 2, Version: 2},
+		{Op: OpDelete, Key: "key1", Old: 2, HasOld: true, Version: 3},
+		{Op: OpStore, Key: "key2", New: 3, Version: 4},
+		{Op: OpPurge, Version: 5},
+	}
+	if !slices.Equal(events, expected) {
+		t.Errorf("Expected events %v, got %v", expected, events)
+	}
+}
+
+func TestWithEventHistory(t *testing.T) {
+	sm := New[string, int](10, WithEventHistory[string, int](3))
+
+	if events := sm.RecentEvents(0); len(events) != 0 {
+		t.Errorf("Expected no events, got %v", events)
+	}
+
+	sm.Store("key1", 1)
+	sm.Store("key2", 2)
+	if events := sm.RecentEvents(0); len(events) != 2 || events[1].Key != "key2" {
+		t.Errorf("Unexpected events %v", events)
+	}
+
+	sm.Store("key3", 3)
+	sm.Remove("key1")
+	sm.Store("key4", 4)
+
+	versions := func(events []Event[string, int]) []uint64 {
+		var vs []uint64
+		for _, e := range events {
+			vs = append(vs, e.Version)
+		}
+		return vs
+	}
+	if vs := versions(sm.RecentEvents(0)); !slices.Equal(vs, []uint64{3, 4, 5}) {
+		t.Errorf("Expected the last 3 events, got versions %v", vs)
+	}
+	if vs := versions(sm.RecentEvents(2)); !slices.Equal(vs, []uint64{4, 5}) {
+		t.Errorf("Expected the last 2 events, got versions %v", vs)
+	}
+	if events := sm.RecentEvents(10); len(events) != 3 || events[1].Op != OpDelete {
+		t.Errorf("Unexpected events %v", events)
+	}
+
+	if New[string, int](10).RecentEvents(10) != nil {
+		t.Error("Expected nil without event history")
+	}
+}
+
+func TestWithClock(t *testing.T) {
+	// a logical clock, advancing by one second on every call
+	tick := time.Unix(0, 0)
+	sm := New[string, int](
+		10,
+		WithEventHistory[string, int](10),
+		WithClock[string, int](
+			func() time.Time {
+				tick = tick.Add(time.Second)
+				return tick
+			},
+		),
+	)
+
+	sm.Store("key1", 1)
+	sm.Store("key2", 2)
+	sm.Remove("key1")
+
+	for i, e := range sm.RecentEvents(0) {
+		if expected := time.Unix(int64(i+1), 0); !e.Time.Equal(expected) {
+			t.Errorf("Expected event %d at %v, got %v", i, expected, e.Time)
+		}
+	}
+}
+
+func TestWithEventClock(t *testing.T) {
+	// a logical clock for events, advancing by one second on every call, and a fake clock for TTLs
+	tick := time.Unix(0, 0)
+	clock := newFakeClock()
+	sm := New[string, int](
+		10,
+		WithEventHistory[string, int](10),
+		WithClock[string, int](clock.Now),
+		WithEventClock[string, int](
+			func() time.Time {
+				tick = tick.Add(time.Second)
+				return tick
+			},
+		),
+	)
+
+	sm.StoreWithTTL("key1", 1, time.Minute)
+	sm.Store("key2", 2)
+	for i, e := range sm.RecentEvents(0) {
+		if expected := time.Unix(int64(i+1), 0); !e.Time.Equal(expected) {
+			t.Errorf("Expected event %d at %v, got %v", i, expected, e.Time)
+		}
+	}
+
+	// TTLs are measured with the clock of the map, which the event clock does not advance
+	if ttl, ok := sm.GetTTL("key1"); !ok || ttl != time.Minute {
+		t.Errorf("Expected a TTL of 1m, got (%v, %v)", ttl, ok)
+	}
+	clock.Advance(time.Minute)
+	if _, ok := sm.Load("key1"); ok {
+		t.Error("Expected key1 to expire with the clock of the map")
+	}
+}
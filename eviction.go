@@ -0,0 +1,358 @@
+package syncmap
+
+import (
+	"container/heap"
+	"container/list"
+	"strconv"
+)
+
+// EvictionPolicy selects which entry a SyncMap bounded WithMaxEntries evicts when it is full.
+type EvictionPolicy int
+
+const (
+	// EvictLRU evicts the least recently used entry. It is the default.
+	EvictLRU EvictionPolicy = iota
+	// EvictLFU evicts the least frequently used entry, the oldest one among equally used entries.
+	// Use counts are halved periodically, so that entries that were popular long ago
+	// do not stay in the map forever. Unlike LRU, LFU is not flushed by scans over many keys used once.
+	EvictLFU
+)
+
+// EvictionReason tells why an entry was removed automatically, see WithEvictionCallback.
+type EvictionReason int
+
+const (
+	// EvictionExpired is the removal of an entry whose time to live has elapsed.
+	EvictionExpired EvictionReason = iota + 1
+	// EvictionCapacity is the eviction of an entry to make room in a map bounded WithMaxEntries or WithMaxCost.
+	EvictionCapacity
+	// EvictionMemoryPressure is the eviction of an entry by OnMemoryPressure.
+	EvictionMemoryPressure
+)
+
+func (r EvictionReason) String() string {
+	switch r {
+	case EvictionExpired:
+		return "Expired"
+	case EvictionCapacity:
+		return "Capacity"
+	case EvictionMemoryPressure:
+		return "MemoryPressure"
+	default:
+		return "EvictionReason(" + strconv.Itoa(int(r)) + ")"
+	}
+}
+
+// lfuDecayPeriod is the number of uses per tracked key after which LFU use counts are halved.
+const lfuDecayPeriod = 8
+
+// evictionPolicy chooses which entry to evict when a bounded SyncMap is full.
+// The map reports every key it adds, accesses and removes, and asks for a victim when it must make room.
+// All calls are serialized by the policy lock of the map, see SyncMap.policyMu.
+type evictionPolicy[K comparable] interface {
+	// added reports that k was inserted.
+	added(k K)
+	// accessed reports that k was read or overwritten. It may be called for keys that are no longer in the map.
+	accessed(k K)
+	// removed reports that k was removed, whatever the reason.
+	removed(k K)
+	// victim returns the key to evict next, if any, without removing it.
+	victim() (K, bool)
+	// reset forgets all the keys.
+	reset()
+}
+
+// WithMaxEntries bounds the SyncMap to n entries: when a write of a new key would make the map grow beyond n,
+// an entry is evicted to make room, by default the least recently used one (see WithEvictionPolicy).
+// Loads and writes count as uses of a key; iterations do not.
+// Evicted entries are reported as OpEvict events and handed to the Disposer, if any.
+// This keeps caches that would otherwise grow without limit from exhausting memory.
+func WithMaxEntries[K comparable, V any](n int) Option[K, V] {
+	return func(m *SyncMap[K, V]) {
+		m.maxEntries = n
+		if m.policy == nil {
+			m.policy = newLRUPolicy[K]()
+		}
+	}
+}
+
+// WithEvictionPolicy sets the policy choosing the entries evicted by a SyncMap bounded WithMaxEntries,
+// and by OnMemoryPressure.
+func WithEvictionPolicy[K comparable, V any](policy EvictionPolicy) Option[K, V] {
+	return func(m *SyncMap[K, V]) {
+		switch policy {
+		case EvictLFU:
+			m.policy = newLFUPolicy[K]()
+		default:
+			m.policy = newLRUPolicy[K]()
+		}
+	}
+}
+
+// WithEvictionCallback sets a callback that is called for every entry removed automatically from the map,
+// by expiration or eviction, with the reason of the removal. Unlike the Disposer, it is not called
+// for explicit removals, so it suits bookkeeping such as decrementing gauges of live entries.
+// The callback is called after the lock has been released, so it may use the map.
+func WithEvictionCallback[K comparable, V any](onEvict func(k K, v V, reason EvictionReason)) Option[K, V] {
+	return func(m *SyncMap[K, V]) {
+		m.onEvict = onEvict
+	}
+}
+
+// The methods below report to the eviction policy of the map, if any.
+// Callers hold at least the read lock; the policy lock serializes reads of the same map.
+
+func (m *SyncMap[K, V]) trackAdded(k K) {
+	if m.policy == nil {
+		return
+	}
+	m.policyMu.Lock()
+	m.policy.added(k)
+	m.recordUse(k)
+	m.policyMu.Unlock()
+}
+
+func (m *SyncMap[K, V]) trackAccessed(k K) {
+	if m.policy == nil {
+		return
+	}
+	m.policyMu.Lock()
+	m.policy.accessed(k)
+	m.recordUse(k)
+	m.policyMu.Unlock()
+}
+
+func (m *SyncMap[K, V]) trackRemoved(k K) {
+	if m.policy == nil {
+		return
+	}
+	m.policyMu.Lock()
+	m.policy.removed(k)
+	m.policyMu.Unlock()
+}
+
+func (m *SyncMap[K, V]) trackReset() {
+	if m.policy == nil {
+		return
+	}
+	m.policyMu.Lock()
+	m.policy.reset()
+	m.policyMu.Unlock()
+}
+
+// victim returns the key to evict next: the choice of the eviction policy, or an arbitrary key without one.
+// It assumes that the caller holds the write lock.
+func (m *SyncMap[K, V]) victim() (K, bool) {
+	if m.policy != nil {
+		m.policyMu.Lock()
+		defer m.policyMu.Unlock()
+		return m.policy.victim()
+	}
+
+	for k := range m.data {
+		if !m.isPinned(k) {
+			return k, true
+		}
+	}
+	var zero K
+	return zero, false
+}
+
+// makeRoom evicts entries until a new one can be added without exceeding the maximum number of entries.
+// It assumes that the caller holds the write lock.
+func (m *SyncMap[K, V]) makeRoom() {
+	for m.maxEntries > 0 && len(m.data) >= m.maxEntries {
+		k, ok := m.victim()
+		if !ok {
+			return
+		}
+		m.evict(k, EvictionCapacity)
+	}
+}
+
+// evict removes the entry of k to make room for others. It assumes that the caller holds the write lock.
+func (m *SyncMap[K, V]) evict(k K, reason EvictionReason) {
+	v := m.decode(m.data[k])
+	delete(m.data, k)
+	m.forget(k)
+	m.size.Add(-1)
+	version := m.version.Add(1)
+	m.checkSoftLimit()
+	m.stats.evicted()
+	m.emit(Event[K, V]{Op: OpEvict, Key: k, Old: v, HasOld: true, Version: version})
+	m.evictedLater(k, v, reason)
+	m.disposeLater(map[K]V{k: v})
+}
+
+// evictedLater queues the eviction callback, if any, to be called for the entry once the lock is released.
+func (m *SyncMap[K, V]) evictedLater(k K, v V, reason EvictionReason) {
+	if m.onEvict == nil {
+		return
+	}
+
+	onEvict := m.onEvict
+	m.deferCallback(
+		func() {
+			onEvict(k, v, reason)
+		},
+	)
+}
+
+// lruPolicy evicts the least recently used key.
+type lruPolicy[K comparable] struct {
+	// front is the most recently used key
+	recency  *list.List
+	elements map[K]*list.Element
+}
+
+func newLRUPolicy[K comparable]() *lruPolicy[K] {
+	return &lruPolicy[K]{recency: list.New(), elements: make(map[K]*list.Element)}
+}
+
+func (p *lruPolicy[K]) added(k K) {
+	if e, ok := p.elements[k]; ok {
+		p.recency.MoveToFront(e)
+		return
+	}
+	p.elements[k] = p.recency.PushFront(k)
+}
+
+func (p *lruPolicy[K]) accessed(k K) {
+	if e, ok := p.elements[k]; ok {
+		p.recency.MoveToFront(e)
+	}
+}
+
+func (p *lruPolicy[K]) removed(k K) {
+	if e, ok := p.elements[k]; ok {
+		p.recency.Remove(e)
+		delete(p.elements, k)
+	}
+}
+
+func (p *lruPolicy[K]) victim() (K, bool) {
+	if e := p.recency.Back(); e != nil {
+		return e.Value.(K), true
+	}
+	var zero K
+	return zero, false
+}
+
+func (p *lruPolicy[K]) reset() {
+	p.recency.Init()
+	clear(p.elements)
+}
+
+// lfuPolicy evicts the least frequently used key, using a min-heap ordered by use count then age.
+type lfuPolicy[K comparable] struct {
+	entries map[K]*lfuEntry[K]
+	heap    lfuHeap[K]
+	// incremented for every added key, to order keys by age
+	seq uint64
+	// uses since the counts were last halved
+	uses int
+}
+
+type lfuEntry[K comparable] struct {
+	key   K
+	count uint32
+	seq   uint64
+	// position in the heap
+	index int
+}
+
+func newLFUPolicy[K comparable]() *lfuPolicy[K] {
+	return &lfuPolicy[K]{entries: make(map[K]*lfuEntry[K])}
+}
+
+func (p *lfuPolicy[K]) added(k K) {
+	if _, ok := p.entries[k]; ok {
+		p.accessed(k)
+		return
+	}
+	p.seq++
+	e := &lfuEntry[K]{key: k, count: 1, seq: p.seq}
+	p.entries[k] = e
+	heap.Push(&p.heap, e)
+}
+
+func (p *lfuPolicy[K]) accessed(k K) {
+	e, ok := p.entries[k]
+	if !ok {
+		return
+	}
+	if e.count < ^uint32(0) {
+		e.count++
+		heap.Fix(&p.heap, e.index)
+	}
+
+	p.uses++
+	if p.uses >= lfuDecayPeriod*len(p.entries) {
+		p.decay()
+	}
+}
+
+// decay halves all the use counts, so that past popularity fades.
+func (p *lfuPolicy[K]) decay() {
+	p.uses = 0
+	for _, e := range p.heap {
+		e.count = max(e.count/2, 1)
+	}
+	// halving preserves the order of the counts, but may turn them into ties broken by age
+	heap.Init(&p.heap)
+}
+
+func (p *lfuPolicy[K]) removed(k K) {
+	if e, ok := p.entries[k]; ok {
+		heap.Remove(&p.heap, e.index)
+		delete(p.entries, k)
+	}
+}
+
+func (p *lfuPolicy[K]) victim() (K, bool) {
+	if len(p.heap) > 0 {
+		return p.heap[0].key, true
+	}
+	var zero K
+	return zero, false
+}
+
+func (p *lfuPolicy[K]) reset() {
+	clear(p.entries)
+	p.heap = nil
+	p.uses = 0
+}
+
+type lfuHeap[K comparable] []*lfuEntry[K]
+
+func (h lfuHeap[K]) Len() int {
+	return len(h)
+}
+
+func (h lfuHeap[K]) Less(i, j int) bool {
+	if h[i].count != h[j].count {
+		return h[i].count < h[j].count
+	}
+	return h[i].seq < h[j].seq
+}
+
+func (h lfuHeap[K]) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *lfuHeap[K]) Push(x any) {
+	e := x.(*lfuEntry[K])
+	e.index = len(*h)
+	*h = append(*h, e)
+}
+
+func (h *lfuHeap[K]) Pop() any {
+	old := *h
+	last := len(old) - 1
+	e := old[last]
+	old[last] = nil
+	*h = old[:last]
+	return e
+}
@@ -0,0 +1,241 @@
+package syncmap
+
+import (
+	"maps"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWithMaxEntries(t *testing.T) {
+	t.Run(
+		"LeastRecentlyUsed", func(t *testing.T) {
+			var evicted []string
+			sm := New[string, int](
+				3,
+				WithMaxEntries[string, int](3),
+				WithEventHook[string, int](
+					func(e Event[string, int]) {
+						if e.Op == OpEvict {
+							evicted = append(evicted, e.Key)
+						}
+					},
+				),
+			)
+			sm.Store("a", 1)
+			sm.Store("b", 2)
+			sm.Store("c", 3)
+			sm.Load("a")      // a is now the most recently used
+			sm.Store("b", 20) // then b
+			sm.Store("d", 4)  // evicts c
+
+			if !slices.Equal(evicted, []string{"c"}) {
+				t.Errorf("Expected [c] to be evicted, got %v", evicted)
+			}
+			expected := map[string]int{"a": 1, "b": 20, "d": 4}
+			if got := sm.Filter(func(k string, v int) bool { return true }); !maps.Equal(got, expected) {
+				t.Errorf("Expected %v, got %v", expected, got)
+			}
+
+			sm.DoLocked(
+				func(m LockedMap[string, int]) {
+					m.Load("a")
+					m.Store("e", 5) // evicts b
+				},
+			)
+			sm.LoadOrStore("d", 0)
+			sm.Store("f", 6) // evicts a
+			if !slices.Equal(evicted, []string{"c", "b", "a"}) {
+				t.Errorf("Expected [c b a] to be evicted, got %v", evicted)
+			}
+			if sm.Len() != 3 {
+				t.Errorf("Expected 3 entries, got %d", sm.Len())
+			}
+		},
+	)
+
+	t.Run(
+		"RemovedKeysAreForgotten", func(t *testing.T) {
+			var disposed []string
+			sm := New[string, int](
+				2,
+				WithMaxEntries[string, int](2),
+				WithDisposer[string, int](
+					func(k string, v int) {
+						disposed = append(disposed, k)
+					},
+				),
+			)
+			sm.Store("a", 1)
+			sm.Store("b", 2)
+			sm.Remove("a")
+			sm.Store("c", 3)
+			if len(disposed) != 1 || sm.Len() != 2 {
+				t.Errorf("Expected no eviction, got %d entries and %v disposed", sm.Len(), disposed)
+			}
+
+			sm.Purge()
+			sm.Store("d", 4)
+			sm.Store("e", 5)
+			sm.Store("f", 6)
+			if _, ok := sm.Load("d"); ok || sm.Len() != 2 {
+				t.Errorf("Expected d to be evicted, got %d entries", sm.Len())
+			}
+		},
+	)
+
+	t.Run(
+		"ConcurrentLoads", func(t *testing.T) {
+			for _, opts := range [][]Option[int, int]{
+				{WithMaxEntries[int, int](100)},
+				{WithMaxEntries[int, int](100), WithReadMostly[int, int]()},
+			} {
+				sm := New[int, int](100, opts...)
+				var wg sync.WaitGroup
+				for g := range 4 {
+					wg.Add(1)
+					go func() {
+						defer wg.Done()
+						for i := range 1000 {
+							sm.Store(g*1000+i, i)
+							sm.Load(g*1000 + i/2)
+						}
+					}()
+				}
+				wg.Wait()
+				if sm.Len() != 100 {
+					t.Errorf("Expected 100 entries, got %d", sm.Len())
+				}
+			}
+		},
+	)
+}
+
+func TestWithEvictionPolicy(t *testing.T) {
+	t.Run(
+		"LeastFrequentlyUsed", func(t *testing.T) {
+			sm := New[string, int](3, WithMaxEntries[string, int](3), WithEvictionPolicy[string, int](EvictLFU))
+			sm.Store("a", 1)
+			sm.Store("b", 2)
+			sm.Store("c", 3)
+			for range 3 {
+				sm.Load("a")
+				sm.Load("c")
+			}
+			sm.Load("b")
+
+			sm.Store("d", 4) // evicts b, the least frequently used
+			if _, ok := sm.Load("b"); ok {
+				t.Error("Expected b to be evicted")
+			}
+
+			sm.Store("e", 5) // evicts d: it is as rarely used as e, but older
+			if _, ok := sm.Load("d"); ok {
+				t.Error("Expected d to be evicted")
+			}
+			for _, k := range []string{"a", "c", "e"} {
+				if _, ok := sm.Load(k); !ok {
+					t.Errorf("Expected %s to be present", k)
+				}
+			}
+		},
+	)
+
+	t.Run(
+		"ScanResistance", func(t *testing.T) {
+			sm := New[int, int](
+				10,
+				WithEvictionPolicy[int, int](EvictLFU),
+				WithMaxEntries[int, int](10),
+			)
+			for i := range 5 {
+				sm.Store(i, i)
+				sm.Load(i)
+			}
+			// a scan over many keys used once does not flush the frequently used ones
+			for i := 100; i < 200; i++ {
+				sm.Store(i, i)
+			}
+			for i := range 5 {
+				if _, ok := sm.Load(i); !ok {
+					t.Errorf("Expected %d to survive the scan", i)
+				}
+			}
+			if sm.Len() != 10 {
+				t.Errorf("Expected 10 entries, got %d", sm.Len())
+			}
+		},
+	)
+
+	t.Run(
+		"Decay", func(t *testing.T) {
+			p := newLFUPolicy[string]()
+			p.added("old")
+			for range 100 {
+				p.accessed("old")
+			}
+			p.added("new")
+			for range 30 {
+				p.accessed("new")
+			}
+			// the counts of old were halved while new was used: old is now the least frequently used
+			if k, _ := p.victim(); k != "old" {
+				t.Errorf("Expected old to be the victim, got %s", k)
+			}
+			p.removed("old")
+			p.removed("new")
+			if _, ok := p.victim(); ok || len(p.entries) != 0 {
+				t.Error("Expected no victim once all the keys are removed")
+			}
+		},
+	)
+}
+
+func TestWithEvictionCallback(t *testing.T) {
+	type eviction struct {
+		key    string
+		value  int
+		reason EvictionReason
+	}
+
+	clock := newFakeClock()
+	var sm *SyncMap[string, int]
+	var evictions []eviction
+	sm = New[string, int](
+		2,
+		WithClock[string, int](clock.Now),
+		WithMaxEntries[string, int](2),
+		WithPressureEviction[string, int](1, 1),
+		WithEvictionCallback[string, int](
+			func(k string, v int, reason EvictionReason) {
+				// the callback runs outside the lock
+				sm.LenLocked()
+				evictions = append(evictions, eviction{k, v, reason})
+			},
+		),
+	)
+
+	sm.StoreWithTTL("a", 1, time.Second)
+	sm.Store("b", 2)
+	sm.Remove("b") // explicit removals are not reported
+	sm.Store("c", 3)
+	clock.Advance(time.Second)
+	sm.Load("a")
+	sm.Store("d", 4)
+	sm.Store("e", 5)
+	sm.OnMemoryPressure(PressureModerate)
+
+	expected := []eviction{
+		{"a", 1, EvictionExpired},
+		{"c", 3, EvictionCapacity},
+		{"d", 4, EvictionMemoryPressure},
+		{"e", 5, EvictionMemoryPressure},
+	}
+	if !slices.Equal(evictions, expected) {
+		t.Errorf("Expected %v, got %v", expected, evictions)
+	}
+	if s := EvictionMemoryPressure.String(); s != "MemoryPressure" {
+		t.Errorf("Expected MemoryPressure, got %s", s)
+	}
+}
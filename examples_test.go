@@ -2,6 +2,7 @@ package syncmap
 
 import (
 	"fmt"
+	"sort"
 )
 
 func Example() {
@@ -55,13 +56,19 @@ func Example() {
 		fmt.Printf("Loaded and deleted 'apple': %d\n", value)
 	}
 
-	// Use Range to iterate over remaining items
+	// Use Range to iterate over remaining items (iteration order is random, so sort the keys)
+	keys := make([]string, 0)
 	sm.Range(
 		func(key string, value int) bool {
-			fmt.Printf("Key: %s, Value: %d\n", key, value)
+			keys = append(keys, key)
 			return true
 		},
 	)
+	sort.Strings(keys)
+	for _, key := range keys {
+		value, _ := sm.Load(key)
+		fmt.Printf("Key: %s, Value: %d\n", key, value)
+	}
 
 	// Use DoLocked to perform multiple operations atomically
 	sm.DoLocked(
@@ -0,0 +1,90 @@
+package syncmap
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// SaveTo writes the entries of the map to w in the format of codec, see MarshalWith.
+func (m *SyncMap[K, V]) SaveTo(w io.Writer, codec Codec) error {
+	b, err := m.MarshalWith(codec)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(b)
+	return err
+}
+
+// LoadFrom replaces the contents of the map with the entries read from r in the format of codec, see UnmarshalWith.
+// If r cannot be read or its data is not valid, the map is left unchanged.
+// A successful load marks the map as ready (see WithReadyGate).
+func (m *SyncMap[K, V]) LoadFrom(r io.Reader, codec Codec) error {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	return m.hydrate(codec, b)
+}
+
+// SaveToFile writes a snapshot of the map to the file at path in the format of codec, so that a long-lived
+// service can restore its map with LoadFromFile after a restart. The snapshot is written to a temporary file
+// in the same directory, synced, then renamed over path, so that a crash never leaves a partial snapshot at path.
+// The file is readable and writable by its owner only (mode 0600).
+func (m *SyncMap[K, V]) SaveToFile(path string, codec Codec) error {
+	b, err := m.MarshalWith(codec)
+	if err != nil {
+		return err
+	}
+	return writeFile(
+		path, func(w io.Writer) error {
+			_, err := w.Write(b)
+			return err
+		},
+	)
+}
+
+// writeFile atomically replaces the file at path with one holding what write writes to it, see SaveToFile.
+func writeFile(path string, write func(w io.Writer) error) (err error) {
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			f.Close()
+			os.Remove(f.Name())
+		}
+	}()
+
+	if err = write(f); err != nil {
+		return err
+	}
+	if err = f.Sync(); err != nil {
+		return err
+	}
+	if err = f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
+// LoadFromFile replaces the contents of the map with the snapshot written to the file at path by SaveToFile.
+// If the file cannot be read or its data is not valid, the map is left unchanged.
+// A successful load marks the map as ready (see WithReadyGate).
+func (m *SyncMap[K, V]) LoadFromFile(path string, codec Codec) error {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return m.hydrate(codec, b)
+}
+
+// hydrate replaces the contents of the map with the snapshot data, and marks the map as ready if it succeeds.
+func (m *SyncMap[K, V]) hydrate(codec Codec, data []byte) error {
+	if err := m.UnmarshalWith(codec, data); err != nil {
+		return err
+	}
+	m.MarkReady()
+	return nil
+}
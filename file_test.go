@@ -0,0 +1,124 @@
+package syncmap
+
+import (
+	"bytes"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/antst/go-syncmap/codec/msgpack"
+)
+
+func TestSyncMapFile(t *testing.T) {
+	t.Run(
+		"File", func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "snapshot.msgpack")
+			sm := New[string, int](10)
+			sm.Store("a", 1)
+			sm.Store("b", 2)
+			if err := sm.SaveToFile(path, msgpack.Codec{}); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+
+			sm.Store("c", 3)
+			if err := sm.SaveToFile(path, msgpack.Codec{}); err != nil {
+				t.Fatalf("Expected the snapshot to be replaced, got %v", err)
+			}
+			if entries, _ := os.ReadDir(filepath.Dir(path)); len(entries) != 1 {
+				t.Errorf("Expected no temporary file to be left, got %d files", len(entries))
+			}
+
+			restored := New[string, int](10)
+			if err := restored.LoadFromFile(path, msgpack.Codec{}); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if !Equal(sm, restored) {
+				t.Errorf("Expected the map to be restored, got %d entries", restored.Len())
+			}
+		},
+	)
+
+	t.Run(
+		"Stream", func(t *testing.T) {
+			sm := New[string, int](10)
+			sm.Store("a", 1)
+
+			var buf bytes.Buffer
+			if err := sm.SaveTo(&buf, msgpack.Codec{}); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			restored := New[string, int](10)
+			if err := restored.LoadFrom(&buf, msgpack.Codec{}); err != nil || !Equal(sm, restored) {
+				t.Errorf("Expected the map to be restored, got %v", err)
+			}
+		},
+	)
+
+	t.Run(
+		"Errors", func(t *testing.T) {
+			dir := t.TempDir()
+			sm := New[string, int](10)
+			sm.Store("a", 1)
+
+			if err := sm.LoadFromFile(filepath.Join(dir, "missing"), msgpack.Codec{}); !errors.Is(err, fs.ErrNotExist) {
+				t.Errorf("Expected fs.ErrNotExist, got %v", err)
+			}
+			if err := sm.SaveToFile(filepath.Join(dir, "missing", "snapshot"), msgpack.Codec{}); err == nil {
+				t.Error("Expected an error for a missing directory")
+			}
+
+			corrupt := filepath.Join(dir, "corrupt")
+			os.WriteFile(corrupt, []byte{0xc1}, 0o600)
+			if err := sm.LoadFromFile(corrupt, msgpack.Codec{}); !errors.Is(err, msgpack.ErrInvalid) {
+				t.Errorf("Expected msgpack.ErrInvalid, got %v", err)
+			}
+			if sm.Len() != 1 {
+				t.Errorf("Expected the map to be unchanged, got %d entries", sm.Len())
+			}
+
+			unsupported := New[string, chan int](10)
+			unsupported.Store("c", make(chan int))
+			path := filepath.Join(dir, "unsupported")
+			if err := unsupported.SaveToFile(path, msgpack.Codec{}); err == nil {
+				t.Error("Expected an error for values that cannot be encoded")
+			}
+			if _, err := os.Stat(path); !errors.Is(err, fs.ErrNotExist) {
+				t.Errorf("Expected no file to be written, got %v", err)
+			}
+		},
+	)
+
+	t.Run(
+		"ReadyGate", func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "snapshot.msgpack")
+			sm := New[string, int](10)
+			sm.Store("a", 1)
+			if err := sm.SaveToFile(path, msgpack.Codec{}); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+
+			restored := New[string, int](10, WithReadyGate[string, int]())
+			if err := restored.LoadFromFile(filepath.Join(t.TempDir(), "missing"), msgpack.Codec{}); err == nil {
+				t.Fatal("Expected an error for a missing file")
+			}
+			if restored.IsReady() {
+				t.Error("Expected the map not to be ready after a failed load")
+			}
+			if err := restored.LoadFromFile(path, msgpack.Codec{}); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if v, ok, err := restored.TryLoad("a"); err != nil || !ok || v != 1 {
+				t.Errorf("Expected 1 after the load, got %v, %v", v, err)
+			}
+
+			var buf bytes.Buffer
+			sm.SaveTo(&buf, msgpack.Codec{})
+			stream := New[string, int](10, WithReadyGate[string, int]())
+			if err := stream.LoadFrom(&buf, msgpack.Codec{}); err != nil || !stream.IsReady() {
+				t.Errorf("Expected the map to be ready after the load, got %v", err)
+			}
+		},
+	)
+}
@@ -0,0 +1,45 @@
+package syncmap
+
+import (
+	"bytes"
+	"encoding/gob"
+	"maps"
+)
+
+// to complain if SyncMap does not implement the interfaces
+var (
+	_ gob.GobEncoder = (*SyncMap[string, any])(nil)
+	_ gob.GobDecoder = (*SyncMap[string, any])(nil)
+)
+
+// GobEncode implements gob.GobEncoder, encoding the entries of the map as a gob-encoded Go map,
+// so that a SyncMap can be sent over net/rpc or written to gob files without first exporting it.
+// As with any gob value, concrete types stored in interface keys or values must be registered with gob.Register.
+// The entries are copied under a read lock and encoded once it is released.
+func (m *SyncMap[K, V]) GobEncode() ([]byte, error) {
+	m.rlock()
+	data := maps.Collect(m.entries())
+	m.runlock()
+
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(data); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// GobDecode implements gob.GobDecoder, replacing the contents of the map with the decoded entries.
+// The data is decoded before the map is locked, and the contents are then replaced atomically,
+// under a single write lock, as with ReplaceAll: if the data is not valid, the map is left unchanged.
+// The pairs rejected by the map are skipped and reported in the returned error.
+func (m *SyncMap[K, V]) GobDecode(b []byte) error {
+	var data map[K]V
+	if err := gob.NewDecoder(bytes.NewReader(b)).Decode(&data); err != nil {
+		return err
+	}
+
+	m.lock()
+	defer m.unlock()
+
+	return m.replace(data)
+}
@@ -0,0 +1,74 @@
+package syncmap
+
+import (
+	"bytes"
+	"encoding/gob"
+	"testing"
+)
+
+func TestSyncMapGob(t *testing.T) {
+	t.Run(
+		"RoundTrip", func(t *testing.T) {
+			sm := New[string, []int](10)
+			sm.Store("a", []int{1, 2})
+			sm.Store("b", []int{3})
+
+			var buf bytes.Buffer
+			if err := gob.NewEncoder(&buf).Encode(sm); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+
+			restored := New[string, []int](10)
+			restored.Store("c", nil)
+			if err := gob.NewDecoder(&buf).Decode(restored); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if restored.Len() != 2 {
+				t.Errorf("Expected the contents to be replaced, got %d entries", restored.Len())
+			}
+			if v, ok := restored.Load("a"); !ok || len(v) != 2 || v[1] != 2 {
+				t.Errorf("Expected [1 2], got (%v, %v)", v, ok)
+			}
+		},
+	)
+
+	t.Run(
+		"Embedded", func(t *testing.T) {
+			type snapshot struct {
+				Version int
+				Users   SyncMap[int, string]
+			}
+
+			var s snapshot
+			s.Version = 3
+			s.Users.Store(1, "alice")
+			s.Users.Store(2, "bob")
+
+			var buf bytes.Buffer
+			if err := gob.NewEncoder(&buf).Encode(&s); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+
+			var restored snapshot
+			if err := gob.NewDecoder(&buf).Decode(&restored); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if restored.Version != 3 || !Equal(&s.Users, &restored.Users) {
+				t.Errorf("Expected the snapshot to be restored, got version %d and %d users", restored.Version, restored.Users.Len())
+			}
+		},
+	)
+
+	t.Run(
+		"Invalid", func(t *testing.T) {
+			sm := New[string, int](10)
+			sm.Store("a", 1)
+			if err := sm.GobDecode([]byte("not gob")); err == nil {
+				t.Error("Expected an error")
+			}
+			if sm.Len() != 1 {
+				t.Errorf("Expected the map to be unchanged, got %d entries", sm.Len())
+			}
+		},
+	)
+}
@@ -0,0 +1,124 @@
+package syncmap
+
+import (
+	"sort"
+	"unsafe"
+)
+
+// GroupMember is a map that can take part in a group snapshot.
+// It is implemented by *SyncMap, whatever its type parameters.
+type GroupMember interface {
+	Version() uint64
+
+	// lockOrder identifies the map, and orders the acquisition of the locks of several maps.
+	lockOrder() uintptr
+	// rlock and runlock acquire and release the read lock, see WithDeadlockDetection.
+	rlock()
+	runlock()
+	// snapshotLocked copies the contents; the caller holds at least a read lock.
+	snapshotLocked() any
+}
+
+// Group is a set of maps that are snapshotted together, e.g. because application state
+// spans several maps that must be persisted coherently.
+type Group struct {
+	members []GroupMember
+}
+
+// NewGroup creates a Group of the given maps.
+func NewGroup(members ...GroupMember) *Group {
+	return &Group{members: members}
+}
+
+// SnapshotGroup captures all maps of the group at a mutually consistent point.
+func (g *Group) SnapshotGroup() GroupSnapshot {
+	return SnapshotGroup(g.members...)
+}
+
+// GroupSnapshot holds copies of the contents of several maps, captured at the same point in time.
+// Use SnapshotOf to access the contents of a single map.
+type GroupSnapshot struct {
+	maps        map[GroupMember]any
+	generations map[GroupMember]uint64
+}
+
+// SnapshotGroup captures the contents and versions of all given maps at a mutually consistent point:
+// the read locks of all maps are held at the same time while they are copied, so no write to any of them
+// can happen in between. The locks are acquired in a deterministic order, so concurrent group snapshots
+// of overlapping sets of maps cannot deadlock.
+func SnapshotGroup(members ...GroupMember) GroupSnapshot {
+	unlock := rlockAll(members)
+	defer unlock()
+
+	s := GroupSnapshot{
+		maps:        make(map[GroupMember]any, len(members)),
+		generations: make(map[GroupMember]uint64, len(members)),
+	}
+	for _, member := range members {
+		s.maps[member] = member.snapshotLocked()
+		s.generations[member] = member.Version()
+	}
+
+	return s
+}
+
+// SnapshotOf returns the contents and version of m captured in the group snapshot s.
+// The ok result is false if m was not part of the snapshot.
+func SnapshotOf[K comparable, V any](s GroupSnapshot, m *SyncMap[K, V]) (data map[K]V, version uint64, ok bool) {
+	d, ok := s.maps[m]
+	if !ok {
+		return nil, 0, false
+	}
+
+	return d.(map[K]V), s.generations[m], true
+}
+
+func (m *SyncMap[K, V]) lockOrder() uintptr {
+	return uintptr(unsafe.Pointer(m))
+}
+
+func (m *SyncMap[K, V]) snapshotLocked() any {
+	data := make(map[K]V, len(m.data))
+	for k, v := range m.entries() {
+		data[k] = v
+	}
+	return data
+}
+
+// rlockAll read-locks all members in lock order and returns a function releasing them.
+// If a lock panics, e.g. because the goroutine holds the write lock of a map created WithDeadlockDetection,
+// the locks already acquired are released.
+func rlockAll(members []GroupMember) func() {
+	ordered := make([]GroupMember, 0, len(members))
+	seen := make(map[uintptr]bool, len(members))
+	for _, member := range members {
+		if !seen[member.lockOrder()] {
+			seen[member.lockOrder()] = true
+			ordered = append(ordered, member)
+		}
+	}
+
+	sort.Slice(
+		ordered, func(i, j int) bool {
+			return ordered[i].lockOrder() < ordered[j].lockOrder()
+		},
+	)
+
+	locked := 0
+	unlock := func() {
+		for i := locked - 1; i >= 0; i-- {
+			ordered[i].runlock()
+		}
+	}
+	defer func() {
+		if locked < len(ordered) {
+			unlock()
+		}
+	}()
+
+	for _, member := range ordered {
+		member.rlock()
+		locked++
+	}
+	return unlock
+}
@@ -0,0 +1,91 @@
+package syncmap
+
+import (
+	"maps"
+	"sync"
+	"testing"
+)
+
+func TestSnapshotGroup(t *testing.T) {
+	users := New[string, int](10)
+	names := New[int, string](10)
+
+	users.Store("alice", 1)
+	names.Store(1, "alice")
+
+	group := NewGroup(users, names)
+
+	t.Run(
+		"Contents and versions", func(t *testing.T) {
+			s := group.SnapshotGroup()
+
+			u, uv, ok := SnapshotOf(s, users)
+			if !ok || !maps.Equal(u, map[string]int{"alice": 1}) || uv != users.Version() {
+				t.Errorf("Unexpected users snapshot %v at version %d", u, uv)
+			}
+
+			n, nv, ok := SnapshotOf(s, names)
+			if !ok || !maps.Equal(n, map[int]string{1: "alice"}) || nv != names.Version() {
+				t.Errorf("Unexpected names snapshot %v at version %d", n, nv)
+			}
+
+			if _, _, ok := SnapshotOf(s, New[string, int](1)); ok {
+				t.Error("SnapshotOf should return false for a map outside the group")
+			}
+
+			users.Store("bob", 2)
+			if u, _, _ := SnapshotOf(s, users); len(u) != 1 {
+				t.Error("Snapshot should not change after the map is modified")
+			}
+		},
+	)
+
+	t.Run(
+		"Consistency", func(t *testing.T) {
+			// the writer keeps both maps in sync inside nested critical sections,
+			// so every group snapshot must observe the same number of entries in both.
+			const iterations = 1000
+
+			users.Purge()
+			names.Purge()
+
+			var wg sync.WaitGroup
+			wg.Add(2)
+
+			go func() {
+				defer wg.Done()
+				for i := 0; i < iterations; i++ {
+					users.DoLocked(
+						func(u LockedMap[string, int]) {
+							names.DoLocked(
+								func(n LockedMap[int, string]) {
+									u.Purge()
+									n.Purge()
+									for j := 0; j < i%5; j++ {
+										u.Store(string(rune('a'+j)), j)
+										n.Store(j, string(rune('a'+j)))
+									}
+								},
+							)
+						},
+					)
+				}
+			}()
+
+			go func() {
+				defer wg.Done()
+				for i := 0; i < iterations; i++ {
+					s := SnapshotGroup(names, users, users)
+					u, _, _ := SnapshotOf(s, users)
+					n, _, _ := SnapshotOf(s, names)
+					if len(u) != len(n) {
+						t.Errorf("Inconsistent snapshot: %d users, %d names", len(u), len(n))
+						return
+					}
+				}
+			}()
+
+			wg.Wait()
+		},
+	)
+}
@@ -0,0 +1,169 @@
+package syncmap
+
+import (
+	"sync"
+)
+
+// HashMap is a thread-safe map whose keys need not be comparable, such as []byte or structs containing slices.
+// Keys are hashed and compared with the functions given to NewHashMap instead of Go's built-in equality.
+// It offers the core operations of SyncMap; operations returning Go maps are not available,
+// as those require comparable keys.
+// The map keeps the keys it is given, so keys must not be modified once stored.
+//
+// Type parameters:
+//
+//	K: can be any type (used as map keys)
+//	V: can be any type (used as map values)
+type HashMap[K, V any] struct {
+	_     noCopy //nolint:unused // Prevent direct copying of HashMap by embedding it in another struct.
+	mu    sync.RWMutex
+	hash  func(k K) uint64
+	equal func(a, b K) bool
+	// entries with colliding hashes share a bucket
+	buckets map[uint64][]hashEntry[K, V]
+	size    int
+}
+
+type hashEntry[K, V any] struct {
+	key   K
+	value V
+}
+
+// NewHashMap creates and returns a new HashMap with the specified initial size.
+// hash must return equal hashes for keys that are equal according to equal.
+// Neither function may call methods of the HashMap.
+func NewHashMap[K, V any](hash func(k K) uint64, equal func(a, b K) bool, size int) *HashMap[K, V] {
+	return &HashMap[K, V]{
+		hash:    hash,
+		equal:   equal,
+		buckets: make(map[uint64][]hashEntry[K, V], size),
+	}
+}
+
+// Store sets the value for a key.
+// It acquires a write lock to ensure thread-safe access to the underlying data.
+func (m *HashMap[K, V]) Store(k K, v V) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.store(m.hash(k), k, v)
+}
+
+// Load retrieves the value associated with the given key.
+// It acquires a read lock to ensure thread-safe access to the underlying data.
+func (m *HashMap[K, V]) Load(k K) (V, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	h := m.hash(k)
+	if i := m.find(h, k); i >= 0 {
+		return m.buckets[h][i].value, true
+	}
+	var zero V
+	return zero, false
+}
+
+// Remove deletes the value associated with the given key.
+// It returns true if the key was present and removed, false otherwise.
+// It acquires a write lock to ensure thread-safe access to the underlying data.
+func (m *HashMap[K, V]) Remove(k K) bool {
+	_, ok := m.LoadAndDelete(k)
+	return ok
+}
+
+// LoadOrStore returns the existing value for the key if present.
+// Otherwise, it stores and returns the given value.
+// The loaded result is true if the value was loaded, false if stored.
+// It acquires a write lock to ensure thread-safe access to the underlying data.
+func (m *HashMap[K, V]) LoadOrStore(key K, value V) (V, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	h := m.hash(key)
+	if i := m.find(h, key); i >= 0 {
+		return m.buckets[h][i].value, true
+	}
+	m.store(h, key, value)
+	return value, false
+}
+
+// LoadAndDelete deletes the value for a key, returning the previous value if any.
+// The loaded result reports whether the key was present.
+// It acquires a write lock to ensure thread-safe access to the underlying data.
+func (m *HashMap[K, V]) LoadAndDelete(key K) (V, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	h := m.hash(key)
+	i := m.find(h, key)
+	if i < 0 {
+		var zero V
+		return zero, false
+	}
+
+	bucket := m.buckets[h]
+	v := bucket[i].value
+	if len(bucket) == 1 {
+		delete(m.buckets, h)
+	} else {
+		bucket[i] = bucket[len(bucket)-1]
+		bucket[len(bucket)-1] = hashEntry[K, V]{}
+		m.buckets[h] = bucket[:len(bucket)-1]
+	}
+	m.size--
+	return v, true
+}
+
+// Range calls f sequentially for each key and value present in the map.
+// If f returns false, Range stops the iteration.
+// It acquires a read lock to ensure thread-safe access to the underlying data.
+func (m *HashMap[K, V]) Range(f func(key K, value V) bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, bucket := range m.buckets {
+		for _, e := range bucket {
+			if !f(e.key, e.value) {
+				return
+			}
+		}
+	}
+}
+
+// Purge removes all key-value pairs from the map.
+// It acquires a write lock to ensure thread-safe access to the underlying data.
+func (m *HashMap[K, V]) Purge() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.buckets = make(map[uint64][]hashEntry[K, V])
+	m.size = 0
+}
+
+// Len returns the number of key-value pairs in the map.
+// It acquires a read lock to ensure thread-safe access to the underlying data.
+func (m *HashMap[K, V]) Len() int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.size
+}
+
+// find returns the index of the key in the bucket of hash h, or -1 if it is absent.
+func (m *HashMap[K, V]) find(h uint64, k K) int {
+	for i, e := range m.buckets[h] {
+		if m.equal(e.key, k) {
+			return i
+		}
+	}
+	return -1
+}
+
+func (m *HashMap[K, V]) store(h uint64, k K, v V) {
+	if i := m.find(h, k); i >= 0 {
+		m.buckets[h][i].value = v
+		return
+	}
+	m.buckets[h] = append(m.buckets[h], hashEntry[K, V]{key: k, value: v})
+	m.size++
+}
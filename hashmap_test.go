@@ -0,0 +1,118 @@
+package syncmap
+
+import (
+	"bytes"
+	"fmt"
+	"hash/maphash"
+	"slices"
+	"sync"
+	"testing"
+)
+
+func TestHashMap(t *testing.T) {
+	seed := maphash.MakeSeed()
+	newMap := func() *HashMap[[]byte, int] {
+		return NewHashMap[[]byte, int](
+			func(k []byte) uint64 {
+				return maphash.Bytes(seed, k)
+			}, bytes.Equal, 10,
+		)
+	}
+
+	t.Run(
+		"Basic", func(t *testing.T) {
+			hm := newMap()
+			hm.Store([]byte("key1"), 1)
+			hm.Store([]byte("key2"), 2)
+			hm.Store([]byte("key1"), 3)
+
+			if v, ok := hm.Load([]byte("key1")); !ok || v != 3 {
+				t.Errorf("Expected (3, true), got (%v, %v)", v, ok)
+			}
+			if _, ok := hm.Load([]byte("missing")); ok {
+				t.Error("Load should return false for non-existent key")
+			}
+			if hm.Len() != 2 {
+				t.Errorf("Expected 2, got %d", hm.Len())
+			}
+
+			if v, loaded := hm.LoadOrStore([]byte("key2"), 0); !loaded || v != 2 {
+				t.Errorf("Expected (2, true), got (%v, %v)", v, loaded)
+			}
+			if v, loaded := hm.LoadOrStore([]byte("key3"), 3); loaded || v != 3 {
+				t.Errorf("Expected (3, false), got (%v, %v)", v, loaded)
+			}
+			if v, loaded := hm.LoadAndDelete([]byte("key3")); !loaded || v != 3 {
+				t.Errorf("Expected (3, true), got (%v, %v)", v, loaded)
+			}
+			if !hm.Remove([]byte("key2")) || hm.Remove([]byte("key2")) {
+				t.Error("Remove should only succeed once")
+			}
+
+			var keys []string
+			hm.Range(
+				func(key []byte, value int) bool {
+					keys = append(keys, string(key))
+					return true
+				},
+			)
+			if !slices.Equal(keys, []string{"key1"}) {
+				t.Errorf("Expected [key1], got %v", keys)
+			}
+
+			hm.Purge()
+			if hm.Len() != 0 {
+				t.Errorf("Expected 0 after Purge, got %d", hm.Len())
+			}
+		},
+	)
+
+	t.Run(
+		"Collisions", func(t *testing.T) {
+			// all keys share a bucket
+			hm := NewHashMap[[]int, string](
+				func(k []int) uint64 {
+					return 0
+				}, slices.Equal[[]int], 10,
+			)
+			for i := 0; i < 10; i++ {
+				hm.Store([]int{i, i}, fmt.Sprint(i))
+			}
+			hm.Remove([]int{3, 3})
+			hm.Remove([]int{0, 0})
+
+			if hm.Len() != 8 {
+				t.Errorf("Expected 8, got %d", hm.Len())
+			}
+			for i := 0; i < 10; i++ {
+				v, ok := hm.Load([]int{i, i})
+				if expected := i != 0 && i != 3; ok != expected || (ok && v != fmt.Sprint(i)) {
+					t.Errorf("Unexpected (%v, %v) for key %d", v, ok, i)
+				}
+			}
+		},
+	)
+
+	t.Run(
+		"Concurrent", func(t *testing.T) {
+			hm := newMap()
+			var wg sync.WaitGroup
+			for w := 0; w < 8; w++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					for i := 0; i < 100; i++ {
+						key := []byte(fmt.Sprint(i))
+						hm.LoadOrStore(key, i)
+						hm.Load(key)
+					}
+				}()
+			}
+			wg.Wait()
+
+			if hm.Len() != 100 {
+				t.Errorf("Expected 100, got %d", hm.Len())
+			}
+		},
+	)
+}
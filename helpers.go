@@ -0,0 +1,288 @@
+package syncmap
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+)
+
+// Equal reports whether two SyncMaps contain the same key-value pairs.
+// Values are compared using ==.
+// It acquires read locks on both maps to ensure thread-safe access to the underlying data.
+func Equal[K, V comparable](m1, m2 *SyncMap[K, V]) bool {
+	return EqualFunc(
+		m1, m2, func(v1, v2 V) bool {
+			return v1 == v2
+		},
+	)
+}
+
+// EqualFunc is like Equal, but compares values using eq.
+// Keys are still compared with ==.
+// It acquires read locks on both maps to ensure thread-safe access to the underlying data.
+func EqualFunc[K comparable, V1, V2 any](m1 *SyncMap[K, V1], m2 *SyncMap[K, V2], eq func(V1, V2) bool) bool {
+	unlock := rlockPair(m1, m2)
+	defer unlock()
+
+	if m1.count() != m2.count() {
+		return false
+	}
+
+	for k, v1 := range m1.entries() {
+		if v2, ok := m2.load(k); !ok || !eq(v1, v2) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Reduce folds all key-value pairs of the SyncMap into a single result.
+// It calls fn for each entry, passing the accumulated result (starting with init), and returns the final result.
+// The iteration order is not specified.
+// It acquires a read lock to ensure thread-safe access to the underlying data.
+func Reduce[K comparable, V, R any](m *SyncMap[K, V], init R, fn func(acc R, k K, v V) R) R {
+	m.rlock()
+	defer m.runlock()
+
+	acc := init
+	for k, v := range m.entries() {
+		acc = fn(acc, k, v)
+	}
+
+	return acc
+}
+
+// MapTo applies fn to all key-value pairs in the SyncMap and returns a new map with the results.
+// Unlike the Map method, the values of the resulting map may be of a different type.
+// It acquires a read lock to ensure thread-safe access to the underlying data.
+func MapTo[K comparable, V, R any](m *SyncMap[K, V], fn func(k K, v V) R) map[K]R {
+	m.rlock()
+	defer m.runlock()
+
+	data := make(map[K]R, len(m.data))
+	for k, v := range m.entries() {
+		data[k] = fn(k, v)
+	}
+
+	return data
+}
+
+// GroupBy groups the entries of the SyncMap by the group key returned by keyFn.
+// The order of entries within a group is not specified.
+// It acquires a read lock to ensure thread-safe access to the underlying data.
+func GroupBy[K comparable, V any, G comparable](m *SyncMap[K, V], keyFn func(k K, v V) G) map[G][]Entry[K, V] {
+	m.rlock()
+	defer m.runlock()
+
+	groups := make(map[G][]Entry[K, V])
+	for k, v := range m.entries() {
+		g := keyFn(k, v)
+		groups[g] = append(groups[g], Entry[K, V]{Key: k, Value: v})
+	}
+
+	return groups
+}
+
+// KeysOfValue returns all keys of the SyncMap whose value is equal to v.
+// The order of the returned keys is not specified.
+// It acquires a read lock to ensure thread-safe access to the underlying data.
+func KeysOfValue[K, V comparable](m *SyncMap[K, V], v V) []K {
+	m.rlock()
+	defer m.runlock()
+
+	keys := make([]K, 0)
+	for k, value := range m.entries() {
+		if value == v {
+			keys = append(keys, k)
+		}
+	}
+
+	return keys
+}
+
+// DoLocked executes a function with exclusive access to the SyncMap and returns its result.
+// It is a typed alternative to the DoLockedWithResult method, which returns any.
+// It acquires a write lock before executing the function and releases it afterward.
+func DoLocked[K comparable, V, R any](m *SyncMap[K, V], f func(LockedMap[K, V]) R) R {
+	m.lock()
+	defer m.unlock()
+	lm := &lockedMap[K, V]{m: m}
+	defer lm.invalidate()
+	return f(lm)
+}
+
+// DoLockedErr executes a function with exclusive access to the SyncMap and returns its result and error.
+// It is the typed, error-returning counterpart of the DoLockedWithResult method.
+// Changes made by f before it fails are not rolled back.
+// It acquires a write lock before executing the function and releases it afterward.
+func DoLockedErr[K comparable, V, R any](m *SyncMap[K, V], f func(LockedMap[K, V]) (R, error)) (R, error) {
+	m.lock()
+	defer m.unlock()
+	lm := &lockedMap[K, V]{m: m}
+	defer lm.invalidate()
+	return f(lm)
+}
+
+// DoLockedMulti executes a function with exclusive access to all the given SyncMaps at once,
+// e.g. to move entries from one map to another atomically.
+// f receives a LockedMap for every map, as its variadic arguments, in the order of ms;
+// a map passed several times is locked once.
+// The locks are always acquired in a deterministic (address) order, so that concurrent callers
+// locking the same maps in a different argument order cannot deadlock.
+// Nesting DoLocked calls on several maps does not give this guarantee.
+func DoLockedMulti[K comparable, V any](f func(maps ...LockedMap[K, V]), ms ...*SyncMap[K, V]) {
+	ordered := slices.Clone(ms)
+	slices.SortFunc(
+		ordered, func(a, b *SyncMap[K, V]) int {
+			return cmp.Compare(a.lockOrder(), b.lockOrder())
+		},
+	)
+	ordered = slices.Compact(ordered)
+
+	locked := make(map[*SyncMap[K, V]]*lockedMap[K, V], len(ordered))
+	defer func() {
+		// run the queued callbacks only once all the maps are unlocked, as they may use any of them
+		var pending []func()
+		for i := len(ordered) - 1; i >= 0; i-- {
+			if lm, ok := locked[ordered[i]]; ok {
+				lm.invalidate()
+				pending = append(pending, ordered[i].release()...)
+			}
+		}
+		for _, f := range pending {
+			f()
+		}
+	}()
+	for _, m := range ordered {
+		m.lock()
+		locked[m] = &lockedMap[K, V]{m: m}
+	}
+
+	maps := make([]LockedMap[K, V], len(ms))
+	for i, m := range ms {
+		maps[i] = locked[m]
+	}
+	f(maps...)
+}
+
+// ReduceLocked is like Reduce, but folds over a map that is already locked, so that a critical section
+// started with DoLocked or DoRLocked can compute aggregates over the current state before deciding on writes.
+// It accepts both LockedMap and ReadOnlyLockedMap.
+func ReduceLocked[K comparable, V, R any](m ReadOnlyLockedMap[K, V], init R, fn func(acc R, k K, v V) R) R {
+	acc := init
+	m.Range(
+		func(k K, v V) bool {
+			acc = fn(acc, k, v)
+			return true
+		},
+	)
+
+	return acc
+}
+
+// DuplicatePolicy determines how Invert handles several keys having the same value.
+type DuplicatePolicy int
+
+const (
+	// DuplicatesKeepAny keeps one of the keys having the same value, chosen arbitrarily.
+	DuplicatesKeepAny DuplicatePolicy = iota
+	// DuplicatesError makes Invert fail with ErrDuplicateValue.
+	DuplicatesError
+)
+
+// Invert returns a new map from the values of the SyncMap to their keys.
+// Several keys having the same value are handled according to policy;
+// with DuplicatesError, Invert returns a nil map and an error wrapping ErrDuplicateValue.
+// It acquires a read lock to ensure thread-safe access to the underlying data.
+func Invert[K, V comparable](m *SyncMap[K, V], policy DuplicatePolicy) (map[V]K, error) {
+	m.rlock()
+	defer m.runlock()
+
+	inverted := make(map[V]K, len(m.data))
+	for k, v := range m.entries() {
+		if prev, ok := inverted[v]; ok && policy == DuplicatesError {
+			return nil, fmt.Errorf("%w: %v (keys %v and %v)", ErrDuplicateValue, v, prev, k)
+		}
+		inverted[v] = k
+	}
+
+	return inverted, nil
+}
+
+// Number is a constraint that permits any integer or floating-point type.
+type Number interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 |
+		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr |
+		~float32 | ~float64
+}
+
+// Sum returns the sum of all values in the SyncMap, or 0 if the map is empty.
+// It acquires a read lock to ensure thread-safe access to the underlying data.
+func Sum[K comparable, V Number](m *SyncMap[K, V]) V {
+	m.rlock()
+	defer m.runlock()
+
+	var sum V
+	for _, v := range m.entries() {
+		sum += v
+	}
+
+	return sum
+}
+
+// Avg returns the arithmetic mean of all values in the SyncMap, or 0 if the map is empty.
+// The values are accumulated as float64, so the result does not overflow for large integer values.
+// It acquires a read lock to ensure thread-safe access to the underlying data.
+func Avg[K comparable, V Number](m *SyncMap[K, V]) float64 {
+	m.rlock()
+	defer m.runlock()
+
+	n := m.count()
+	if n == 0 {
+		return 0
+	}
+
+	var sum float64
+	for _, v := range m.entries() {
+		sum += float64(v)
+	}
+
+	return sum / float64(n)
+}
+
+// rlockPair read-locks both maps and returns a function releasing them.
+// The locks are always acquired in lock order, so that concurrent callers locking
+// the same pair in opposite argument order cannot deadlock with a pending writer.
+func rlockPair(a, b GroupMember) func() {
+	return rlockAll([]GroupMember{a, b})
+}
+
+// entryHeap is a min-heap of entries ordered by less, implementing heap.Interface.
+type entryHeap[K comparable, V any] struct {
+	entries []Entry[K, V]
+	less    func(a, b Entry[K, V]) bool
+}
+
+func (h *entryHeap[K, V]) Len() int {
+	return len(h.entries)
+}
+
+func (h *entryHeap[K, V]) Less(i, j int) bool {
+	return h.less(h.entries[i], h.entries[j])
+}
+
+func (h *entryHeap[K, V]) Swap(i, j int) {
+	h.entries[i], h.entries[j] = h.entries[j], h.entries[i]
+}
+
+func (h *entryHeap[K, V]) Push(x any) {
+	h.entries = append(h.entries, x.(Entry[K, V]))
+}
+
+func (h *entryHeap[K, V]) Pop() any {
+	last := len(h.entries) - 1
+	e := h.entries[last]
+	h.entries = h.entries[:last]
+	return e
+}
@@ -0,0 +1,415 @@
+package syncmap
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestEqual(t *testing.T) {
+	sm1 := New[string, int](10)
+	sm2 := New[string, int](10)
+
+	t.Run(
+		"Empty maps", func(t *testing.T) {
+			if !Equal(sm1, sm2) {
+				t.Error("Empty maps should be equal")
+			}
+			if !Equal(sm1, sm1) {
+				t.Error("A map should be equal to itself")
+			}
+		},
+	)
+
+	t.Run(
+		"Same contents", func(t *testing.T) {
+			sm1.Store("key1", 1)
+			sm1.Store("key2", 2)
+			sm2.Store("key2", 2)
+			sm2.Store("key1", 1)
+
+			if !Equal(sm1, sm2) {
+				t.Error("Maps with the same contents should be equal")
+			}
+		},
+	)
+
+	t.Run(
+		"Different contents", func(t *testing.T) {
+			sm2.Store("key2", 3)
+			if Equal(sm1, sm2) {
+				t.Error("Maps with different values should not be equal")
+			}
+
+			sm2.Store("key2", 2)
+			sm2.Store("key3", 3)
+			if Equal(sm1, sm2) {
+				t.Error("Maps with different lengths should not be equal")
+			}
+		},
+	)
+
+	t.Run(
+		"Concurrent comparisons in opposite order", func(t *testing.T) {
+			var wg sync.WaitGroup
+			wg.Add(3)
+
+			go func() {
+				defer wg.Done()
+				for i := 0; i < 1000; i++ {
+					Equal(sm1, sm2)
+				}
+			}()
+			go func() {
+				defer wg.Done()
+				for i := 0; i < 1000; i++ {
+					Equal(sm2, sm1)
+				}
+			}()
+			go func() {
+				defer wg.Done()
+				for i := 0; i < 1000; i++ {
+					sm1.Store("key4", i)
+					sm2.Store("key4", i)
+				}
+			}()
+
+			wg.Wait()
+		},
+	)
+}
+
+func TestEqualFunc(t *testing.T) {
+	sm1 := New[string, int](10)
+	sm2 := New[string, string](10)
+
+	sm1.Store("key1", 1)
+	sm2.Store("key1", "1")
+
+	eq := func(v1 int, v2 string) bool {
+		return strings.Repeat("1", v1) == v2
+	}
+
+	if !EqualFunc(sm1, sm2, eq) {
+		t.Error("Maps should be equal according to eq")
+	}
+
+	sm2.Store("key1", "11")
+	if EqualFunc(sm1, sm2, eq) {
+		t.Error("Maps should not be equal according to eq")
+	}
+}
+
+func TestReduce(t *testing.T) {
+	sm := New[string, int](10)
+
+	total := Reduce(
+		sm, 0, func(acc int, k string, v int) int {
+			return acc + v
+		},
+	)
+	if total != 0 {
+		t.Errorf("Expected 0 for an empty map, got %d", total)
+	}
+
+	sm.Store("a", 1)
+	sm.Store("bb", 2)
+	sm.Store("ccc", 3)
+
+	total = Reduce(
+		sm, 0, func(acc int, k string, v int) int {
+			return acc + v
+		},
+	)
+	if total != 6 {
+		t.Errorf("Expected 6, got %d", total)
+	}
+
+	keyLen := Reduce(
+		sm, int64(0), func(acc int64, k string, v int) int64 {
+			return acc + int64(len(k))
+		},
+	)
+	if keyLen != 6 {
+		t.Errorf("Expected 6, got %d", keyLen)
+	}
+}
+
+func TestKeysOfValue(t *testing.T) {
+	sm := New[string, int](10)
+	sm.Store("key1", 1)
+	sm.Store("key2", 2)
+	sm.Store("key3", 1)
+
+	keys := KeysOfValue(sm, 1)
+	slices.Sort(keys)
+	if !slices.Equal(keys, []string{"key1", "key3"}) {
+		t.Errorf("Expected [key1 key3], got %v", keys)
+	}
+
+	if keys := KeysOfValue(sm, 5); len(keys) != 0 {
+		t.Errorf("Expected no keys, got %v", keys)
+	}
+}
+
+func TestMapTo(t *testing.T) {
+	type user struct {
+		Name string
+		Age  int
+	}
+
+	sm := New[int, user](10)
+	sm.Store(1, user{Name: "alice", Age: 30})
+	sm.Store(2, user{Name: "bob", Age: 25})
+
+	summaries := MapTo(
+		sm, func(id int, u user) string {
+			return fmt.Sprintf("%d:%s(%d)", id, u.Name, u.Age)
+		},
+	)
+
+	expected := map[int]string{1: "1:alice(30)", 2: "2:bob(25)"}
+	if !maps.Equal(summaries, expected) {
+		t.Errorf("Expected %v, got %v", expected, summaries)
+	}
+}
+
+func TestGroupBy(t *testing.T) {
+	sm := New[string, int](10)
+	sm.Store("a", 1)
+	sm.Store("b", 2)
+	sm.Store("c", 3)
+	sm.Store("d", 4)
+	sm.Store("e", 5)
+
+	groups := GroupBy(
+		sm, func(k string, v int) bool {
+			return v%2 == 0
+		},
+	)
+
+	if len(groups) != 2 {
+		t.Fatalf("Expected 2 groups, got %d", len(groups))
+	}
+
+	keysOf := func(entries []Entry[string, int]) []string {
+		keys := make([]string, 0, len(entries))
+		for _, e := range entries {
+			if e.Value != int(e.Key[0]-'a'+1) {
+				t.Errorf("Unexpected entry %+v", e)
+			}
+			keys = append(keys, e.Key)
+		}
+		slices.Sort(keys)
+		return keys
+	}
+
+	if keys := keysOf(groups[true]); !slices.Equal(keys, []string{"b", "d"}) {
+		t.Errorf("Expected [b d], got %v", keys)
+	}
+	if keys := keysOf(groups[false]); !slices.Equal(keys, []string{"a", "c", "e"}) {
+		t.Errorf("Expected [a c e], got %v", keys)
+	}
+}
+
+func TestSumAvg(t *testing.T) {
+	ints := New[string, int](10)
+	if Sum(ints) != 0 || Avg(ints) != 0 {
+		t.Error("Expected Sum and Avg of an empty map to be 0")
+	}
+
+	ints.Store("a", 1)
+	ints.Store("b", 2)
+	ints.Store("c", 4)
+
+	if s := Sum(ints); s != 7 {
+		t.Errorf("Expected sum 7, got %d", s)
+	}
+	if a := Avg(ints); a != 7.0/3.0 {
+		t.Errorf("Expected average %v, got %v", 7.0/3.0, a)
+	}
+
+	type latency float64
+	floats := New[string, latency](10)
+	floats.Store("p50", 1.5)
+	floats.Store("p99", 2.5)
+
+	if s := Sum(floats); s != 4 {
+		t.Errorf("Expected sum 4, got %v", s)
+	}
+	if a := Avg(floats); a != 2 {
+		t.Errorf("Expected average 2, got %v", a)
+	}
+}
+
+func TestInvert(t *testing.T) {
+	sm := New[string, int](10)
+	sm.Store("one", 1)
+	sm.Store("two", 2)
+
+	inverted, err := Invert(sm, DuplicatesError)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if expected := map[int]string{1: "one", 2: "two"}; !maps.Equal(inverted, expected) {
+		t.Errorf("Expected %v, got %v", expected, inverted)
+	}
+
+	sm.Store("uno", 1)
+
+	if _, err := Invert(sm, DuplicatesError); !errors.Is(err, ErrDuplicateValue) {
+		t.Errorf("Expected ErrDuplicateValue, got %v", err)
+	}
+
+	inverted, err = Invert(sm, DuplicatesKeepAny)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(inverted) != 2 || (inverted[1] != "one" && inverted[1] != "uno") || inverted[2] != "two" {
+		t.Errorf("Unexpected inverted map %v", inverted)
+	}
+}
+
+func TestDoLocked(t *testing.T) {
+	sm := New[string, int](10)
+	sm.Store("key1", 1)
+
+	previous := DoLocked(
+		sm, func(m LockedMap[string, int]) int {
+			v, _ := m.Load("key1")
+			m.Store("key1", v+1)
+			return v
+		},
+	)
+	if previous != 1 {
+		t.Errorf("Expected 1, got %v", previous)
+	}
+
+	keys := DoLocked(
+		sm, func(m LockedMap[string, int]) []string {
+			m.Store("key2", 2)
+			return m.Keys()
+		},
+	)
+	slices.Sort(keys)
+	if !slices.Equal(keys, []string{"key1", "key2"}) {
+		t.Errorf("Expected [key1 key2], got %v", keys)
+	}
+	if v, _ := sm.Load("key1"); v != 2 {
+		t.Errorf("Expected 2, got %v", v)
+	}
+}
+
+func TestDoLockedMulti(t *testing.T) {
+	t.Run(
+		"Move", func(t *testing.T) {
+			a := New[string, int](10)
+			b := New[string, int](10)
+			for i := 0; i < 100; i++ {
+				a.Store(fmt.Sprint(i), i)
+			}
+
+			// move entries back and forth, locking the maps in opposite argument orders
+			move := func(from, to *SyncMap[string, int], reversed bool) {
+				ms := []*SyncMap[string, int]{from, to}
+				if reversed {
+					ms = []*SyncMap[string, int]{to, from}
+				}
+				DoLockedMulti(
+					func(maps ...LockedMap[string, int]) {
+						src, dst := maps[0], maps[1]
+						if reversed {
+							src, dst = dst, src
+						}
+						for _, k := range src.Keys() {
+							v, _ := src.LoadAndDelete(k)
+							dst.Store(k, v)
+						}
+					}, ms...,
+				)
+			}
+
+			var wg sync.WaitGroup
+			for i := 0; i < 20; i++ {
+				wg.Add(2)
+				go func() {
+					defer wg.Done()
+					move(a, b, false)
+				}()
+				go func() {
+					defer wg.Done()
+					move(b, a, true)
+				}()
+			}
+			wg.Wait()
+
+			if n := a.Len() + b.Len(); n != 100 {
+				t.Errorf("Expected 100 entries in total, got %d", n)
+			}
+		},
+	)
+
+	t.Run(
+		"SameMapTwice", func(t *testing.T) {
+			sm := New[string, int](10)
+			DoLockedMulti(
+				func(maps ...LockedMap[string, int]) {
+					maps[0].Store("key1", 1)
+					if v, ok := maps[1].Load("key1"); !ok || v != 1 {
+						t.Errorf("Expected (1, true), got (%v, %v)", v, ok)
+					}
+				}, sm, sm,
+			)
+		},
+	)
+
+	t.Run(
+		"CallbacksAfterAllUnlocked", func(t *testing.T) {
+			var a, b *SyncMap[string, int]
+			dispose := func(k string, v int) {
+				a.LenLocked()
+				b.LenLocked()
+			}
+			a = New[string, int](10, WithDisposer[string, int](dispose))
+			b = New[string, int](10, WithDisposer[string, int](dispose))
+			a.Store("key1", 1)
+			b.Store("key1", 1)
+
+			DoLockedMulti(
+				func(maps ...LockedMap[string, int]) {
+					maps[0].Remove("key1")
+					maps[1].Remove("key1")
+				}, a, b,
+			)
+		},
+	)
+}
+
+func TestDoLockedErr(t *testing.T) {
+	sm := New[string, int](10)
+	sm.Store("key1", 1)
+
+	errMissing := errors.New("missing")
+	pop := func(key string) (int, error) {
+		return DoLockedErr(
+			sm, func(m LockedMap[string, int]) (int, error) {
+				v, ok := m.LoadAndDelete(key)
+				if !ok {
+					return 0, errMissing
+				}
+				return v, nil
+			},
+		)
+	}
+
+	if v, err := pop("key1"); err != nil || v != 1 {
+		t.Errorf("Expected 1, got %v, %v", v, err)
+	}
+	if _, err := pop("key1"); !errors.Is(err, errMissing) {
+		t.Errorf("Expected errMissing, got %v", err)
+	}
+}
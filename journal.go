@@ -0,0 +1,399 @@
+package syncmap
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"sync"
+	"time"
+)
+
+// JournalSync is the policy of a journal for syncing its records to stable storage, see WithJournalSync.
+type JournalSync int
+
+const (
+	// JournalSyncPeriodic syncs the journal in the background, every interval. A crash of the machine
+	// loses the records written since the last sync, but a crash of the process loses none. It is the default.
+	JournalSyncPeriodic JournalSync = iota
+	// JournalSyncAlways syncs every record before the write lock of the map is released, so that
+	// no acknowledged change is lost, at the cost of a sync per change.
+	JournalSyncAlways
+	// JournalSyncNever never syncs the journal explicitly, leaving it to the operating system.
+	JournalSyncNever
+)
+
+// JournalOption configures the journal set by WithJournal.
+type JournalOption func(j *journal)
+
+// WithJournalSync sets the sync policy of the journal, and the interval of JournalSyncPeriodic (1s by default).
+// Syncing requires the writer of the journal to have a `Sync() error` method, as *os.File does:
+// other writers are never synced.
+func WithJournalSync(policy JournalSync, interval time.Duration) JournalOption {
+	return func(j *journal) {
+		j.policy = policy
+		if interval > 0 {
+			j.interval = interval
+		}
+	}
+}
+
+// WithJournalErrorHandler sets a function called with the errors of the journal, if writing or syncing it fails.
+// The change that failed to be journaled has been made to the map regardless. onError may be called
+// under the write lock of the map, so it must not use the map.
+// The first error is also returned by SyncJournal and Close.
+func WithJournalErrorHandler(onError func(err error)) JournalOption {
+	return func(j *journal) {
+		j.onError = onError
+	}
+}
+
+// WithJournal makes the map append every change made to it to the write-ahead log w, typically a file opened
+// for appending, so that its contents can be restored with Replay after a restart or a crash.
+// Combined with a periodic snapshot (see SaveToFile), after which the journal is started anew,
+// this makes the map durable.
+//
+// Each record describes one Store, Remove or Purge, whichever method (or LockedMap) made it, or a change
+// of the expiration of an entry (StoreWithTTL, ExpireAt, Persist); evictions and expirations are journaled
+// as removals. Deadlines are journaled as absolute times, so that a replayed entry expires when the original
+// would have; the restarts of sliding TTLs by reads and Touch are not journaled. The key and value of a record are encoded with codec,
+// and the records are framed with their length and a checksum so that a torn write is detected on replay.
+// Records are written under the write lock of the map, in the order of the changes, and synced
+// to stable storage according to the sync policy, see WithJournalSync.
+// Close syncs the journal and stops its background goroutine; it does not close w.
+func WithJournal[K comparable, V any](w io.Writer, codec Codec, opts ...JournalOption) Option[K, V] {
+	return func(m *SyncMap[K, V]) {
+		j := &journal{w: w, codec: codec, interval: time.Second}
+		for _, opt := range opts {
+			opt(j)
+		}
+		if s, ok := w.(interface{ Sync() error }); ok && j.policy != JournalSyncNever {
+			j.syncer = s
+		}
+		if j.syncer != nil && j.policy == JournalSyncPeriodic {
+			j.stop = make(chan struct{})
+			j.done = make(chan struct{})
+			go j.run(j.stop)
+		}
+		m.journal = j
+	}
+}
+
+// SyncJournal syncs the journal of the map to stable storage, whatever its sync policy,
+// and returns the first error that occurred while writing or syncing it, if any.
+// It returns nil if the map has no journal.
+func (m *SyncMap[K, V]) SyncJournal() error {
+	if m.journal == nil {
+		return nil
+	}
+	m.journal.sync()
+	return m.journal.error()
+}
+
+// JournalCorruptError is returned by Replay when the journal ends with an incomplete or corrupt record,
+// e.g. because the process crashed while writing it. The records before Offset have been replayed:
+// the journal should be truncated at Offset before records are appended to it again.
+type JournalCorruptError struct {
+	// Offset is the number of bytes of the journal before the invalid record.
+	Offset int64
+	// Reason describes what is wrong with the record.
+	Reason string
+}
+
+func (e *JournalCorruptError) Error() string {
+	return fmt.Sprintf("syncmap: corrupt journal record at offset %d: %s", e.Offset, e.Reason)
+}
+
+// Replay applies the changes recorded in the journal read from r to the map, in order,
+// to restore its contents. The changes are decoded with the codec of the journal of the map, and are neither
+// journaled again nor persisted by the writer of the map, if any (see WithWriter). The whole journal is read
+// before the map is locked, and the changes are then applied under a single write lock. If the journal ends
+// with an incomplete or corrupt record, the changes before it are applied and a *JournalCorruptError is returned.
+// A replay without error marks the map as ready (see WithReadyGate).
+// It returns ErrNoJournal if the map was created without WithJournal.
+func (m *SyncMap[K, V]) Replay(r io.Reader) error {
+	if m.journal == nil {
+		return ErrNoJournal
+	}
+
+	records, readErr := readJournal[K, V](bufio.NewReader(r), m.journal.codec)
+	if _, ok := readErr.(*JournalCorruptError); readErr != nil && !ok {
+		return readErr
+	}
+
+	m.lock()
+	defer m.unlock()
+
+	m.replaying = true
+	defer func() {
+		m.replaying = false
+	}()
+
+	var errs []error
+	for _, rec := range records {
+		k := m.key(rec.Key)
+		switch Op(rec.Op) {
+		case OpStore:
+			// a replay restores values, which are not written to the writer of the map again
+			stored, err := m.put(k, rec.Value, false)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("key %v: %w", rec.Key, err))
+			}
+			if stored && rec.Expires != 0 {
+				m.restoreDeadline(k, rec)
+			}
+		case journalDeadline:
+			if _, ok := m.data[k]; !ok {
+				break
+			}
+			if rec.Expires == 0 {
+				delete(m.expiry, k)
+			} else {
+				m.restoreDeadline(k, rec)
+			}
+			m.version.Add(1)
+		case OpDelete:
+			m.drop(k)
+		case OpPurge:
+			m.purge()
+		}
+	}
+	if err := errors.Join(append(errs, readErr)...); err != nil {
+		return err
+	}
+	m.MarkReady()
+	return nil
+}
+
+// restoreDeadline sets the expiration of the entry of k journaled in rec.
+func (m *SyncMap[K, V]) restoreDeadline(k K, rec journalRecord[K, V]) {
+	d := &deadline{ttl: time.Duration(rec.TTL)}
+	d.at.Store(rec.Expires)
+	if m.expiry == nil {
+		m.expiry = make(map[K]*deadline)
+	}
+	m.expiry[k] = d
+}
+
+// journalDeadline is the op of the records of the changes of the expiration of an entry, which have no Op.
+const journalDeadline = 0x80
+
+// journalRecord is the payload of a journal record.
+type journalRecord[K comparable, V any] struct {
+	Op    uint8
+	Key   K `json:",omitempty"`
+	Value V `json:",omitempty"`
+	// Expires is the deadline of the entry in nanoseconds since the Unix epoch, 0 if it has none,
+	// and TTL the duration it was set with.
+	Expires int64 `json:",omitempty"`
+	TTL     int64 `json:",omitempty"`
+}
+
+// journal is the write-ahead log of a map, see WithJournal.
+type journal struct {
+	codec    Codec
+	policy   JournalSync
+	interval time.Duration
+	onError  func(err error)
+
+	// serializes writes, which are made under the write lock of the map, with background syncs
+	mu     sync.Mutex
+	w      io.Writer
+	syncer interface{ Sync() error }
+	// set when records were written since the last sync
+	dirty bool
+	err   error
+	buf   []byte
+
+	// background sync goroutine of JournalSyncPeriodic, nil otherwise
+	stop chan struct{}
+	done chan struct{}
+}
+
+// record journals the change described by e. It assumes that the caller holds the write lock of the map.
+func (m *SyncMap[K, V]) record(e Event[K, V]) {
+	if m.journal == nil || m.replaying {
+		return
+	}
+
+	rec := journalRecord[K, V]{Op: uint8(e.Op), Key: e.Key}
+	switch e.Op {
+	case OpStore:
+		rec.Value = e.New
+		// the default TTL, if any, is set before the event
+		rec.Expires, rec.TTL = m.journaledDeadline(e.Key)
+	case OpEvict, OpExpire:
+		rec.Op = uint8(OpDelete)
+	}
+	m.journal.append(rec)
+}
+
+// recordDeadline journals the expiration of the entry of k, after it was set or removed.
+// It assumes that the caller holds the write lock of the map.
+func (m *SyncMap[K, V]) recordDeadline(k K) {
+	if m.journal == nil || m.replaying {
+		return
+	}
+
+	rec := journalRecord[K, V]{Op: journalDeadline, Key: k}
+	rec.Expires, rec.TTL = m.journaledDeadline(k)
+	m.journal.append(rec)
+}
+
+// journaledDeadline returns the deadline and the TTL of the entry of k, or zeros if it does not expire.
+func (m *SyncMap[K, V]) journaledDeadline(k K) (expires, ttl int64) {
+	d, ok := m.expiry[k]
+	if !ok {
+		return 0, 0
+	}
+	return d.at.Load(), int64(d.ttl)
+}
+
+// crcTable is the Castagnoli polynomial, which is hardware accelerated on most platforms.
+var crcTable = crc32.MakeTable(crc32.Castagnoli)
+
+// append writes a record holding the encoding of rec: its length as a uvarint, its CRC-32C, then the encoding.
+func (j *journal) append(rec any) {
+	payload, err := j.codec.Encode(rec)
+	if err != nil {
+		j.fail(err)
+		return
+	}
+
+	j.mu.Lock()
+	j.buf = binary.AppendUvarint(j.buf[:0], uint64(len(payload)))
+	j.buf = binary.BigEndian.AppendUint32(j.buf, crc32.Checksum(payload, crcTable))
+	j.buf = append(j.buf, payload...)
+	_, err = j.w.Write(j.buf)
+	if err == nil {
+		j.dirty = true
+		if j.policy == JournalSyncAlways {
+			err = j.syncLocked()
+		}
+	}
+	j.mu.Unlock()
+
+	if err != nil {
+		j.fail(err)
+	}
+}
+
+// readJournal decodes the records of a journal.
+func readJournal[K comparable, V any](r *bufio.Reader, codec Codec) ([]journalRecord[K, V], error) {
+	var records []journalRecord[K, V]
+	var offset int64
+	for {
+		if _, err := r.Peek(1); err == io.EOF {
+			return records, nil
+		}
+
+		n, err := binary.ReadUvarint(r)
+		if err != nil {
+			return records, corruptJournal(offset, "bad length", err)
+		}
+		var head [4]byte
+		if _, err := io.ReadFull(r, head[:]); err != nil {
+			return records, corruptJournal(offset, "bad checksum", err)
+		}
+		payload := make([]byte, n)
+		if _, err := io.ReadFull(r, payload); err != nil {
+			return records, corruptJournal(offset, "incomplete payload", err)
+		}
+		if crc32.Checksum(payload, crcTable) != binary.BigEndian.Uint32(head[:]) {
+			return records, corruptJournal(offset, "checksum mismatch", nil)
+		}
+
+		var rec journalRecord[K, V]
+		if err := codec.Decode(payload, &rec); err != nil {
+			return records, corruptJournal(offset, err.Error(), nil)
+		}
+		records = append(records, rec)
+		offset += int64(len(binary.AppendUvarint(nil, n))) + 4 + int64(n)
+	}
+}
+
+// corruptJournal returns the error reporting an invalid record at offset, or err itself
+// if it is an error of the reader rather than a truncated record.
+func corruptJournal(offset int64, reason string, err error) error {
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return err
+	}
+	return &JournalCorruptError{Offset: offset, Reason: reason}
+}
+
+// run syncs the journal every interval until it is closed.
+func (j *journal) run(stop <-chan struct{}) {
+	defer close(j.done)
+
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			j.sync()
+		case <-stop:
+			return
+		}
+	}
+}
+
+// sync syncs the records written since the last sync, if any.
+func (j *journal) sync() {
+	j.mu.Lock()
+	err := j.syncLocked()
+	j.mu.Unlock()
+
+	if err != nil {
+		j.fail(err)
+	}
+}
+
+func (j *journal) syncLocked() error {
+	if j.syncer == nil || !j.dirty {
+		return nil
+	}
+	if err := j.syncer.Sync(); err != nil {
+		return err
+	}
+	j.dirty = false
+	return nil
+}
+
+// close stops the background sync goroutine, if any, syncs the journal and returns its first error.
+func (j *journal) close() error {
+	j.mu.Lock()
+	stop := j.stop
+	j.stop = nil
+	j.mu.Unlock()
+
+	if stop != nil {
+		close(stop)
+		<-j.done
+	}
+	j.sync()
+	return j.error()
+}
+
+// fail records the first error of the journal and reports err to the error handler, if any.
+func (j *journal) fail(err error) {
+	err = fmt.Errorf("syncmap: journal: %w", err)
+
+	j.mu.Lock()
+	if j.err == nil {
+		j.err = err
+	}
+	j.mu.Unlock()
+
+	if j.onError != nil {
+		j.onError(err)
+	}
+}
+
+func (j *journal) error() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	return j.err
+}
@@ -0,0 +1,284 @@
+package syncmap
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/antst/go-syncmap/codec/msgpack"
+)
+
+// syncedBuffer is a journal writer that counts its syncs.
+type syncedBuffer struct {
+	bytes.Buffer
+	syncs atomic.Int64
+}
+
+func (b *syncedBuffer) Sync() error {
+	b.syncs.Add(1)
+	return nil
+}
+
+// failingWriter is a journal writer whose writes fail.
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, errors.New("disk full")
+}
+
+func TestSyncMapJournal(t *testing.T) {
+	t.Run(
+		"Replay", func(t *testing.T) {
+			clock := newFakeClock()
+			var log bytes.Buffer
+			sm := New[string, int](
+				10,
+				WithClock[string, int](clock.Now),
+				WithMaxEntries[string, int](3),
+				WithJournal[string, int](&log, msgpack.Codec{}),
+			)
+			sm.Store("a", 1)
+			sm.Store("b", 2)
+			sm.Purge()
+			sm.Store("c", 3)
+			sm.Store("d", 4)
+			sm.Remove("c")
+			sm.DoLocked(func(lm LockedMap[string, int]) { lm.Store("e", 5) })
+			sm.StoreWithTTL("f", 6, time.Second)
+			sm.Store("g", 7) // evicts d
+			clock.Advance(time.Minute)
+			sm.RemoveExpired()
+			sm.Store("e", 50)
+
+			var replayLog bytes.Buffer
+			restored := New[string, int](10, WithJournal[string, int](&replayLog, msgpack.Codec{}))
+			if err := restored.Replay(bytes.NewReader(log.Bytes())); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if !Equal(sm, restored) {
+				t.Errorf("Expected the contents to be restored, got %v", restored.Filter(func(string, int) bool { return true }))
+			}
+			if replayLog.Len() != 0 {
+				t.Errorf("Expected the replayed changes not to be journaled again, got %d bytes", replayLog.Len())
+			}
+
+			restored.Store("h", 8)
+			if replayLog.Len() == 0 {
+				t.Error("Expected the changes after the replay to be journaled")
+			}
+		},
+	)
+
+	t.Run(
+		"Expiration", func(t *testing.T) {
+			clock := newFakeClock()
+			var log bytes.Buffer
+			sm := New[string, int](10, WithClock[string, int](clock.Now), WithJournal[string, int](&log, msgpack.Codec{}))
+			sm.StoreWithTTL("session", 1, time.Minute)
+			sm.Store("token", 2)
+			sm.ExpireAt("token", clock.Now().Add(2*time.Minute))
+			sm.StoreWithTTL("user", 3, time.Minute)
+			sm.Persist("user")
+			clock.Advance(10 * time.Second)
+
+			restored := New[string, int](10, WithClock[string, int](clock.Now), WithJournal[string, int](&bytes.Buffer{}, msgpack.Codec{}))
+			if err := restored.Replay(bytes.NewReader(log.Bytes())); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			for k, expected := range map[string]time.Duration{"session": 50 * time.Second, "token": 110 * time.Second} {
+				if ttl, ok := restored.GetTTL(k); !ok || ttl != expected {
+					t.Errorf("Expected a TTL of %v for %s, got %v", expected, k, ttl)
+				}
+			}
+			if ttl, ok := restored.GetTTL("user"); ok {
+				t.Errorf("Expected no TTL for user, got %v", ttl)
+			}
+
+			clock.Advance(time.Minute)
+			if _, ok := restored.Load("session"); ok {
+				t.Error("Expected the replayed session to expire")
+			}
+			if _, ok := restored.Load("token"); !ok {
+				t.Error("Expected the replayed token to be present")
+			}
+		},
+	)
+
+	t.Run(
+		"Writer", func(t *testing.T) {
+			var log bytes.Buffer
+			sm := New[string, int](10, WithJournal[string, int](&log, msgpack.Codec{}))
+			sm.Store("a", 1)
+			sm.Store("b", 2)
+
+			store := newRecordingStore()
+			restored := New[string, int](
+				10,
+				WithJournal[string, int](&bytes.Buffer{}, msgpack.Codec{}),
+				WithWriter[string, int](store.write),
+			)
+			if err := restored.Replay(bytes.NewReader(log.Bytes())); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if restored.Len() != 2 {
+				t.Errorf("Expected 2 entries, got %d", restored.Len())
+			}
+			if store.writes != 0 {
+				t.Errorf("Expected the replayed values not to be persisted, got %d writes", store.writes)
+			}
+		},
+	)
+
+	t.Run(
+		"TornRecord", func(t *testing.T) {
+			var log bytes.Buffer
+			sm := New[string, int](10, WithJournal[string, int](&log, msgpack.Codec{}))
+			sm.Store("a", 1)
+			valid := log.Len()
+			sm.Store("b", 2)
+
+			for name, data := range map[string][]byte{
+				"Truncated": log.Bytes()[:log.Len()-1],
+				"Checksum":  append(append([]byte(nil), log.Bytes()[:log.Len()-1]...), 0xff),
+			} {
+				restored := New[string, int](10, WithJournal[string, int](&bytes.Buffer{}, msgpack.Codec{}))
+				err := restored.Replay(bytes.NewReader(data))
+				var corrupt *JournalCorruptError
+				if !errors.As(err, &corrupt) || corrupt.Offset != int64(valid) {
+					t.Errorf("Expected a corrupt record at offset %d for %s, got %v", valid, name, err)
+				}
+				if v, ok := restored.Load("a"); !ok || v != 1 || restored.Len() != 1 {
+					t.Errorf("Expected the valid records to be replayed for %s, got %d entries", name, restored.Len())
+				}
+			}
+		},
+	)
+
+	t.Run(
+		"ReadyGate", func(t *testing.T) {
+			var log bytes.Buffer
+			sm := New[string, int](10, WithJournal[string, int](&log, msgpack.Codec{}))
+			sm.Store("a", 1)
+
+			torn := New[string, int](10, WithReadyGate[string, int](), WithJournal[string, int](&bytes.Buffer{}, msgpack.Codec{}))
+			if err := torn.Replay(bytes.NewReader(log.Bytes()[:log.Len()-1])); err == nil || torn.IsReady() {
+				t.Errorf("Expected a torn journal to leave the map not ready, got %v", err)
+			}
+
+			restored := New[string, int](10, WithReadyGate[string, int](), WithJournal[string, int](&bytes.Buffer{}, msgpack.Codec{}))
+			if err := restored.Replay(bytes.NewReader(log.Bytes())); err != nil || !restored.IsReady() {
+				t.Errorf("Expected the map to be ready after the replay, got %v", err)
+			}
+		},
+	)
+
+	t.Run(
+		"File", func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "map.wal")
+			open := func() *os.File {
+				f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0o600)
+				if err != nil {
+					t.Fatal(err)
+				}
+				return f
+			}
+
+			f := open()
+			sm := New[string, int](10, WithJournal[string, int](f, msgpack.Codec{}, WithJournalSync(JournalSyncAlways, 0)))
+			sm.Store("a", 1)
+			sm.Store("b", 2)
+			if err := sm.Close(); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			f.Close()
+
+			// restart: replay the journal, then keep appending to it
+			f = open()
+			defer f.Close()
+			restored := New[string, int](10, WithJournal[string, int](f, msgpack.Codec{}))
+			if err := restored.Replay(f); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			restored.Remove("a")
+			restored.Close()
+
+			again := New[string, int](10, WithJournal[string, int](&bytes.Buffer{}, msgpack.Codec{}))
+			b, _ := os.ReadFile(path)
+			if err := again.Replay(bytes.NewReader(b)); err != nil || again.Len() != 1 {
+				t.Errorf("Expected only b after the second restart, got %d entries and %v", again.Len(), err)
+			}
+		},
+	)
+
+	t.Run(
+		"SyncPolicy", func(t *testing.T) {
+			var always, never syncedBuffer
+			sm := New[string, int](10, WithJournal[string, int](&always, msgpack.Codec{}, WithJournalSync(JournalSyncAlways, 0)))
+			sm.Store("a", 1)
+			sm.Store("b", 2)
+			if n := always.syncs.Load(); n != 2 {
+				t.Errorf("Expected a sync per record, got %d", n)
+			}
+
+			sm = New[string, int](10, WithJournal[string, int](&never, msgpack.Codec{}, WithJournalSync(JournalSyncNever, 0)))
+			sm.Store("a", 1)
+			sm.Close()
+			if n := never.syncs.Load(); n != 0 {
+				t.Errorf("Expected no sync, got %d", n)
+			}
+
+			var periodic syncedBuffer
+			sm = New[string, int](
+				10, WithJournal[string, int](&periodic, msgpack.Codec{}, WithJournalSync(JournalSyncPeriodic, time.Millisecond)),
+			)
+			sm.Store("a", 1)
+			for deadline := time.Now().Add(time.Second); periodic.syncs.Load() == 0 && time.Now().Before(deadline); {
+				time.Sleep(time.Millisecond)
+			}
+			if periodic.syncs.Load() == 0 {
+				t.Error("Expected the journal to be synced in the background")
+			}
+			syncs := periodic.syncs.Load()
+			time.Sleep(10 * time.Millisecond)
+			if n := periodic.syncs.Load(); n != syncs {
+				t.Errorf("Expected no sync without new records, got %d more", n-syncs)
+			}
+			sm.Store("b", 2)
+			sm.Close()
+			if n := periodic.syncs.Load(); n != syncs+1 {
+				t.Errorf("Expected Close to sync the last record, got %d more syncs", n-syncs)
+			}
+		},
+	)
+
+	t.Run(
+		"Errors", func(t *testing.T) {
+			var reported []error
+			sm := New[string, int](
+				10, WithJournal[string, int](failingWriter{}, msgpack.Codec{}, WithJournalErrorHandler(func(err error) { reported = append(reported, err) })),
+			)
+			sm.Store("a", 1)
+			sm.Store("b", 2)
+			if sm.Len() != 2 || len(reported) != 2 {
+				t.Errorf("Expected the changes to be made and the errors reported, got %d entries and %d errors", sm.Len(), len(reported))
+			}
+			if err := sm.SyncJournal(); err == nil {
+				t.Error("Expected SyncJournal to return the error")
+			}
+			if err := sm.Close(); err == nil {
+				t.Error("Expected Close to return the error")
+			}
+
+			if err := New[string, int](10).Replay(bytes.NewReader(nil)); !errors.Is(err, ErrNoJournal) {
+				t.Errorf("Expected ErrNoJournal, got %v", err)
+			}
+			if err := New[string, int](10).SyncJournal(); err != nil {
+				t.Errorf("Expected no error without a journal, got %v", err)
+			}
+		},
+	)
+}
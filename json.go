@@ -0,0 +1,151 @@
+package syncmap
+
+import (
+	"bytes"
+	"cmp"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strconv"
+)
+
+// to complain if SyncMap does not implement the interfaces
+var (
+	_ json.Marshaler   = (*SyncMap[string, any])(nil)
+	_ json.Unmarshaler = (*SyncMap[string, any])(nil)
+)
+
+// WithSortedJSON makes MarshalJSON emit the keys in their natural order, e.g. numerically for integer keys,
+// so that snapshots of the map are easy to diff. Without it, the output is deterministic as well, but
+// encoding/json sorts the keys by their text, so that the key 10 comes before the key 9.
+func WithSortedJSON[K cmp.Ordered, V any]() Option[K, V] {
+	return func(m *SyncMap[K, V]) {
+		m.jsonKeyOrder = cmp.Compare[K]
+	}
+}
+
+// MarshalJSON implements json.Marshaler, encoding the map as a JSON object, so that a SyncMap can be
+// embedded directly into configuration and API structs. As with Go maps, the key type must be a string,
+// an integer type or implement encoding.TextMarshaler, such as netip.Addr: the keys are encoded as
+// the text of the object keys, and UnmarshalJSON decodes them back, with encoding.TextUnmarshaler
+// for the types that implement it. Float keys, which encoding/json does not support, are accepted
+// with WithSortedJSON only. The keys are sorted, see WithSortedJSON.
+// The entries are copied under a read lock and encoded once it is released, so the values are encoded
+// without holding the lock and their MarshalJSON methods, if any, may use the map.
+func (m *SyncMap[K, V]) MarshalJSON() ([]byte, error) {
+	m.rlock()
+	data := maps.Collect(m.entries())
+	m.runlock()
+
+	if m.jsonKeyOrder != nil {
+		return marshalSortedJSON(data, m.jsonKeyOrder)
+	}
+	return json.Marshal(data)
+}
+
+// marshalSortedJSON encodes data as a JSON object whose keys, which are of a cmp.Ordered type, are in the given order.
+func marshalSortedJSON[K comparable, V any](data map[K]V, order func(a, b K) int) ([]byte, error) {
+	keys := slices.SortedFunc(maps.Keys(data), order)
+
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, k := range keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+
+		key, err := jsonKey(k)
+		if err != nil {
+			return nil, err
+		}
+		encodedKey, err := json.Marshal(key)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(encodedKey)
+		buf.WriteByte(':')
+
+		encodedValue, err := json.Marshal(data[k])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(encodedValue)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// jsonKey returns the text of k as the key of a JSON object. As with encoding/json, the text of a string
+// is itself and types implementing encoding.TextMarshaler encode their text; integers are formatted
+// in base 10, and floats, which encoding/json does not support as keys, as with strconv.FormatFloat.
+func jsonKey(k any) (string, error) {
+	rv := reflect.ValueOf(k)
+	if rv.Kind() == reflect.String {
+		return rv.String(), nil
+	}
+	if tm, ok := k.(encoding.TextMarshaler); ok {
+		text, err := tm.MarshalText()
+		return string(text), err
+	}
+
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(rv.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(rv.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(rv.Float(), 'g', -1, rv.Type().Bits()), nil
+	}
+	return "", fmt.Errorf("json: unsupported key type %T", k)
+}
+
+// UnmarshalJSON implements json.Unmarshaler, replacing the contents of the map with the entries of a JSON object.
+// The object is decoded before the map is locked, and the contents are then replaced atomically,
+// under a single write lock, as with ReplaceAll: if the data is not valid, the map is left unchanged.
+// The pairs rejected by the map are skipped and reported in the returned error.
+// A JSON null leaves the map unchanged.
+func (m *SyncMap[K, V]) UnmarshalJSON(b []byte) error {
+	data, err := unmarshalJSONObject[K, V](b)
+	if err != nil {
+		return err
+	}
+	if data == nil {
+		return nil
+	}
+
+	m.lock()
+	defer m.unlock()
+
+	return m.replace(data)
+}
+
+// unmarshalJSONObject decodes a JSON object into a map, as json.Unmarshal does, except that float keys,
+// which encoding/json does not support, are parsed as jsonKey formats them.
+func unmarshalJSONObject[K comparable, V any](b []byte) (map[K]V, error) {
+	kt := reflect.TypeFor[K]()
+	isFloat := kt.Kind() == reflect.Float32 || kt.Kind() == reflect.Float64
+	if !isFloat || reflect.PointerTo(kt).Implements(reflect.TypeFor[encoding.TextUnmarshaler]()) {
+		var data map[K]V
+		err := json.Unmarshal(b, &data)
+		return data, err
+	}
+
+	var raw map[string]V
+	if err := json.Unmarshal(b, &raw); err != nil || raw == nil {
+		return nil, err
+	}
+	data := make(map[K]V, len(raw))
+	for text, v := range raw {
+		f, err := strconv.ParseFloat(text, kt.Bits())
+		if err != nil {
+			return nil, fmt.Errorf("json: invalid key %q for type %v: %w", text, kt, err)
+		}
+		var k K
+		reflect.ValueOf(&k).Elem().SetFloat(f)
+		data[k] = v
+	}
+	return data, nil
+}
@@ -0,0 +1,241 @@
+package syncmap
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/netip"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestSyncMapJSON(t *testing.T) {
+	t.Run(
+		"RoundTrip", func(t *testing.T) {
+			sm := New[string, int](10)
+			sm.Store("a", 1)
+			sm.Store("b", 2)
+
+			b, err := json.Marshal(sm)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if string(b) != `{"a":1,"b":2}` {
+				t.Errorf("Expected the entries as a JSON object, got %s", b)
+			}
+
+			restored := New[string, int](10)
+			restored.Store("c", 3)
+			if err := json.Unmarshal(b, restored); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if !Equal(sm, restored) {
+				t.Errorf("Expected the contents to be replaced, got %v", restored.Filter(func(string, int) bool { return true }))
+			}
+		},
+	)
+
+	t.Run(
+		"Embedded", func(t *testing.T) {
+			type config struct {
+				Name   string
+				Limits SyncMap[string, int]
+			}
+
+			var c config
+			if err := json.Unmarshal([]byte(`{"Name":"api","Limits":{"read":10,"write":5}}`), &c); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if v, ok := c.Limits.Load("write"); !ok || v != 5 {
+				t.Errorf("Expected 5, got (%v, %v)", v, ok)
+			}
+
+			b, err := json.Marshal(&c)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if string(b) != `{"Name":"api","Limits":{"read":10,"write":5}}` {
+				t.Errorf("Expected the embedded map to be encoded, got %s", b)
+			}
+
+			if err := json.Unmarshal([]byte(`{"Limits":null}`), &c); err != nil || c.Limits.Len() != 2 {
+				t.Errorf("Expected null to leave the map unchanged, got %d entries and %v", c.Limits.Len(), err)
+			}
+		},
+	)
+
+	t.Run(
+		"Deterministic", func(t *testing.T) {
+			sm := New[int, string](10)
+			for i := range 12 {
+				sm.Store(i, "v")
+			}
+			first, _ := json.Marshal(sm)
+			for range 10 {
+				if b, _ := json.Marshal(sm); string(b) != string(first) {
+					t.Fatalf("Expected the same output, got %s and %s", first, b)
+				}
+			}
+			if !strings.HasPrefix(string(first), `{"0":"v","1":"v","10":"v","11":"v","2":`) {
+				t.Errorf("Expected the keys sorted by their text, got %s", first)
+			}
+		},
+	)
+
+	t.Run(
+		"Sorted", func(t *testing.T) {
+			sm := New[int, string](10, WithSortedJSON[int, string]())
+			for _, i := range []int{10, -1, 9, 2} {
+				sm.Store(i, strconv.Itoa(i))
+			}
+			b, err := json.Marshal(sm)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if string(b) != `{"-1":"-1","2":"2","9":"9","10":"10"}` {
+				t.Errorf("Expected the keys in numeric order, got %s", b)
+			}
+
+			restored := New[int, string](10)
+			if err := json.Unmarshal(b, restored); err != nil || !Equal(sm, restored) {
+				t.Errorf("Expected the map to round-trip, got %v", err)
+			}
+
+			floats := New[float64, bool](10, WithSortedJSON[float64, bool]())
+			floats.Store(1.5, true)
+			floats.Store(-2, false)
+			if b, err := json.Marshal(floats); err != nil || string(b) != `{"-2":false,"1.5":true}` {
+				t.Errorf("Expected float keys in numeric order, got %s and %v", b, err)
+			}
+
+			strs := New[string, int](10, WithSortedJSON[string, int]())
+			strs.Store("b", 1)
+			strs.Store("a\"<", 2)
+			if b, err := json.Marshal(strs); err != nil || string(b) != `{"a\"\u003c":2,"b":1}` {
+				t.Errorf("Expected escaped keys in order, got %s and %v", b, err)
+			}
+		},
+	)
+
+	t.Run(
+		"Keys", func(t *testing.T) {
+			addrs := New[netip.Addr, string](10)
+			addrs.Store(netip.MustParseAddr("10.0.0.1"), "gateway")
+			addrs.Store(netip.MustParseAddr("::1"), "loopback")
+			b, err := json.Marshal(addrs)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if string(b) != `{"10.0.0.1":"gateway","::1":"loopback"}` {
+				t.Errorf("Expected the text of the keys, got %s", b)
+			}
+			restored := New[netip.Addr, string](10)
+			if err := json.Unmarshal(b, restored); err != nil || !Equal(addrs, restored) {
+				t.Errorf("Expected the map to round-trip, got %v", err)
+			}
+
+			ids := New[uint16, bool](10)
+			ids.Store(443, true)
+			b, _ = json.Marshal(ids)
+			restoredIDs := New[uint16, bool](10)
+			if err := json.Unmarshal(b, restoredIDs); err != nil || !Equal(ids, restoredIDs) {
+				t.Errorf("Expected the map to round-trip, got %s and %v", b, err)
+			}
+			if err := json.Unmarshal([]byte(`{"70000":true}`), restoredIDs); err == nil {
+				t.Error("Expected an error for a key that overflows")
+			}
+		},
+	)
+
+	t.Run(
+		"SortedTextKeys", func(t *testing.T) {
+			sm := New[jsonLevel, int](10, WithSortedJSON[jsonLevel, int]())
+			sm.Store(2, 20)
+			sm.Store(1, 10)
+			b, err := json.Marshal(sm)
+			if err != nil || string(b) != `{"level-1":10,"level-2":20}` {
+				t.Errorf("Expected the text of the keys in their order, got %s and %v", b, err)
+			}
+			restored := New[jsonLevel, int](10)
+			if err := json.Unmarshal(b, restored); err != nil || !Equal(sm, restored) {
+				t.Errorf("Expected the map to round-trip, got %v", err)
+			}
+		},
+	)
+
+	t.Run(
+		"SortedFloatKeys", func(t *testing.T) {
+			sm := New[float64, string](10, WithSortedJSON[float64, string]())
+			sm.Store(10, "ten")
+			sm.Store(2.5, "two and a half")
+			sm.Store(-1e21, "large")
+			b, err := json.Marshal(sm)
+			if err != nil || string(b) != `{"-1e+21":"large","2.5":"two and a half","10":"ten"}` {
+				t.Errorf("Expected the keys in numerical order, got %s and %v", b, err)
+			}
+			restored := New[float64, string](10)
+			if err := json.Unmarshal(b, restored); err != nil || !Equal(sm, restored) {
+				t.Errorf("Expected the map to round-trip, got %v", err)
+			}
+
+			small := New[float32, int](10)
+			if err := json.Unmarshal([]byte(`{"0.1":1}`), small); err != nil {
+				t.Errorf("Expected no error, got %v", err)
+			}
+			if v, ok := small.Load(0.1); !ok || v != 1 {
+				t.Errorf("Expected (1, true) for the float32 key, got (%v, %v)", v, ok)
+			}
+			if err := json.Unmarshal([]byte(`{"one":1}`), small); err == nil {
+				t.Error("Expected an error for a key that is not a number")
+			}
+		},
+	)
+
+	t.Run(
+		"Empty", func(t *testing.T) {
+			b, err := json.Marshal(New[int, string](0))
+			if err != nil || string(b) != `{}` {
+				t.Errorf("Expected {}, got %s and %v", b, err)
+			}
+		},
+	)
+
+	t.Run(
+		"Invalid", func(t *testing.T) {
+			sm := New[string, int](10)
+			sm.Store("a", 1)
+			if err := json.Unmarshal([]byte(`{"b":"not a number"}`), sm); err == nil {
+				t.Error("Expected an error")
+			}
+			if v, ok := sm.Load("a"); !ok || v != 1 || sm.Len() != 1 {
+				t.Errorf("Expected the map to be unchanged, got %d entries", sm.Len())
+			}
+		},
+	)
+
+	t.Run(
+		"Rejected", func(t *testing.T) {
+			sm := New[string, int](10, WithZeroKeyForbidden[string, int]())
+			err := json.Unmarshal([]byte(`{"":1,"a":2}`), sm)
+			if !errors.Is(err, ErrZeroKey) {
+				t.Errorf("Expected ErrZeroKey, got %v", err)
+			}
+			if v, ok := sm.Load("a"); !ok || v != 2 || sm.Len() != 1 {
+				t.Errorf("Expected the valid pair to be stored, got %d entries", sm.Len())
+			}
+		},
+	)
+}
+
+// jsonLevel is an ordered key type that encodes itself as text.
+type jsonLevel int
+
+func (l jsonLevel) MarshalText() ([]byte, error) {
+	return fmt.Appendf(nil, "level-%d", int(l)), nil
+}
+
+func (l *jsonLevel) UnmarshalText(b []byte) error {
+	_, err := fmt.Sscanf(string(b), "level-%d", (*int)(l))
+	return err
+}
@@ -0,0 +1,8 @@
+// Package keycodec derives stable byte encodings for map keys, including composite struct keys,
+// for use by snapshots, persistence backends and ordered maps.
+//
+// Codecs are composed from primitive codecs (String, Int, Uint, Bool, Bytes) with Struct,
+// whose encoding is defined by the order of its fields rather than by the memory layout of the key type.
+// All encodings are order-preserving: comparing the encodings of two keys with bytes.Compare
+// gives the same result as comparing the keys field by field, in the order of the fields.
+package keycodec
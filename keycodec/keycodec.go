@@ -0,0 +1,216 @@
+package keycodec
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+)
+
+var (
+	// ErrInvalidEncoding is returned when decoding bytes that were not produced by the codec.
+	ErrInvalidEncoding = errors.New("keycodec: invalid encoding")
+
+	// ErrTrailingBytes is returned by Decode when bytes remain after the decoded key.
+	ErrTrailingBytes = errors.New("keycodec: trailing bytes")
+)
+
+// Codec encodes values of type T into bytes and back.
+type Codec[T any] interface {
+	// Append appends the encoding of v to dst and returns the extended buffer.
+	Append(dst []byte, v T) []byte
+	// Decode decodes a value from the beginning of src and returns it with the remaining bytes.
+	Decode(src []byte) (T, []byte, error)
+}
+
+// Encode returns the encoding of v.
+func Encode[T any](c Codec[T], v T) []byte {
+	return c.Append(nil, v)
+}
+
+// Decode decodes a value from src, which must hold exactly one encoded value.
+func Decode[T any](c Codec[T], src []byte) (T, error) {
+	v, rest, err := c.Decode(src)
+	if err != nil {
+		return v, err
+	}
+	if len(rest) != 0 {
+		var zero T
+		return zero, fmt.Errorf("%w: %d bytes", ErrTrailingBytes, len(rest))
+	}
+	return v, nil
+}
+
+// Signed is the set of signed integer types.
+type Signed interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64
+}
+
+// Unsigned is the set of unsigned integer types.
+type Unsigned interface {
+	~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr
+}
+
+// Int returns a codec for signed integers, encoded on 8 bytes.
+func Int[T Signed]() Codec[T] {
+	return intCodec[T]{}
+}
+
+type intCodec[T Signed] struct{}
+
+func (intCodec[T]) Append(dst []byte, v T) []byte {
+	// flipping the sign bit makes negative numbers sort before positive ones
+	return binary.BigEndian.AppendUint64(dst, uint64(v)^(1<<63))
+}
+
+func (intCodec[T]) Decode(src []byte) (T, []byte, error) {
+	if len(src) < 8 {
+		return 0, src, fmt.Errorf("%w: integer needs 8 bytes, got %d", ErrInvalidEncoding, len(src))
+	}
+	return T(int64(binary.BigEndian.Uint64(src) ^ (1 << 63))), src[8:], nil
+}
+
+// Uint returns a codec for unsigned integers, encoded on 8 bytes.
+func Uint[T Unsigned]() Codec[T] {
+	return uintCodec[T]{}
+}
+
+type uintCodec[T Unsigned] struct{}
+
+func (uintCodec[T]) Append(dst []byte, v T) []byte {
+	return binary.BigEndian.AppendUint64(dst, uint64(v))
+}
+
+func (uintCodec[T]) Decode(src []byte) (T, []byte, error) {
+	if len(src) < 8 {
+		return 0, src, fmt.Errorf("%w: integer needs 8 bytes, got %d", ErrInvalidEncoding, len(src))
+	}
+	return T(binary.BigEndian.Uint64(src)), src[8:], nil
+}
+
+// Float returns a codec for float64 values, encoded on 8 bytes and ordered like the numbers,
+// with negative zero before positive zero and NaNs after all other values.
+func Float() Codec[float64] {
+	return floatCodec{}
+}
+
+type floatCodec struct{}
+
+func (floatCodec) Append(dst []byte, v float64) []byte {
+	bits := math.Float64bits(v)
+	if bits&(1<<63) != 0 {
+		bits = ^bits
+	} else {
+		bits |= 1 << 63
+	}
+	return binary.BigEndian.AppendUint64(dst, bits)
+}
+
+func (floatCodec) Decode(src []byte) (float64, []byte, error) {
+	if len(src) < 8 {
+		return 0, src, fmt.Errorf("%w: float needs 8 bytes, got %d", ErrInvalidEncoding, len(src))
+	}
+	bits := binary.BigEndian.Uint64(src)
+	if bits&(1<<63) != 0 {
+		bits &^= 1 << 63
+	} else {
+		bits = ^bits
+	}
+	return math.Float64frombits(bits), src[8:], nil
+}
+
+// Bool returns a codec for booleans, encoded on 1 byte, false before true.
+func Bool() Codec[bool] {
+	return boolCodec{}
+}
+
+type boolCodec struct{}
+
+func (boolCodec) Append(dst []byte, v bool) []byte {
+	if v {
+		return append(dst, 1)
+	}
+	return append(dst, 0)
+}
+
+func (boolCodec) Decode(src []byte) (bool, []byte, error) {
+	if len(src) < 1 || src[0] > 1 {
+		return false, src, fmt.Errorf("%w: bad boolean", ErrInvalidEncoding)
+	}
+	return src[0] == 1, src[1:], nil
+}
+
+// String returns a codec for strings. Strings are escaped and terminated,
+// so that a string field does not swallow the fields that follow it and prefixes sort first.
+func String[T ~string]() Codec[T] {
+	return stringCodec[T]{}
+}
+
+type stringCodec[T ~string] struct{}
+
+func (stringCodec[T]) Append(dst []byte, v T) []byte {
+	return appendEscaped(dst, v)
+}
+
+func (stringCodec[T]) Decode(src []byte) (T, []byte, error) {
+	b, rest, err := decodeEscaped(src)
+	return T(b), rest, err
+}
+
+// Bytes returns a codec for byte slices, escaped and terminated like strings.
+// Decoding never returns nil, so nil and empty slices are not told apart.
+func Bytes() Codec[[]byte] {
+	return bytesCodec{}
+}
+
+type bytesCodec struct{}
+
+func (bytesCodec) Append(dst []byte, v []byte) []byte {
+	return appendEscaped(dst, v)
+}
+
+func (bytesCodec) Decode(src []byte) ([]byte, []byte, error) {
+	return decodeEscaped(src)
+}
+
+// Escaped strings encode 0x00 as 0x00 0xFF and end with 0x00 0x01,
+// which sorts before any byte that may follow in a longer string.
+const (
+	escape     = 0x00
+	escapedNul = 0xFF
+	terminator = 0x01
+)
+
+func appendEscaped[T ~string | ~[]byte](dst []byte, v T) []byte {
+	for i := 0; i < len(v); i++ {
+		if v[i] == escape {
+			dst = append(dst, escape, escapedNul)
+		} else {
+			dst = append(dst, v[i])
+		}
+	}
+	return append(dst, escape, terminator)
+}
+
+func decodeEscaped(src []byte) ([]byte, []byte, error) {
+	out := make([]byte, 0, len(src))
+	for i := 0; i < len(src); i++ {
+		if src[i] != escape {
+			out = append(out, src[i])
+			continue
+		}
+		if i+1 == len(src) {
+			break
+		}
+		switch src[i+1] {
+		case escapedNul:
+			out = append(out, escape)
+			i++
+		case terminator:
+			return out, src[i+2:], nil
+		default:
+			return nil, src, fmt.Errorf("%w: bad escape sequence", ErrInvalidEncoding)
+		}
+	}
+	return nil, src, fmt.Errorf("%w: unterminated string", ErrInvalidEncoding)
+}
@@ -0,0 +1,201 @@
+package keycodec
+
+import (
+	"bytes"
+	"cmp"
+	"errors"
+	"math"
+	"slices"
+	"testing"
+)
+
+// checkCodec verifies that values round-trip through c and that their encodings sort like compare says.
+func checkCodec[T any](t *testing.T, c Codec[T], values []T, equal func(a, b T) bool, compare func(a, b T) int) {
+	t.Helper()
+	for _, v := range values {
+		got, err := Decode(c, Encode(c, v))
+		if err != nil || !equal(got, v) {
+			t.Errorf("Expected %v to round-trip, got %v (%v)", v, got, err)
+		}
+	}
+	for _, a := range values {
+		for _, b := range values {
+			if got, expected := bytes.Compare(Encode(c, a), Encode(c, b)), compare(a, b); got != expected {
+				t.Errorf("Expected encodings of %v and %v to compare as %d, got %d", a, b, expected, got)
+			}
+		}
+	}
+}
+
+func equal[T comparable](a, b T) bool {
+	return a == b
+}
+
+func TestPrimitives(t *testing.T) {
+	t.Run(
+		"Int", func(t *testing.T) {
+			checkCodec(t, Int[int64](), []int64{math.MinInt64, -1000, -1, 0, 1, 42, math.MaxInt64}, equal, cmp.Compare)
+			checkCodec(t, Int[int8](), []int8{-128, -1, 0, 1, 127}, equal, cmp.Compare)
+		},
+	)
+
+	t.Run(
+		"Uint", func(t *testing.T) {
+			checkCodec(t, Uint[uint64](), []uint64{0, 1, 255, 256, math.MaxUint64}, equal, cmp.Compare)
+		},
+	)
+
+	t.Run(
+		"Float", func(t *testing.T) {
+			values := []float64{math.Inf(-1), -1e300, -1.5, -math.SmallestNonzeroFloat64, 0, 1, 1.5, math.Inf(1)}
+			checkCodec(t, Float(), values, equal, cmp.Compare)
+
+			nan, err := Decode(Float(), Encode(Float(), math.NaN()))
+			if err != nil || !math.IsNaN(nan) {
+				t.Errorf("Expected NaN to round-trip, got %v (%v)", nan, err)
+			}
+		},
+	)
+
+	t.Run(
+		"Bool", func(t *testing.T) {
+			checkCodec(
+				t, Bool(), []bool{false, true}, equal, func(a, b bool) int {
+					return cmp.Compare(boolInt(a), boolInt(b))
+				},
+			)
+		},
+	)
+
+	t.Run(
+		"String", func(t *testing.T) {
+			values := []string{"", "\x00", "\x00\x00", "\x00\x01", "a", "a\x00", "a\x00b", "ab", "b", "\xff"}
+			checkCodec(t, String[string](), values, equal, cmp.Compare)
+		},
+	)
+
+	t.Run(
+		"Bytes", func(t *testing.T) {
+			values := [][]byte{{}, {0}, {0, 0xff}, {1}, {1, 0}, {0xff}}
+			checkCodec(t, Bytes(), values, bytes.Equal, bytes.Compare)
+		},
+	)
+}
+
+func boolInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+type userKey struct {
+	Tenant string
+	ID     int64
+	Active bool
+}
+
+var userKeyCodec = Struct(
+	FieldOf(
+		func(k userKey) string { return k.Tenant },
+		func(k *userKey, v string) { k.Tenant = v },
+		String[string](),
+	),
+	FieldOf(
+		func(k userKey) int64 { return k.ID },
+		func(k *userKey, v int64) { k.ID = v },
+		Int[int64](),
+	),
+	FieldOf(
+		func(k userKey) bool { return k.Active },
+		func(k *userKey, v bool) { k.Active = v },
+		Bool(),
+	),
+)
+
+func TestStruct(t *testing.T) {
+	keys := []userKey{
+		{"", 0, false},
+		{"", 1, true},
+		{"acme", -5, false},
+		{"acme", 7, false},
+		{"acme", 7, true},
+		{"acme\x00corp", 0, false},
+		{"acmecorp", -1, false},
+		{"beta", math.MinInt64, true},
+	}
+	checkCodec(
+		t, userKeyCodec, keys, equal, func(a, b userKey) int {
+			return cmp.Or(
+				cmp.Compare(a.Tenant, b.Tenant),
+				cmp.Compare(a.ID, b.ID),
+				cmp.Compare(boolInt(a.Active), boolInt(b.Active)),
+			)
+		},
+	)
+
+	sorted := slices.Clone(keys)
+	slices.SortFunc(
+		sorted, func(a, b userKey) int {
+			return bytes.Compare(Encode(userKeyCodec, a), Encode(userKeyCodec, b))
+		},
+	)
+	if !slices.Equal(sorted, keys) {
+		t.Errorf("Expected encodings to sort like the keys, got %v", sorted)
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	tests := map[string]struct {
+		decode func() error
+		err    error
+	}{
+		"ShortInt": {
+			func() error {
+				_, err := Decode(Int[int64](), []byte{1, 2, 3})
+				return err
+			}, ErrInvalidEncoding,
+		},
+		"UnterminatedString": {
+			func() error {
+				_, err := Decode(String[string](), []byte("abc"))
+				return err
+			}, ErrInvalidEncoding,
+		},
+		"BadEscape": {
+			func() error {
+				_, err := Decode(String[string](), []byte{'a', 0, 7, 0, 1})
+				return err
+			}, ErrInvalidEncoding,
+		},
+		"BadBool": {
+			func() error {
+				_, err := Decode(Bool(), []byte{2})
+				return err
+			}, ErrInvalidEncoding,
+		},
+		"TrailingBytes": {
+			func() error {
+				_, err := Decode(Uint[uint32](), append(Encode(Uint[uint32](), 1), 0))
+				return err
+			}, ErrTrailingBytes,
+		},
+		"TruncatedStruct": {
+			func() error {
+				b := Encode(userKeyCodec, userKey{"acme", 1, true})
+				_, err := Decode(userKeyCodec, b[:len(b)-2])
+				return err
+			}, ErrInvalidEncoding,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(
+			name, func(t *testing.T) {
+				if err := tt.decode(); !errors.Is(err, tt.err) {
+					t.Errorf("Expected %v, got %v", tt.err, err)
+				}
+			},
+		)
+	}
+}
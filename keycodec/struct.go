@@ -0,0 +1,74 @@
+package keycodec
+
+// Field is a field of a composite key of type K, see FieldOf and Struct.
+type Field[K any] struct {
+	append func(dst []byte, k K) []byte
+	decode func(src []byte, k *K) ([]byte, error)
+}
+
+// FieldOf describes a field of type F of the composite key K: get reads it from a key,
+// set writes it into a key being decoded, and c encodes it.
+func FieldOf[K, F any](get func(k K) F, set func(k *K, f F), c Codec[F]) Field[K] {
+	return Field[K]{
+		append: func(dst []byte, k K) []byte {
+			return c.Append(dst, get(k))
+		},
+		decode: func(src []byte, k *K) ([]byte, error) {
+			f, rest, err := c.Decode(src)
+			if err != nil {
+				return src, err
+			}
+			set(k, f)
+			return rest, nil
+		},
+	}
+}
+
+// Struct returns a codec for the composite key K, encoding its fields one after another in the given order.
+// The encoding only depends on that order, not on the declaration of K, so fields can be added to K
+// (and appended to the list) without changing the encoding of existing keys' fields.
+//
+//	type userKey struct {
+//		Tenant string
+//		ID     int64
+//	}
+//
+//	codec := keycodec.Struct(
+//		keycodec.FieldOf(
+//			func(k userKey) string { return k.Tenant },
+//			func(k *userKey, v string) { k.Tenant = v },
+//			keycodec.String[string](),
+//		),
+//		keycodec.FieldOf(
+//			func(k userKey) int64 { return k.ID },
+//			func(k *userKey, v int64) { k.ID = v },
+//			keycodec.Int[int64](),
+//		),
+//	)
+func Struct[K any](fields ...Field[K]) Codec[K] {
+	return structCodec[K]{fields: fields}
+}
+
+type structCodec[K any] struct {
+	fields []Field[K]
+}
+
+func (c structCodec[K]) Append(dst []byte, k K) []byte {
+	for _, f := range c.fields {
+		dst = f.append(dst, k)
+	}
+	return dst
+}
+
+func (c structCodec[K]) Decode(src []byte) (K, []byte, error) {
+	var k K
+	rest := src
+	for _, f := range c.fields {
+		var err error
+		if rest, err = f.decode(rest, &k); err != nil {
+			var zero K
+			return zero, src, err
+		}
+	}
+	return k, rest, nil
+}
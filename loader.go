@@ -0,0 +1,155 @@
+package syncmap
+
+import (
+	"errors"
+	"math/rand/v2"
+	"time"
+)
+
+// WithLoader makes the SyncMap a read-through cache: Load of a missing key calls load,
+// stores the value it returns and returns it. Concurrent loads of the same key share a single call to load,
+// see LoadOrCompute. When load fails, Load reports the key as missing and nothing is stored;
+// TryLoad returns the error.
+// Only the Load and TryLoad methods of the map load missing keys: other methods, such as Range
+// or the methods of a LockedMap, only see the entries that have been loaded.
+// load should report keys that do not exist with an error wrapping ErrNotFound, see WithNegativeCaching.
+// load is called without holding the lock, so it may use the map.
+func WithLoader[K comparable, V any](load func(k K) (V, error)) Option[K, V] {
+	return func(m *SyncMap[K, V]) {
+		m.loader = load
+	}
+}
+
+// WithNegativeCaching makes a SyncMap created WithLoader remember for ttl the keys that the loader
+// reported as not found (with an error wrapping ErrNotFound): until then, loading them again fails
+// with ErrNotFound without calling the loader, so that repeated lookups of keys that do not exist
+// do not hammer the backing store. Storing a key forgets that it was not found.
+func WithNegativeCaching[K comparable, V any](ttl time.Duration) Option[K, V] {
+	return func(m *SyncMap[K, V]) {
+		m.negativeTTL = ttl
+	}
+}
+
+// WithMaxConcurrentLoads limits the number of calls to the loader of a SyncMap created WithLoader
+// in progress at once, including the reloads of WithRefreshAhead, so that a cold start does not overwhelm
+// the backing store: concurrent loads of the same key already share a single call, this also bounds
+// the loads of distinct keys. The loads beyond the limit wait for a call to return.
+// A limit of zero or less means no limit.
+func WithMaxConcurrentLoads[K comparable, V any](n int) Option[K, V] {
+	return func(m *SyncMap[K, V]) {
+		m.loadSlots = nil
+		if n > 0 {
+			m.loadSlots = make(chan struct{}, n)
+		}
+	}
+}
+
+// WithRefreshAhead makes a SyncMap created WithLoader reload the entries stored with a TTL
+// in the background when they are read during the last fraction of their TTL (e.g. 0.2 for the last 20%),
+// so that hot keys are refreshed before they expire and their readers never wait for the loader.
+// The fraction is randomized by up to ±jitter of its value at every read (e.g. 0.1 for ±10%),
+// so that entries stored at the same time are not all refreshed at once.
+// Reloaded values are stored with the TTL of the entry they replace; failed reloads are ignored,
+// the entry then expires normally. There is at most one reload in progress per key.
+func WithRefreshAhead[K comparable, V any](fraction, jitter float64) Option[K, V] {
+	return func(m *SyncMap[K, V]) {
+		m.refreshAhead = fraction
+		m.refreshJitter = jitter
+	}
+}
+
+// LoadCached retrieves the value associated with the given key, loading it if the map was created
+// WithLoader, like Load. The ok result is false if the key does not exist, including when that is
+// known from negative caching (see WithNegativeCaching), and the error is the error of the loader,
+// if it failed for any other reason.
+func (m *SyncMap[K, V]) LoadCached(k K) (v V, ok bool, err error) {
+	v, err = m.loadThrough(m.key(k))
+	switch {
+	case err == nil:
+		return v, true, nil
+	case errors.Is(err, ErrNotFound):
+		return v, false, nil
+	default:
+		return v, false, err
+	}
+}
+
+// loadThrough retrieves the value associated with the normalized key k, calling the loader of the map
+// if the key is missing. It returns ErrNotFound if the key is missing and there is no loader.
+func (m *SyncMap[K, V]) loadThrough(k K) (V, error) {
+	if v, ok := m.lookup(k); ok {
+		return v, nil
+	}
+	var zero V
+	if m.loader == nil || m.knownMissing(k) {
+		return zero, ErrNotFound
+	}
+
+	v, err := m.loadOrCompute(
+		k, func() (V, error) {
+			return m.callLoader(k)
+		},
+	)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			m.rememberMissing(k)
+		}
+		return zero, err
+	}
+	return v, nil
+}
+
+// callLoader calls the loader of the map for k, once fewer than the maximum number of loads are in progress,
+// see WithMaxConcurrentLoads.
+func (m *SyncMap[K, V]) callLoader(k K) (V, error) {
+	if m.loadSlots != nil {
+		m.loadSlots <- struct{}{}
+		defer func() {
+			<-m.loadSlots
+		}()
+	}
+	return m.loader(k)
+}
+
+// dueForRefresh reports whether an entry read with the given deadline should be reloaded ahead of its expiration,
+// see WithRefreshAhead. The deadline is nil for entries without TTL.
+func (m *SyncMap[K, V]) dueForRefresh(d *deadline) bool {
+	if d == nil || m.refreshAhead <= 0 || m.loader == nil {
+		return false
+	}
+
+	fraction := m.refreshAhead
+	if m.refreshJitter > 0 {
+		fraction *= 1 + m.refreshJitter*(2*rand.Float64()-1)
+	}
+	left := time.Duration(d.at.Load() - m.clock().UnixNano())
+	return left < time.Duration(fraction*float64(d.ttl))
+}
+
+// knownMissing reports whether k was recently reported as not found by the loader.
+func (m *SyncMap[K, V]) knownMissing(k K) bool {
+	if m.negativeTTL <= 0 {
+		return false
+	}
+
+	m.rlock()
+	defer m.runlock()
+
+	t, ok := m.missing[k]
+	return ok && m.clock().Before(t)
+}
+
+// rememberMissing records that k was reported as not found by the loader, see WithNegativeCaching.
+func (m *SyncMap[K, V]) rememberMissing(k K) {
+	if m.negativeTTL <= 0 {
+		return
+	}
+
+	m.lock()
+	defer m.unlock()
+
+	if m.missing == nil {
+		m.missing = make(map[K]time.Time)
+	}
+	m.missing[k] = m.clock().Add(m.negativeTTL)
+}
@@ -0,0 +1,241 @@
+package syncmap
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWithLoader(t *testing.T) {
+	t.Run(
+		"ReadThrough", func(t *testing.T) {
+			var loads atomic.Int32
+			sm := New[string, int](
+				10,
+				WithLoader[string, int](
+					func(k string) (int, error) {
+						loads.Add(1)
+						return len(k), nil
+					},
+				),
+			)
+
+			var wg sync.WaitGroup
+			for range 10 {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					if v, ok := sm.Load("hello"); !ok || v != 5 {
+						t.Errorf("Expected (5, true), got (%v, %v)", v, ok)
+					}
+				}()
+			}
+			wg.Wait()
+
+			if loads.Load() != 1 {
+				t.Errorf("Expected a single load, got %d", loads.Load())
+			}
+			if sm.Len() != 1 {
+				t.Errorf("Expected the loaded value to be stored, got %d entries", sm.Len())
+			}
+		},
+	)
+
+	t.Run(
+		"Errors", func(t *testing.T) {
+			errMissing := errors.New("no such row")
+			sm := New[string, int](
+				10,
+				WithLoader[string, int](
+					func(k string) (int, error) {
+						if k == "missing" {
+							return -1, errMissing
+						}
+						return 1, nil
+					},
+				),
+			)
+
+			if v, ok := sm.Load("missing"); ok || v != 0 {
+				t.Errorf("Expected (0, false), got (%v, %v)", v, ok)
+			}
+			if _, ok, err := sm.TryLoad("missing"); ok || !errors.Is(err, errMissing) {
+				t.Errorf("Expected %v, got (%v, %v)", errMissing, ok, err)
+			}
+			if v, ok, err := sm.TryLoad("present"); !ok || v != 1 || err != nil {
+				t.Errorf("Expected (1, true, nil), got (%v, %v, %v)", v, ok, err)
+			}
+			if sm.Len() != 1 {
+				t.Errorf("Expected failed loads not to be stored, got %d entries", sm.Len())
+			}
+		},
+	)
+
+	t.Run(
+		"WithoutLoader", func(t *testing.T) {
+			sm := New[string, int](10)
+			if v, ok, err := sm.TryLoad("missing"); ok || v != 0 || err != nil {
+				t.Errorf("Expected (0, false, nil), got (%v, %v, %v)", v, ok, err)
+			}
+		},
+	)
+}
+
+func TestWithMaxConcurrentLoads(t *testing.T) {
+	var inProgress, peak atomic.Int32
+	sm := New[int, int](
+		100,
+		WithLoader[int, int](
+			func(k int) (int, error) {
+				n := inProgress.Add(1)
+				defer inProgress.Add(-1)
+				for {
+					p := peak.Load()
+					if n <= p || peak.CompareAndSwap(p, n) {
+						break
+					}
+				}
+				time.Sleep(time.Millisecond)
+				return k, nil
+			},
+		),
+		WithMaxConcurrentLoads[int, int](3),
+	)
+
+	var wg sync.WaitGroup
+	for i := range 30 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if v, ok := sm.Load(i); !ok || v != i {
+				t.Errorf("Expected (%d, true), got (%v, %v)", i, v, ok)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if p := peak.Load(); p > 3 {
+		t.Errorf("Expected at most 3 concurrent loads, got %d", p)
+	}
+	if sm.Len() != 30 {
+		t.Errorf("Expected 30 entries, got %d", sm.Len())
+	}
+}
+
+func TestWithNegativeCaching(t *testing.T) {
+	clock := newFakeClock()
+	var loads atomic.Int32
+	errUnavailable := errors.New("store unavailable")
+	sm := New[string, int](
+		10,
+		WithClock[string, int](clock.Now),
+		WithNegativeCaching[string, int](time.Minute),
+		WithLoader[string, int](
+			func(k string) (int, error) {
+				loads.Add(1)
+				switch k {
+				case "unavailable":
+					return 0, errUnavailable
+				case "present":
+					return 1, nil
+				default:
+					return 0, fmt.Errorf("no row for %q: %w", k, ErrNotFound)
+				}
+			},
+		),
+	)
+
+	for range 3 {
+		if v, ok, err := sm.LoadCached("missing"); ok || v != 0 || err != nil {
+			t.Errorf("Expected (0, false, nil), got (%v, %v, %v)", v, ok, err)
+		}
+	}
+	if loads.Load() != 1 {
+		t.Errorf("Expected the miss to be cached, got %d loads", loads.Load())
+	}
+
+	// other errors are not cached
+	for range 2 {
+		if _, _, err := sm.LoadCached("unavailable"); !errors.Is(err, errUnavailable) {
+			t.Errorf("Expected %v, got %v", errUnavailable, err)
+		}
+	}
+	if loads.Load() != 3 {
+		t.Errorf("Expected errors not to be cached, got %d loads", loads.Load())
+	}
+
+	if v, ok, err := sm.LoadCached("present"); !ok || v != 1 || err != nil {
+		t.Errorf("Expected (1, true, nil), got (%v, %v, %v)", v, ok, err)
+	}
+
+	// storing a key forgets the miss
+	sm.Store("missing", 2)
+	if v, ok := sm.Load("missing"); !ok || v != 2 {
+		t.Errorf("Expected (2, true), got (%v, %v)", v, ok)
+	}
+	sm.Remove("missing")
+
+	clock.Advance(time.Minute)
+	loads.Store(0)
+	sm.Load("missing")
+	if loads.Load() != 1 {
+		t.Errorf("Expected the miss to be forgotten once its TTL has elapsed, got %d loads", loads.Load())
+	}
+
+	clock.Advance(time.Minute)
+	sm.RemoveExpired()
+	if sm.LenLocked() != 1 || len(sm.missing) != 0 {
+		t.Errorf("Expected RemoveExpired to forget the elapsed misses, got %d", len(sm.missing))
+	}
+}
+
+func TestWithRefreshAhead(t *testing.T) {
+	for _, readMostly := range []bool{false, true} {
+		clock := newFakeClock()
+		var loads atomic.Int32
+		opts := []Option[string, int]{
+			WithClock[string, int](clock.Now),
+			WithDefaultTTL[string, int](time.Minute),
+			WithRefreshAhead[string, int](0.25, 0.1),
+			WithLoader[string, int](
+				func(k string) (int, error) {
+					return int(loads.Add(1)), nil
+				},
+			),
+		}
+		if readMostly {
+			opts = append(opts, WithReadMostly[string, int]())
+		}
+		sm := New[string, int](10, opts...)
+
+		if v, _ := sm.Load("k"); v != 1 {
+			t.Errorf("Expected the first load to return 1, got %d", v)
+		}
+		clock.Advance(30 * time.Second)
+		sm.Load("k")
+		if loads.Load() != 1 {
+			t.Errorf("Expected no reload before the last quarter of the TTL, got %d loads", loads.Load())
+		}
+
+		clock.Advance(20 * time.Second)
+		if v, ok := sm.Load("k"); !ok || v != 1 {
+			t.Errorf("Expected the current value while reloading, got (%v, %v)", v, ok)
+		}
+		deadline := time.Now().Add(time.Second)
+		for time.Now().Before(deadline) {
+			if v, _ := sm.Load("k"); v == 2 {
+				break
+			}
+			time.Sleep(time.Millisecond)
+		}
+		if v, _ := sm.Load("k"); v != 2 {
+			t.Errorf("Expected the reloaded value (read-mostly: %v), got %d", readMostly, v)
+		}
+		if ttl, ok := sm.GetTTL("k"); !ok || ttl != time.Minute {
+			t.Errorf("Expected the reloaded value to have a full TTL, got (%v, %v)", ttl, ok)
+		}
+	}
+}
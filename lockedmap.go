@@ -1,7 +1,15 @@
 package syncmap
 
+import (
+	"maps"
+	"sync/atomic"
+)
+
 // to complain if a type does not implement the required methods
-var _ LockedMap[any, any] = (*lockedMap[any, any])(nil)
+var (
+	_ LockedMap[any, any]         = (*lockedMap[any, any])(nil)
+	_ ReadOnlyLockedMap[any, any] = (*readOnlyLockedMap[any, any])(nil)
+)
 
 // LockedMap is an interface that provides safe access to the map while locked.
 // It defines methods for loading, storing, deleting, and ranging over map entries.
@@ -10,14 +18,14 @@ var _ LockedMap[any, any] = (*lockedMap[any, any])(nil)
 // The methods in this interface assume that the caller has already acquired
 // the necessary lock. Therefore, these methods should only be used within
 // the context of SyncMap's DoLocked and DoLockedWithResult methods.
+// A LockedMap is invalidated when the callback it was passed to returns:
+// calling any of its methods afterwards panics instead of silently accessing the map unlocked.
 //
 // Type parameters:
 //   - K: must be a comparable type (used as map keys)
 //   - V: can be any type (used as map values)
 type LockedMap[K comparable, V any] interface {
-	// Load retrieves the value for a key.
-	// It returns the value and a boolean indicating whether the key was present.
-	Load(key K) (V, bool)
+	ReadOnlyLockedMap[K, V]
 
 	// LoadOrStore returns the existing value for the key if present.
 	// Otherwise, it stores and returns the given value.
@@ -31,25 +39,67 @@ type LockedMap[K comparable, V any] interface {
 	// The loaded result reports whether the key was present.
 	LoadAndDelete(key K) (V, bool)
 
+	// Swap stores the value for a key and returns the previous value if any.
+	// The loaded result reports whether the key was present.
+	Swap(key K, value V) (previous V, loaded bool)
+
+	// CompareAndSwap stores new for the key if its current value is equal to old,
+	// and reports whether it did so. As with sync.Map, the values are compared with ==,
+	// so CompareAndSwap panics if old is not of a comparable type.
+	CompareAndSwap(key K, old, new V) (swapped bool)
+
 	// Remove deletes the value associated with the given key from the map.
 	// It returns true if the key was present and removed, false otherwise.
 	Remove(k K) bool
 
 	// Purge removes all key-value pairs from the map, effectively clearing its contents.
 	Purge()
+}
+
+// ReadOnlyLockedMap is the read-only subset of LockedMap, passed to DoRLocked callbacks.
+// It only exposes methods that do not modify the map, so a callback running under the read lock
+// cannot mutate the map: this is enforced at compile time.
+//
+// Type parameters:
+//   - K: must be a comparable type (used as map keys)
+//   - V: can be any type (used as map values)
+type ReadOnlyLockedMap[K comparable, V any] interface {
+	// Load retrieves the value for a key.
+	// It returns the value and a boolean indicating whether the key was present.
+	Load(key K) (V, bool)
 
 	// Range calls f sequentially for each key and value present in the map.
 	// If f returns false, Range stops the iteration.
 	Range(f func(key K, value V) bool)
 
 	// Filter creates a new map containing key-value pairs from the map that satisfy the given predicate function.
-	// It acquires a read lock to ensure thread-safe access to the underlying data.
 	Filter(predicateFn func(k K, v V) bool) map[K]V
 
 	// Map applies a given function to all key-value pairs in the map and returns a new map with the results.
-	// It acquires a read lock to ensure thread-safe access to the underlying data.
 	Map(mapFn func(k K, v V) V) map[K]V
 
+	// Contains reports whether the key is present in the map.
+	Contains(key K) bool
+
+	// Keys returns all keys present in the map, in no particular order.
+	Keys() []K
+
+	// Values returns all values present in the map, in no particular order.
+	Values() []V
+
+	// Entries returns all key-value pairs present in the map, in no particular order.
+	Entries() []Entry[K, V]
+
+	// Clone returns a copy of the contents of the map.
+	Clone() map[K]V
+
+	// Count returns the number of key-value pairs in the map that satisfy the given predicate function.
+	Count(predicateFn func(k K, v V) bool) int
+
+	// FindKeys returns the keys of all entries whose value satisfies the given predicate function,
+	// in no particular order.
+	FindKeys(predicateFn func(v V) bool) []K
+
 	// Len returns the number of items in the map.
 	Len() int
 
@@ -60,31 +110,77 @@ type LockedMap[K comparable, V any] interface {
 // unexported type to restrict access
 type lockedMap[K comparable, V any] struct {
 	m *SyncMap[K, V]
+	// set once the critical section that created the lockedMap has ended
+	done atomic.Bool
+}
+
+// invalidate marks the lockedMap as no longer usable; it is called when its critical section ends.
+func (lm *lockedMap[K, V]) invalidate() {
+	lm.done.Store(true)
+}
+
+// ensureActive panics if the lockedMap is used after its critical section has ended,
+// which would otherwise silently access the map without holding the lock.
+func (lm *lockedMap[K, V]) ensureActive(method string) {
+	if lm.done.Load() {
+		panic("syncmap: LockedMap." + method + " called after the locked callback returned; LockedMap must not escape its callback")
+	}
 }
 
 func (lm *lockedMap[K, V]) Len() int {
-	return len(lm.m.data)
+	lm.ensureActive("Len")
+	return lm.m.count()
 }
 
 func (lm *lockedMap[K, V]) Load(key K) (V, bool) {
-	v, ok := lm.m.data[key]
+	lm.ensureActive("Load")
+	k := lm.m.key(key)
+	v, ok := lm.m.load(k)
+	if ok {
+		lm.m.accessed(k)
+	}
 	return v, ok
 }
 
 func (lm *lockedMap[K, V]) Store(key K, value V) {
-	lm.m.data[key] = value
+	lm.ensureActive("Store")
+	lm.m.check("Store", lm.m.store(lm.m.key(key), value))
 }
 
 func (lm *lockedMap[K, V]) LoadAndDelete(key K) (V, bool) {
-	v, ok := lm.m.data[key]
-	if ok {
-		delete(lm.m.data, key)
+	lm.ensureActive("LoadAndDelete")
+	return lm.m.remove(lm.m.key(key))
+}
+
+func (lm *lockedMap[K, V]) Swap(key K, value V) (V, bool) {
+	lm.ensureActive("Swap")
+	k := lm.m.key(key)
+	previous, loaded := lm.m.load(k)
+	lm.m.check("Swap", lm.m.store(k, value))
+	return previous, loaded
+}
+
+func (lm *lockedMap[K, V]) CompareAndSwap(key K, old, new V) bool {
+	lm.ensureActive("CompareAndSwap")
+	k := lm.m.key(key)
+	if current, ok := lm.m.load(k); !ok || any(current) != any(old) {
+		return false
 	}
-	return v, ok
+
+	err := lm.m.store(k, new)
+	lm.m.check("CompareAndSwap", err)
+	return err == nil
+}
+
+func (lm *lockedMap[K, V]) Contains(key K) bool {
+	lm.ensureActive("Contains")
+	_, ok := lm.m.load(lm.m.key(key))
+	return ok
 }
 
 func (lm *lockedMap[K, V]) Range(f func(key K, value V) bool) {
-	for k, v := range lm.m.data {
+	lm.ensureActive("Range")
+	for k, v := range lm.m.entries() {
 		if !f(k, v) {
 			break
 		}
@@ -92,31 +188,27 @@ func (lm *lockedMap[K, V]) Range(f func(key K, value V) bool) {
 }
 
 func (lm *lockedMap[K, V]) Purge() {
-	lm.m.data = make(map[K]V)
+	lm.ensureActive("Purge")
+	lm.m.purge()
 }
 
 func (lm *lockedMap[K, V]) Remove(k K) bool {
-	if _, ok := lm.m.data[k]; !ok {
-		return false
-	}
-	delete(lm.m.data, k)
-	return true
+	lm.ensureActive("Remove")
+	return lm.m.drop(lm.m.key(k))
 }
 
 func (lm *lockedMap[K, V]) LoadOrStore(key K, value V) (V, bool) {
-
-	if v, ok := lm.m.data[key]; ok {
-		return v, true
-	}
-
-	lm.m.data[key] = value
-	return value, false
+	lm.ensureActive("LoadOrStore")
+	v, loaded, err := lm.m.loadOrStore(lm.m.key(key), value)
+	lm.m.check("LoadOrStore", err)
+	return v, loaded
 }
 
 func (lm *lockedMap[K, V]) Filter(predicateFn func(k K, v V) bool) map[K]V {
+	lm.ensureActive("Filter")
 	data := make(map[K]V)
 
-	for k, v := range lm.m.data {
+	for k, v := range lm.m.entries() {
 		if predicateFn(k, v) {
 			data[k] = v
 		}
@@ -126,15 +218,128 @@ func (lm *lockedMap[K, V]) Filter(predicateFn func(k K, v V) bool) map[K]V {
 }
 
 func (lm *lockedMap[K, V]) Map(mapFn func(k K, v V) V) map[K]V {
+	lm.ensureActive("Map")
 	data := make(map[K]V, len(lm.m.data))
 
-	for k, v := range lm.m.data {
+	for k, v := range lm.m.entries() {
 		data[k] = mapFn(k, v)
 	}
 
 	return data
 }
 
+func (lm *lockedMap[K, V]) Keys() []K {
+	lm.ensureActive("Keys")
+	keys := make([]K, 0, len(lm.m.data))
+	for k := range lm.m.entries() {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+func (lm *lockedMap[K, V]) Values() []V {
+	lm.ensureActive("Values")
+	values := make([]V, 0, len(lm.m.data))
+	for _, v := range lm.m.entries() {
+		values = append(values, v)
+	}
+	return values
+}
+
+func (lm *lockedMap[K, V]) Entries() []Entry[K, V] {
+	lm.ensureActive("Entries")
+	entries := make([]Entry[K, V], 0, len(lm.m.data))
+	for k, v := range lm.m.entries() {
+		entries = append(entries, Entry[K, V]{Key: k, Value: v})
+	}
+	return entries
+}
+
+func (lm *lockedMap[K, V]) Clone() map[K]V {
+	lm.ensureActive("Clone")
+	return maps.Collect(lm.m.entries())
+}
+
+func (lm *lockedMap[K, V]) Count(predicateFn func(k K, v V) bool) int {
+	lm.ensureActive("Count")
+	n := 0
+	for k, v := range lm.m.entries() {
+		if predicateFn(k, v) {
+			n++
+		}
+	}
+	return n
+}
+
+func (lm *lockedMap[K, V]) FindKeys(predicateFn func(v V) bool) []K {
+	lm.ensureActive("FindKeys")
+	keys := make([]K, 0)
+	for k, v := range lm.m.entries() {
+		if predicateFn(v) {
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
+
 func (lm *lockedMap[K, V]) syncMap() *SyncMap[K, V] {
 	return lm.m
 }
+
+// readOnlyLockedMap wraps a lockedMap exposing only its read methods,
+// so that it cannot be type-asserted back to a LockedMap.
+type readOnlyLockedMap[K comparable, V any] struct {
+	lm *lockedMap[K, V]
+}
+
+func (rm *readOnlyLockedMap[K, V]) Load(key K) (V, bool) {
+	return rm.lm.Load(key)
+}
+
+func (rm *readOnlyLockedMap[K, V]) Range(f func(key K, value V) bool) {
+	rm.lm.Range(f)
+}
+
+func (rm *readOnlyLockedMap[K, V]) Filter(predicateFn func(k K, v V) bool) map[K]V {
+	return rm.lm.Filter(predicateFn)
+}
+
+func (rm *readOnlyLockedMap[K, V]) Map(mapFn func(k K, v V) V) map[K]V {
+	return rm.lm.Map(mapFn)
+}
+
+func (rm *readOnlyLockedMap[K, V]) Keys() []K {
+	return rm.lm.Keys()
+}
+
+func (rm *readOnlyLockedMap[K, V]) Contains(key K) bool {
+	return rm.lm.Contains(key)
+}
+
+func (rm *readOnlyLockedMap[K, V]) Values() []V {
+	return rm.lm.Values()
+}
+
+func (rm *readOnlyLockedMap[K, V]) Entries() []Entry[K, V] {
+	return rm.lm.Entries()
+}
+
+func (rm *readOnlyLockedMap[K, V]) Clone() map[K]V {
+	return rm.lm.Clone()
+}
+
+func (rm *readOnlyLockedMap[K, V]) Count(predicateFn func(k K, v V) bool) int {
+	return rm.lm.Count(predicateFn)
+}
+
+func (rm *readOnlyLockedMap[K, V]) FindKeys(predicateFn func(v V) bool) []K {
+	return rm.lm.FindKeys(predicateFn)
+}
+
+func (rm *readOnlyLockedMap[K, V]) Len() int {
+	return rm.lm.Len()
+}
+
+func (rm *readOnlyLockedMap[K, V]) syncMap() *SyncMap[K, V] {
+	return rm.lm.m
+}
@@ -1,7 +1,10 @@
 package syncmap
 
 import (
+	"maps"
+	"slices"
 	"sort"
+	"strings"
 	"testing"
 )
 
@@ -84,7 +87,7 @@ func TestLockedMap(t *testing.T) {
 			sort.Strings(keys)
 			sort.Ints(values)
 
-			if !slicesEqual(keys, expectedKeys) || !slicesEqual(values, expectedValues) {
+			if !slices.Equal(keys, expectedKeys) || !slices.Equal(values, expectedValues) {
 				t.Errorf("Range didn't return expected results. Got keys: %v, values: %v", keys, values)
 			}
 		},
@@ -231,3 +234,315 @@ func TestLockedMap(t *testing.T) {
 	)
 
 }
+
+func TestReadOnlyLockedMap(t *testing.T) {
+	sm := New[string, int](10)
+	sm.Store("key1", 1)
+	sm.Store("key2", 2)
+	sm.Store("key3", 3)
+
+	t.Run(
+		"Reads", func(t *testing.T) {
+			sm.DoRLocked(
+				func(m ReadOnlyLockedMap[string, int]) {
+					if v, ok := m.Load("key2"); !ok || v != 2 {
+						t.Errorf("Expected 2, got %v", v)
+					}
+					if m.Len() != 3 {
+						t.Errorf("Expected length 3, got %d", m.Len())
+					}
+
+					keys := m.Keys()
+					sort.Strings(keys)
+					if !slices.Equal(keys, []string{"key1", "key2", "key3"}) {
+						t.Errorf("Unexpected keys %v", keys)
+					}
+
+					filtered := m.Filter(
+						func(k string, v int) bool {
+							return v > 1
+						},
+					)
+					if len(filtered) != 2 {
+						t.Errorf("Expected 2 filtered items, got %d", len(filtered))
+					}
+
+					mapped := m.Map(
+						func(k string, v int) int {
+							return -v
+						},
+					)
+					if mapped["key3"] != -3 {
+						t.Errorf("Expected -3, got %d", mapped["key3"])
+					}
+
+					count := 0
+					m.Range(
+						func(key string, value int) bool {
+							count++
+							return true
+						},
+					)
+					if count != 3 {
+						t.Errorf("Expected 3 iterations, got %d", count)
+					}
+				},
+			)
+		},
+	)
+
+	t.Run(
+		"Cannot be asserted to LockedMap", func(t *testing.T) {
+			sm.DoRLocked(
+				func(m ReadOnlyLockedMap[string, int]) {
+					if _, ok := m.(LockedMap[string, int]); ok {
+						t.Error("ReadOnlyLockedMap should not be convertible to LockedMap")
+					}
+				},
+			)
+		},
+	)
+
+	t.Run(
+		"Concurrent readers", func(t *testing.T) {
+			inside := make(chan struct{})
+			release := make(chan struct{})
+			done := make(chan struct{})
+
+			go func() {
+				defer close(done)
+				sm.DoRLocked(
+					func(m ReadOnlyLockedMap[string, int]) {
+						close(inside)
+						<-release
+					},
+				)
+			}()
+
+			<-inside
+			// a second reader must not be blocked by the first one
+			sm.DoRLocked(
+				func(m ReadOnlyLockedMap[string, int]) {
+					m.Len()
+				},
+			)
+			close(release)
+			<-done
+		},
+	)
+}
+
+func TestLockedMapKeys(t *testing.T) {
+	sm := New[string, int](10)
+	sm.DoLocked(
+		func(m LockedMap[string, int]) {
+			if keys := m.Keys(); len(keys) != 0 {
+				t.Errorf("Expected no keys, got %v", keys)
+			}
+			m.Store("key1", 1)
+			m.Store("key2", 2)
+
+			keys := m.Keys()
+			sort.Strings(keys)
+			if !slices.Equal(keys, []string{"key1", "key2"}) {
+				t.Errorf("Unexpected keys %v", keys)
+			}
+		},
+	)
+}
+
+func TestLockedMapSnapshots(t *testing.T) {
+	sm := New[string, int](10)
+	sm.Store("key1", 1)
+	sm.Store("key2", 2)
+
+	sm.DoRLocked(
+		func(m ReadOnlyLockedMap[string, int]) {
+			if !m.Contains("key1") || m.Contains("non-existent") {
+				t.Error("Contains should report exactly the present keys")
+			}
+
+			values := m.Values()
+			slices.Sort(values)
+			if !slices.Equal(values, []int{1, 2}) {
+				t.Errorf("Unexpected values %v", values)
+			}
+
+			entries := m.Entries()
+			sort.Slice(
+				entries, func(i, j int) bool {
+					return entries[i].Key < entries[j].Key
+				},
+			)
+			if !slices.Equal(entries, []Entry[string, int]{{"key1", 1}, {"key2", 2}}) {
+				t.Errorf("Unexpected entries %v", entries)
+			}
+
+			clone := m.Clone()
+			if !maps.Equal(clone, map[string]int{"key1": 1, "key2": 2}) {
+				t.Errorf("Unexpected clone %v", clone)
+			}
+			clone["key3"] = 3
+			if m.Contains("key3") {
+				t.Error("Modifying the clone should not affect the map")
+			}
+		},
+	)
+}
+
+func TestLockedMapSwap(t *testing.T) {
+	sm := New[string, int](10)
+
+	t.Run(
+		"Swap", func(t *testing.T) {
+			sm.DoLocked(
+				func(m LockedMap[string, int]) {
+					if _, loaded := m.Swap("key1", 1); loaded {
+						t.Error("Swap should not load a non-existent key")
+					}
+					if prev, loaded := m.Swap("key1", 2); !loaded || prev != 1 {
+						t.Errorf("Expected (1, true), got (%v, %v)", prev, loaded)
+					}
+				},
+			)
+			if v, _ := sm.Load("key1"); v != 2 {
+				t.Errorf("Expected 2, got %v", v)
+			}
+		},
+	)
+
+	t.Run(
+		"CompareAndSwap", func(t *testing.T) {
+			sm.DoLocked(
+				func(m LockedMap[string, int]) {
+					if m.CompareAndSwap("key1", 1, 3) {
+						t.Error("CompareAndSwap should fail on a mismatching value")
+					}
+					if m.CompareAndSwap("non-existent", 0, 3) {
+						t.Error("CompareAndSwap should fail on a non-existent key")
+					}
+					if !m.CompareAndSwap("key1", 2, 3) {
+						t.Error("CompareAndSwap should succeed on a matching value")
+					}
+				},
+			)
+			if v, _ := sm.Load("key1"); v != 3 {
+				t.Errorf("Expected 3, got %v", v)
+			}
+		},
+	)
+}
+
+func TestLockedMapAggregates(t *testing.T) {
+	sm := New[string, int](10)
+	sm.Store("key1", 1)
+	sm.Store("key2", 2)
+	sm.Store("key3", 3)
+
+	// reserve budget only if the current total allows it
+	sm.DoLocked(
+		func(m LockedMap[string, int]) {
+			total := ReduceLocked(
+				m, 0, func(acc int, k string, v int) int {
+					return acc + v
+				},
+			)
+			if total != 6 {
+				t.Errorf("Expected total 6, got %d", total)
+			}
+			if total < 10 {
+				m.Store("key4", 10-total)
+			}
+
+			if n := m.Count(
+				func(k string, v int) bool {
+					return v >= 3
+				},
+			); n != 2 {
+				t.Errorf("Expected count 2, got %d", n)
+			}
+		},
+	)
+
+	sm.DoRLocked(
+		func(m ReadOnlyLockedMap[string, int]) {
+			keys := m.FindKeys(
+				func(v int) bool {
+					return v%2 == 0
+				},
+			)
+			sort.Strings(keys)
+			if !slices.Equal(keys, []string{"key2", "key4"}) {
+				t.Errorf("Expected [key2 key4], got %v", keys)
+			}
+
+			total := ReduceLocked(
+				m, 0, func(acc int, k string, v int) int {
+					return acc + v
+				},
+			)
+			if total != 10 {
+				t.Errorf("Expected total 10, got %d", total)
+			}
+		},
+	)
+}
+
+func TestLockedMapUseAfterCallback(t *testing.T) {
+	sm := New[string, int](10)
+
+	expectPanic := func(name string, f func()) {
+		defer func() {
+			r := recover()
+			msg, ok := r.(string)
+			if !ok || !strings.Contains(msg, "LockedMap."+name+" called after the locked callback returned") {
+				t.Errorf("%s: expected use-after-callback panic, got %v", name, r)
+			}
+		}()
+		f()
+	}
+
+	var escaped LockedMap[string, int]
+	sm.DoLocked(
+		func(m LockedMap[string, int]) {
+			escaped = m
+			m.Store("key1", 1)
+		},
+	)
+
+	expectPanic(
+		"Store", func() {
+			escaped.Store("key2", 2)
+		},
+	)
+	expectPanic(
+		"Load", func() {
+			escaped.Load("key1")
+		},
+	)
+	expectPanic(
+		"Range", func() {
+			escaped.Range(
+				func(key string, value int) bool {
+					return true
+				},
+			)
+		},
+	)
+
+	var escapedRO ReadOnlyLockedMap[string, int]
+	sm.DoRLocked(
+		func(m ReadOnlyLockedMap[string, int]) {
+			escapedRO = m
+		},
+	)
+	expectPanic(
+		"Len", func() {
+			escapedRO.Len()
+		},
+	)
+
+	if _, ok := sm.Load("key2"); ok {
+		t.Error("The escaped LockedMap must not have modified the map")
+	}
+}
@@ -0,0 +1,98 @@
+package syncmap
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// LockStrategy selects the lock protecting a SyncMap, see WithLockStrategy.
+type LockStrategy int
+
+const (
+	// LockRWMutex uses a sync.RWMutex, letting readers proceed in parallel. This is the default.
+	LockRWMutex LockStrategy = iota
+	// LockMutex uses a sync.Mutex, serializing readers as well as writers.
+	// It is cheaper than LockRWMutex when critical sections are very short or reads are not dominant.
+	LockMutex
+	// LockSpin uses a spinlock that busy-waits (yielding the processor between attempts)
+	// instead of parking the goroutine, serializing readers as well as writers.
+	// It only pays off when critical sections are a few nanoseconds long and the lock is rarely contended
+	// for long; callbacks passed to DoLocked and similar methods should then be kept trivial.
+	LockSpin
+)
+
+// WithLockStrategy selects the lock protecting the SyncMap, depending on the length of its critical sections.
+// With LockMutex and LockSpin, read operations are serialized, so DoRLocked callbacks
+// no longer run in parallel.
+func WithLockStrategy[K comparable, V any](strategy LockStrategy) Option[K, V] {
+	return func(m *SyncMap[K, V]) {
+		switch strategy {
+		case LockMutex:
+			m.customLock = &mutexLocker{}
+		case LockSpin:
+			m.customLock = &spinLocker{}
+		default:
+			m.customLock = nil
+		}
+	}
+}
+
+// rwLocker is the lock protecting a SyncMap. It is implemented by *sync.RWMutex.
+type rwLocker interface {
+	Lock()
+	TryLock() bool
+	Unlock()
+	RLock()
+	RUnlock()
+}
+
+// locker returns the lock protecting the map.
+func (m *SyncMap[K, V]) locker() rwLocker {
+	if m.customLock != nil {
+		return m.customLock
+	}
+	return &m.mu
+}
+
+// mutexLocker is an rwLocker whose read lock is exclusive.
+type mutexLocker struct {
+	sync.Mutex
+}
+
+func (l *mutexLocker) RLock() {
+	l.Lock()
+}
+
+func (l *mutexLocker) RUnlock() {
+	l.Unlock()
+}
+
+// spinLocker is an exclusive rwLocker busy-waiting for the lock.
+type spinLocker struct {
+	locked atomic.Bool
+}
+
+func (l *spinLocker) Lock() {
+	for !l.TryLock() {
+		runtime.Gosched()
+	}
+}
+
+func (l *spinLocker) TryLock() bool {
+	return !l.locked.Load() && l.locked.CompareAndSwap(false, true)
+}
+
+func (l *spinLocker) Unlock() {
+	if !l.locked.Swap(false) {
+		panic("syncmap: unlock of unlocked spinlock")
+	}
+}
+
+func (l *spinLocker) RLock() {
+	l.Lock()
+}
+
+func (l *spinLocker) RUnlock() {
+	l.Unlock()
+}
@@ -0,0 +1,72 @@
+package syncmap
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestWithLockStrategy(t *testing.T) {
+	strategies := map[string]LockStrategy{"RWMutex": LockRWMutex, "Mutex": LockMutex, "Spin": LockSpin}
+
+	for name, strategy := range strategies {
+		t.Run(
+			name, func(t *testing.T) {
+				sm := New[int, int](10, WithLockStrategy[int, int](strategy))
+
+				var wg sync.WaitGroup
+				for w := 0; w < 8; w++ {
+					wg.Add(1)
+					go func() {
+						defer wg.Done()
+						for i := 0; i < 100; i++ {
+							sm.DoLocked(
+								func(m LockedMap[int, int]) {
+									v, _ := m.Load(i)
+									m.Store(i, v+1)
+								},
+							)
+							sm.Load(i)
+						}
+					}()
+				}
+				wg.Wait()
+
+				sm.DoRLocked(
+					func(m ReadOnlyLockedMap[int, int]) {
+						if m.Len() != 100 {
+							t.Errorf("Expected 100, got %d", m.Len())
+						}
+						if v, _ := m.Load(42); v != 8 {
+							t.Errorf("Expected 8, got %d", v)
+						}
+					},
+				)
+
+				sm.DoLocked(
+					func(m LockedMap[int, int]) {
+						if sm.TryDoLocked(func(LockedMap[int, int]) {}) {
+							t.Error("TryDoLocked should fail while the lock is held")
+						}
+					},
+				)
+
+				other := New[int, int](10)
+				other.ReplaceAll(sm.Filter(func(k, v int) bool { return true }))
+				if !Equal(sm, other) {
+					t.Error("Expected the maps to be equal")
+				}
+			},
+		)
+	}
+}
+
+func TestSpinLockerUnlockOfUnlocked(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected unlocking an unlocked spinlock to panic")
+		}
+	}()
+
+	var l spinLocker
+	l.Unlock()
+}
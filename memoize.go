@@ -0,0 +1,16 @@
+package syncmap
+
+// Memoize returns a function that caches the results of fn in a SyncMap created with the given options,
+// e.g. WithDefaultTTL for results to be recomputed after a while, or WithMaxEntries to bound the cache.
+// Concurrent calls for the same key share a single call to fn, see LoadOrCompute.
+// Errors are returned but not cached.
+func Memoize[K comparable, V any](fn func(k K) (V, error), opts ...Option[K, V]) func(k K) (V, error) {
+	m := New[K, V](0, opts...)
+	return func(k K) (V, error) {
+		return m.LoadOrCompute(
+			k, func() (V, error) {
+				return fn(k)
+			},
+		)
+	}
+}
@@ -0,0 +1,97 @@
+package syncmap
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestMemoize(t *testing.T) {
+	t.Run(
+		"Caching", func(t *testing.T) {
+			calls := map[int]int{}
+			square := Memoize(
+				func(k int) (int, error) {
+					calls[k]++
+					return k * k, nil
+				},
+			)
+
+			for range 3 {
+				if v, err := square(4); v != 16 || err != nil {
+					t.Errorf("Expected (16, nil), got (%v, %v)", v, err)
+				}
+			}
+			if calls[4] != 1 {
+				t.Errorf("Expected a single call, got %d", calls[4])
+			}
+		},
+	)
+
+	t.Run(
+		"Errors", func(t *testing.T) {
+			calls := 0
+			errFailed := errors.New("failed")
+			fn := Memoize(
+				func(k string) (int, error) {
+					calls++
+					return 0, errFailed
+				},
+			)
+			for range 2 {
+				if _, err := fn("k"); !errors.Is(err, errFailed) {
+					t.Errorf("Expected %v, got %v", errFailed, err)
+				}
+			}
+			if calls != 2 {
+				t.Errorf("Expected errors not to be cached, got %d calls", calls)
+			}
+		},
+	)
+
+	t.Run(
+		"Options", func(t *testing.T) {
+			clock := newFakeClock()
+			calls := 0
+			fn := Memoize(
+				func(k int) (int, error) {
+					calls++
+					return k, nil
+				},
+				WithClock[int, int](clock.Now),
+				WithDefaultTTL[int, int](time.Minute),
+				WithMaxEntries[int, int](2),
+			)
+
+			fn(1)
+			fn(2)
+			fn(3) // evicts 1
+			fn(1)
+			if calls != 4 {
+				t.Errorf("Expected the evicted result to be recomputed, got %d calls", calls)
+			}
+
+			clock.Advance(time.Minute)
+			fn(1)
+			if calls != 5 {
+				t.Errorf("Expected the expired result to be recomputed, got %d calls", calls)
+			}
+		},
+	)
+}
+
+func TestWithDefaultTTL(t *testing.T) {
+	clock := newFakeClock()
+	sm := New[string, int](10, WithClock[string, int](clock.Now), WithDefaultTTL[string, int](time.Minute))
+	sm.Store("a", 1)
+	sm.StoreWithTTL("b", 2, time.Hour)
+	sm.LoadOrStore("c", 3)
+
+	if ttl, ok := sm.GetTTL("a"); !ok || ttl != time.Minute {
+		t.Errorf("Expected (1m, true), got (%v, %v)", ttl, ok)
+	}
+	clock.Advance(time.Minute)
+	if sm.LenLocked() != 1 {
+		t.Errorf("Expected only the entry with its own TTL to be left, got %d entries", sm.LenLocked())
+	}
+}
@@ -0,0 +1,115 @@
+package syncmap
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// EntryMeta is the metadata of an entry of a map created WithEntryMetadata.
+type EntryMeta struct {
+	// CreatedAt is when the key was stored while absent from the map.
+	CreatedAt time.Time
+	// UpdatedAt is when the value was last stored.
+	UpdatedAt time.Time
+	// LastAccess is when the value was last read, or the zero time if it was never read.
+	LastAccess time.Time
+	// AccessCount is how many times the value was read since the key was created.
+	AccessCount uint64
+}
+
+// WithEntryMetadata makes the map record, for each entry, when it was created and updated,
+// when it was last read and how many times, for cache debugging and staleness audits.
+// The metadata is returned by Meta and RangeMeta. Reads that miss are not recorded,
+// and reading the metadata does not count as an access.
+// Recording the metadata costs an allocation per new key and two atomic writes per read.
+func WithEntryMetadata[K comparable, V any]() Option[K, V] {
+	return func(m *SyncMap[K, V]) {
+		m.withMeta = true
+	}
+}
+
+// Meta returns the metadata of the entry of k.
+// The boolean result is false if the key is not present or if the map was not created WithEntryMetadata.
+// It acquires a read lock to ensure thread-safe access to the underlying data.
+func (m *SyncMap[K, V]) Meta(k K) (EntryMeta, bool) {
+	m.rlock()
+	defer m.runlock()
+
+	k = m.key(k)
+	if _, ok := m.load(k); !ok {
+		return EntryMeta{}, false
+	}
+	meta, ok := m.meta[k]
+	if !ok {
+		return EntryMeta{}, false
+	}
+	return meta.snapshot(), true
+}
+
+// RangeMeta calls f sequentially for each key and value present in the map, with the metadata of the entry,
+// which is zero if the map was not created WithEntryMetadata.
+// If f returns false, RangeMeta stops the iteration.
+// It acquires a read lock to ensure thread-safe access to the underlying data.
+func (m *SyncMap[K, V]) RangeMeta(f func(key K, value V, meta EntryMeta) bool) {
+	defer m.recoverCallback("RangeMeta")
+	m.rlock()
+	defer m.runlock()
+
+	for k, v := range m.entries() {
+		var meta EntryMeta
+		if e, ok := m.meta[k]; ok {
+			meta = e.snapshot()
+		}
+		if !f(k, v, meta) {
+			break
+		}
+	}
+}
+
+// entryMeta is the metadata recorded for an entry. Its mutable fields are atomic because reads are recorded
+// under the read lock, or without any lock if the map was created WithReadMostly, and read-mostly snapshots
+// share the entryMeta of the map.
+type entryMeta struct {
+	created     time.Time
+	updated     atomic.Int64 // unix nanos
+	lastAccess  atomic.Int64 // unix nanos, 0 if never read
+	accessCount atomic.Uint64
+}
+
+// accessed records a read at now. It does nothing on a nil entryMeta, when metadata is not recorded.
+func (e *entryMeta) accessed(now time.Time) {
+	if e == nil {
+		return
+	}
+	e.lastAccess.Store(now.UnixNano())
+	e.accessCount.Add(1)
+}
+
+func (e *entryMeta) snapshot() EntryMeta {
+	meta := EntryMeta{CreatedAt: e.created, UpdatedAt: time.Unix(0, e.updated.Load()), AccessCount: e.accessCount.Load()}
+	if at := e.lastAccess.Load(); at != 0 {
+		meta.LastAccess = time.Unix(0, at)
+	}
+	return meta
+}
+
+// stamp records a write of the entry of k, which was already present if exists is set.
+// It assumes that the caller holds the write lock.
+func (m *SyncMap[K, V]) stamp(k K, exists bool) {
+	if !m.withMeta {
+		return
+	}
+
+	now := m.clock()
+	if e, ok := m.meta[k]; ok && exists {
+		e.updated.Store(now.UnixNano())
+		return
+	}
+
+	if m.meta == nil {
+		m.meta = make(map[K]*entryMeta)
+	}
+	e := &entryMeta{created: now}
+	e.updated.Store(now.UnixNano())
+	m.meta[k] = e
+}
@@ -0,0 +1,106 @@
+package syncmap
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSyncMapMeta(t *testing.T) {
+	t.Run(
+		"Timestamps", func(t *testing.T) {
+			for _, readMostly := range []bool{false, true} {
+				clock := newFakeClock()
+				opts := []Option[string, int]{WithClock[string, int](clock.Now), WithEntryMetadata[string, int]()}
+				if readMostly {
+					opts = append(opts, WithReadMostly[string, int]())
+				}
+				sm := New[string, int](10, opts...)
+				created := clock.Now()
+				sm.Store("a", 1)
+
+				meta, ok := sm.Meta("a")
+				if !ok || !meta.CreatedAt.Equal(created) || !meta.UpdatedAt.Equal(created) {
+					t.Errorf("Expected the creation time, got %+v (read-mostly: %v)", meta, readMostly)
+				}
+				if !meta.LastAccess.IsZero() || meta.AccessCount != 0 {
+					t.Errorf("Expected no access, got %+v (read-mostly: %v)", meta, readMostly)
+				}
+
+				clock.Advance(time.Second)
+				sm.Load("a")
+				sm.Load("a")
+				clock.Advance(time.Second)
+				updated := clock.Now()
+				sm.Store("a", 2)
+				sm.Load("missing")
+
+				meta, _ = sm.Meta("a")
+				if !meta.CreatedAt.Equal(created) || !meta.UpdatedAt.Equal(updated) {
+					t.Errorf("Expected the update time, got %+v (read-mostly: %v)", meta, readMostly)
+				}
+				if !meta.LastAccess.Equal(created.Add(time.Second)) || meta.AccessCount != 2 {
+					t.Errorf("Expected 2 accesses, got %+v (read-mostly: %v)", meta, readMostly)
+				}
+			}
+		},
+	)
+
+	t.Run(
+		"Recreated", func(t *testing.T) {
+			clock := newFakeClock()
+			sm := New[string, int](10, WithClock[string, int](clock.Now), WithEntryMetadata[string, int]())
+			sm.Store("a", 1)
+			sm.Load("a")
+			sm.Remove("a")
+			if _, ok := sm.Meta("a"); ok {
+				t.Error("Expected no metadata for a removed key")
+			}
+
+			clock.Advance(time.Minute)
+			sm.Store("a", 1)
+			meta, _ := sm.Meta("a")
+			if !meta.CreatedAt.Equal(clock.Now()) || meta.AccessCount != 0 {
+				t.Errorf("Expected fresh metadata, got %+v", meta)
+			}
+		},
+	)
+
+	t.Run(
+		"RangeMeta", func(t *testing.T) {
+			sm := New[string, int](10, WithEntryMetadata[string, int]())
+			sm.Store("a", 1)
+			sm.Store("b", 2)
+			sm.Load("b")
+
+			counts := make(map[string]uint64)
+			sm.RangeMeta(
+				func(k string, v int, meta EntryMeta) bool {
+					counts[k] = meta.AccessCount
+					return true
+				},
+			)
+			if len(counts) != 2 || counts["a"] != 0 || counts["b"] != 1 {
+				t.Errorf("Expected the access counts of a and b, got %v", counts)
+			}
+		},
+	)
+
+	t.Run(
+		"Disabled", func(t *testing.T) {
+			sm := New[string, int](10)
+			sm.Store("a", 1)
+			sm.Load("a")
+			if _, ok := sm.Meta("a"); ok {
+				t.Error("Expected no metadata without WithEntryMetadata")
+			}
+			sm.RangeMeta(
+				func(k string, v int, meta EntryMeta) bool {
+					if meta != (EntryMeta{}) {
+						t.Errorf("Expected zero metadata, got %+v", meta)
+					}
+					return true
+				},
+			)
+		},
+	)
+}
@@ -0,0 +1,168 @@
+package syncmap
+
+import (
+	"maps"
+	"time"
+)
+
+// Option configures a SyncMap created by New.
+type Option[K comparable, V any] func(m *SyncMap[K, V])
+
+// WithCapacity sets the initial capacity of the SyncMap, as the size argument of New does.
+func WithCapacity[K comparable, V any](capacity int) Option[K, V] {
+	return func(m *SyncMap[K, V]) {
+		m.data = make(map[K]V, capacity)
+	}
+}
+
+// WithKeyFunc sets a function that canonicalizes keys before every operation that takes a key
+// (Load, Store, Remove, LoadOrStore, LoadAndDelete, and their LockedMap counterparts),
+// so that logically equal keys (e.g. differing only in case or surrounding whitespace)
+// refer to the same entry. Keys are stored in their canonical form,
+// so Range, Map and Filter observe canonical keys.
+//
+// keyFn must be deterministic and must not call methods of the SyncMap.
+func WithKeyFunc[K comparable, V any](keyFn func(k K) K) Option[K, V] {
+	return func(m *SyncMap[K, V]) {
+		m.keyFn = keyFn
+	}
+}
+
+// WithStrictMode makes the SyncMap panic with a descriptive error when an operation is rejected
+// (for example, storing a forbidden zero key), instead of silently dropping the write.
+// The panic value wraps the rejection error, so it can be inspected with errors.Is after recover.
+// Regardless of the mode, TryStore always reports rejections as errors.
+func WithStrictMode[K comparable, V any]() Option[K, V] {
+	return func(m *SyncMap[K, V]) {
+		m.strict = true
+	}
+}
+
+// WithZeroKeyForbidden makes the SyncMap reject stores of the zero value of the key type with ErrZeroKey.
+// This catches uninitialized keys (empty strings, zero IDs) being stored by mistake.
+func WithZeroKeyForbidden[K comparable, V any]() Option[K, V] {
+	return func(m *SyncMap[K, V]) {
+		m.forbidZeroKey = true
+	}
+}
+
+// WithSoftLimit sets a soft limit on the number of entries in the SyncMap.
+// When a write makes the map grow to limit entries or more, onExceeded is called with the new size.
+// The map keeps accepting writes; the callback is meant for alerting, before growth becomes a problem.
+// The callback fires once per crossing and is re-armed when the map shrinks below the limit again.
+// It is called after the lock has been released, so it may use the map.
+func WithSoftLimit[K comparable, V any](limit int, onExceeded func(size int)) Option[K, V] {
+	return func(m *SyncMap[K, V]) {
+		m.softLimit = limit
+		m.onSoftLimit = onExceeded
+	}
+}
+
+// WithScanThrottle makes Range yield the read lock after every chunk entries and pause for the given duration
+// (or just yield the processor if pause is 0) before continuing, so that long background scans
+// do not spike writer latency.
+// The price is consistency: a throttled Range does not observe a single point-in-time view of the map.
+// Entries removed while the lock is yielded and not yet reached are not visited, entries added may or may not be,
+// and after Purge or ReplaceAll the scan finishes over the previous contents.
+func WithScanThrottle[K comparable, V any](chunk int, pause time.Duration) Option[K, V] {
+	return func(m *SyncMap[K, V]) {
+		m.scanChunk = chunk
+		m.scanPause = pause
+	}
+}
+
+// WithChangeDetector sets an equality function used to skip no-op writes: when a stored value
+// is equal to the current value of the key according to eq, the write is skipped entirely,
+// so the version is not bumped and no change is signalled to callbacks.
+// It applies to every write of an existing key, whether made through Store, ApplyDelta or a LockedMap.
+func WithChangeDetector[K comparable, V any](eq func(old, new V) bool) Option[K, V] {
+	return func(m *SyncMap[K, V]) {
+		m.unchanged = eq
+	}
+}
+
+// WithKeyInterning makes the SyncMap intern keys when they are first stored, using the unique package:
+// equal keys stored in any interned map (or interned elsewhere in the process) share a single canonical copy,
+// and strings sliced from larger buffers are copied, so that they no longer pin those buffers in memory.
+// The map holds the unique.Handle of each of its keys while the key is in the map, which keeps the canonical
+// copy alive, as the unique package drops canonical copies that no handle references anymore.
+// This cuts memory for maps with many string (or string-containing) keys, at the cost of a handle
+// per entry and of an extra lookup in the global intern table for every insertion of a new key.
+func WithKeyInterning[K comparable, V any]() Option[K, V] {
+	return func(m *SyncMap[K, V]) {
+		m.intern = true
+	}
+}
+
+// Disposer releases the resources held by an entry dropped from a SyncMap.
+type Disposer[K comparable, V any] func(k K, v V)
+
+// WithDisposer sets a Disposer that is called for every entry dropped from the map:
+// by Remove, Purge, ReplaceAll and ApplyDelta, including through a LockedMap, and by expiration or eviction,
+// so that resources held by values are released whichever path drops them.
+// It is not called by LoadAndDelete, which hands the value over to the caller, nor for values
+// replaced by a write to an existing key.
+// The Disposer is called after the lock has been released, so it may use the map.
+func WithDisposer[K comparable, V any](dispose Disposer[K, V]) Option[K, V] {
+	return func(m *SyncMap[K, V]) {
+		m.dispose = dispose
+	}
+}
+
+// WithName sets the name of the SyncMap, so that services with many maps can tell them apart.
+// The name is reported by Name and included in the errors and panics reported by the map.
+func WithName[K comparable, V any](name string) Option[K, V] {
+	return func(m *SyncMap[K, V]) {
+		m.name = name
+	}
+}
+
+// WithLabels attaches a copy of the given labels (e.g. owning team or subsystem) to the SyncMap.
+// They are reported by Labels, for observability tooling to tag what it reports about the map.
+func WithLabels[K comparable, V any](labels map[string]string) Option[K, V] {
+	return func(m *SyncMap[K, V]) {
+		m.labels = maps.Clone(labels)
+	}
+}
+
+// WithReadMostly makes Load lock-free, for read-heavy workloads where even the read lock
+// causes cache-line contention between cores. Load reads an immutable snapshot of the map,
+// which is copied and atomically published whenever a critical section that modified the map
+// releases the write lock. Writes thus cost O(n), so this only suits maps that are rarely written.
+// A Load made by the goroutine holding the write lock (see WithReentrantLocking) does not observe
+// the changes of its critical section yet; the LockedMap does.
+func WithReadMostly[K comparable, V any]() Option[K, V] {
+	return func(m *SyncMap[K, V]) {
+		m.readMostly = true
+		m.publish()
+	}
+}
+
+// WithMaxValueSize makes the SyncMap reject stores of values larger than limit bytes with ErrValueTooLarge,
+// so that accidentally huge values are caught when stored instead of silently bloating the process.
+// sizer returns the size of a value in bytes; it is called for every stored value, so it should be cheap
+// (e.g. the length of a byte slice, or an estimate).
+func WithMaxValueSize[K comparable, V any](limit int, sizer func(v V) int) Option[K, V] {
+	return func(m *SyncMap[K, V]) {
+		m.maxValueSize = limit
+		m.sizer = sizer
+	}
+}
+
+// WithSlidingExpiration makes every Load of an entry stored with StoreWithTTL restart its TTL, as Touch does,
+// so that entries expire once they have not been read for their TTL, as sessions time out when idle.
+// Loads through a LockedMap and LoadOrStore of an existing key count as reads; iterations do not.
+func WithSlidingExpiration[K comparable, V any]() Option[K, V] {
+	return func(m *SyncMap[K, V]) {
+		m.sliding = true
+	}
+}
+
+// WithAutoCompaction makes the SyncMap compact itself (see Compact) once it has shrunk to a quarter
+// of the largest size it has reached since it was last rebuilt, provided that size was at least minPeak entries.
+// Compaction happens when the write lock is released, so it never disrupts an iteration in progress.
+func WithAutoCompaction[K comparable, V any](minPeak int) Option[K, V] {
+	return func(m *SyncMap[K, V]) {
+		m.compactAt = minPeak
+	}
+}
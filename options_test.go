@@ -0,0 +1,634 @@
+package syncmap
+
+import (
+	"errors"
+	"maps"
+	"runtime"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+	"unsafe"
+)
+
+func TestNewWithOptions(t *testing.T) {
+	sm := NewWithOptions[string, int](
+		WithCapacity[string, int](100),
+		WithKeyFunc[string, int](strings.ToLower),
+	)
+
+	sm.Store("KEY1", 1)
+	if v, ok := sm.Load("key1"); !ok || v != 1 {
+		t.Errorf("Expected (1, true), got (%v, %v)", v, ok)
+	}
+
+	empty := NewWithOptions[string, int]()
+	empty.Store("key1", 1)
+	if empty.Len() != 1 {
+		t.Errorf("Expected 1, got %d", empty.Len())
+	}
+}
+
+func TestWithKeyFunc(t *testing.T) {
+	type userKey struct {
+		Tenant string
+		Email  string
+	}
+
+	normalize := func(k userKey) userKey {
+		return userKey{
+			Tenant: strings.TrimSpace(k.Tenant),
+			Email:  strings.ToLower(strings.TrimSpace(k.Email)),
+		}
+	}
+
+	sm := New[userKey, int](10, WithKeyFunc[userKey, int](normalize))
+
+	t.Run(
+		"Store and Load", func(t *testing.T) {
+			sm.Store(userKey{Tenant: " acme", Email: "Bob@Example.com "}, 1)
+
+			if v, ok := sm.Load(userKey{Tenant: "acme", Email: "bob@example.com"}); !ok || v != 1 {
+				t.Errorf("Expected 1, got %v", v)
+			}
+			if sm.Len() != 1 {
+				t.Errorf("Expected length 1, got %d", sm.Len())
+			}
+		},
+	)
+
+	t.Run(
+		"Keys are stored in canonical form", func(t *testing.T) {
+			sm.Range(
+				func(key userKey, value int) bool {
+					if key != (userKey{Tenant: "acme", Email: "bob@example.com"}) {
+						t.Errorf("Expected canonical key, got %+v", key)
+					}
+					return true
+				},
+			)
+		},
+	)
+
+	t.Run(
+		"LoadOrStore", func(t *testing.T) {
+			if v, loaded := sm.LoadOrStore(userKey{Tenant: "acme", Email: "BOB@example.com"}, 2); !loaded || v != 1 {
+				t.Errorf("Expected existing value 1, got %v", v)
+			}
+		},
+	)
+
+	t.Run(
+		"LockedMap", func(t *testing.T) {
+			sm.DoLocked(
+				func(m LockedMap[userKey, int]) {
+					m.Store(userKey{Tenant: "acme ", Email: "ALICE@example.com"}, 3)
+					if v, ok := m.Load(userKey{Tenant: "acme", Email: "alice@example.com"}); !ok || v != 3 {
+						t.Errorf("Expected 3, got %v", v)
+					}
+				},
+			)
+		},
+	)
+
+	t.Run(
+		"Remove and LoadAndDelete", func(t *testing.T) {
+			if !sm.Remove(userKey{Tenant: "acme", Email: " bob@EXAMPLE.com"}) {
+				t.Error("Remove should return true for a logically equal key")
+			}
+			if v, ok := sm.LoadAndDelete(userKey{Tenant: "acme", Email: "Alice@Example.com"}); !ok || v != 3 {
+				t.Errorf("Expected 3, got %v", v)
+			}
+			if sm.Len() != 0 {
+				t.Errorf("Expected length 0, got %d", sm.Len())
+			}
+		},
+	)
+}
+
+func TestWithZeroKeyForbidden(t *testing.T) {
+	t.Run(
+		"Lenient mode", func(t *testing.T) {
+			sm := New[string, int](10, WithZeroKeyForbidden[string, int]())
+
+			sm.Store("", 1)
+			if _, ok := sm.Load(""); ok {
+				t.Error("Zero key should not be stored")
+			}
+
+			if v, loaded := sm.LoadOrStore("", 2); loaded || v != 0 {
+				t.Errorf("Expected rejected LoadOrStore to return 0, false, got %v, %v", v, loaded)
+			}
+
+			if err := sm.TryStore("", 3); !errors.Is(err, ErrZeroKey) {
+				t.Errorf("Expected ErrZeroKey, got %v", err)
+			}
+			if err := sm.TryStore("key1", 1); err != nil {
+				t.Errorf("Expected no error, got %v", err)
+			}
+			if sm.Len() != 1 {
+				t.Errorf("Expected length 1, got %d", sm.Len())
+			}
+		},
+	)
+
+	t.Run(
+		"Strict mode", func(t *testing.T) {
+			sm := New[string, int](10, WithZeroKeyForbidden[string, int](), WithStrictMode[string, int]())
+
+			expectPanic := func(name string, f func()) {
+				defer func() {
+					r := recover()
+					err, ok := r.(error)
+					if !ok || !errors.Is(err, ErrZeroKey) {
+						t.Errorf("%s: expected panic with ErrZeroKey, got %v", name, r)
+					}
+				}()
+				f()
+			}
+
+			expectPanic(
+				"Store", func() {
+					sm.Store("", 1)
+				},
+			)
+			expectPanic(
+				"LoadOrStore", func() {
+					sm.LoadOrStore("", 1)
+				},
+			)
+			expectPanic(
+				"LockedMap.Store", func() {
+					sm.DoLocked(
+						func(m LockedMap[string, int]) {
+							m.Store("", 1)
+						},
+					)
+				},
+			)
+
+			if err := sm.TryStore("", 1); !errors.Is(err, ErrZeroKey) {
+				t.Errorf("Expected ErrZeroKey, got %v", err)
+			}
+			if sm.Len() != 0 {
+				t.Errorf("Expected length 0, got %d", sm.Len())
+			}
+		},
+	)
+
+	t.Run(
+		"Nil map", func(t *testing.T) {
+			var sm *SyncMap[string, int]
+			if err := sm.TryStore("key1", 1); !errors.Is(err, ErrNilMap) {
+				t.Errorf("Expected ErrNilMap, got %v", err)
+			}
+		},
+	)
+}
+
+func TestWithSoftLimit(t *testing.T) {
+	var sizes []int
+	var sm *SyncMap[string, int]
+	sm = New[string, int](
+		10, WithSoftLimit[string, int](
+			2, func(size int) {
+				// the callback runs outside the lock, so using the map must not deadlock
+				if sm.Len() < size {
+					t.Errorf("Expected length of at least %d, got %d", size, sm.Len())
+				}
+				sizes = append(sizes, size)
+			},
+		),
+	)
+
+	sm.Store("key1", 1)
+	if len(sizes) != 0 {
+		t.Fatalf("Callback should not fire below the limit, got %v", sizes)
+	}
+
+	sm.Store("key2", 2)
+	sm.Store("key3", 3)
+	sm.Store("key3", 4)
+	if !slices.Equal(sizes, []int{2}) {
+		t.Fatalf("Expected callback to fire once with size 2, got %v", sizes)
+	}
+
+	sm.Remove("key3")
+	sm.Remove("key2")
+	sm.DoLocked(
+		func(m LockedMap[string, int]) {
+			m.Store("key2", 2)
+			m.Store("key3", 3)
+		},
+	)
+	if !slices.Equal(sizes, []int{2, 2}) {
+		t.Fatalf("Expected callback to fire again after re-arming, got %v", sizes)
+	}
+}
+
+func TestWithScanThrottle(t *testing.T) {
+	sm := New[int, int](10, WithScanThrottle[int, int](1, 20*time.Millisecond))
+	for i := 0; i < 3; i++ {
+		sm.Store(i, i)
+	}
+
+	started := make(chan struct{})
+	var stored atomic.Bool
+	visited := 0
+	storedBeforeEnd := false
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		sm.Range(
+			func(key int, value int) bool {
+				visited++
+				if visited == 1 {
+					close(started)
+				} else if stored.Load() {
+					storedBeforeEnd = true
+				}
+				return true
+			},
+		)
+	}()
+
+	<-started
+	// the writer gets the lock while the scan is paused between chunks
+	sm.Store(100, 100)
+	stored.Store(true)
+	<-done
+
+	if !storedBeforeEnd {
+		t.Error("Expected the writer to proceed before the throttled scan finished")
+	}
+	if visited < 3 {
+		t.Errorf("Expected at least 3 visited entries, got %d", visited)
+	}
+
+	count := 0
+	sm.Range(
+		func(key int, value int) bool {
+			count++
+			return count < 2
+		},
+	)
+	if count != 2 {
+		t.Errorf("Expected the scan to stop after 2 entries, got %d", count)
+	}
+}
+
+func TestWithChangeDetector(t *testing.T) {
+	type config struct {
+		Endpoint string
+		Retries  int
+	}
+
+	sm := New[string, config](
+		10, WithChangeDetector[string, config](
+			func(old, new config) bool {
+				return old == new
+			},
+		),
+	)
+
+	sm.Store("svc", config{Endpoint: "a", Retries: 1})
+	version := sm.Version()
+
+	sm.Store("svc", config{Endpoint: "a", Retries: 1})
+	sm.DoLocked(
+		func(m LockedMap[string, config]) {
+			m.Store("svc", config{Endpoint: "a", Retries: 1})
+		},
+	)
+	if sm.Version() != version {
+		t.Errorf("Expected no-op writes to keep version %d, got %d", version, sm.Version())
+	}
+
+	sm.Store("svc", config{Endpoint: "b", Retries: 1})
+	if sm.Version() == version {
+		t.Error("Expected a real change to bump the version")
+	}
+	if v, _ := sm.Load("svc"); v.Endpoint != "b" {
+		t.Errorf("Expected endpoint b, got %s", v.Endpoint)
+	}
+}
+
+func TestWithKeyInterning(t *testing.T) {
+	sm1 := New[string, int](10, WithKeyInterning[string, int]())
+	sm2 := New[string, int](10, WithKeyInterning[string, int]())
+
+	// two distinct allocations of the same key, sliced from larger buffers
+	buf1 := strings.Repeat("x", 1024) + "tenant/42/user/7"
+	buf2 := strings.Repeat("y", 1024) + "tenant/42/user/7"
+	sm1.Store(buf1[1024:], 1)
+	// the canonical copy must outlive garbage collections while a map holds the key
+	runtime.GC()
+	runtime.GC()
+	sm2.Store(buf2[1024:], 2)
+
+	var k1, k2 string
+	sm1.Range(
+		func(key string, value int) bool {
+			k1 = key
+			return true
+		},
+	)
+	sm2.Range(
+		func(key string, value int) bool {
+			k2 = key
+			return true
+		},
+	)
+
+	if k1 != "tenant/42/user/7" || k2 != k1 {
+		t.Fatalf("Unexpected keys %q and %q", k1, k2)
+	}
+	if unsafe.StringData(k1) != unsafe.StringData(k2) {
+		t.Error("Expected interned keys to share storage")
+	}
+	if unsafe.StringData(k1) == unsafe.StringData(buf1[1024:]) {
+		t.Error("Expected the interned key not to reference the original buffer")
+	}
+
+	if v, ok := sm1.Load("tenant/42/user/7"); !ok || v != 1 {
+		t.Errorf("Expected 1, got %v", v)
+	}
+
+	// the handle is released with the entry
+	sm1.Remove("tenant/42/user/7")
+	if len(sm1.handles) != 0 {
+		t.Errorf("Expected no handle left, got %d", len(sm1.handles))
+	}
+}
+
+func TestWithDisposer(t *testing.T) {
+	var disposed []string
+	sm := New[string, int](
+		10, WithDisposer[string, int](
+			func(k string, v int) {
+				disposed = append(disposed, k)
+			},
+		),
+	)
+	reset := func(data map[string]int) {
+		sm.ReplaceAll(data)
+		disposed = nil
+	}
+
+	t.Run(
+		"Remove", func(t *testing.T) {
+			reset(map[string]int{"a": 1, "b": 2})
+			sm.Remove("a")
+			sm.Remove("missing")
+			sm.DoLocked(
+				func(m LockedMap[string, int]) {
+					m.Remove("b")
+				},
+			)
+			if !slices.Equal(disposed, []string{"a", "b"}) {
+				t.Errorf("Expected [a b] to be disposed, got %v", disposed)
+			}
+		},
+	)
+
+	t.Run(
+		"Purge", func(t *testing.T) {
+			reset(map[string]int{"a": 1, "b": 2})
+			sm.Purge()
+			slices.Sort(disposed)
+			if !slices.Equal(disposed, []string{"a", "b"}) {
+				t.Errorf("Expected [a b] to be disposed, got %v", disposed)
+			}
+		},
+	)
+
+	t.Run(
+		"ReplaceAll", func(t *testing.T) {
+			reset(map[string]int{"a": 1, "b": 2})
+			// b is refreshed with the same value, which is still in use
+			sm.ReplaceAll(map[string]int{"b": 2, "c": 3})
+			if !slices.Equal(disposed, []string{"a"}) {
+				t.Errorf("Expected [a] to be disposed, got %v", disposed)
+			}
+		},
+	)
+
+	t.Run(
+		"ApplyDelta", func(t *testing.T) {
+			reset(map[string]int{"a": 1, "b": 2})
+			sm.ApplyDelta(Delta[string, int]{Deletes: []string{"a"}, Upserts: map[string]int{"b": 3}})
+			if !slices.Equal(disposed, []string{"a"}) {
+				t.Errorf("Expected [a] to be disposed, got %v", disposed)
+			}
+		},
+	)
+
+	t.Run(
+		"ApplyDeltaReset", func(t *testing.T) {
+			reset(map[string]int{"a": 1, "b": 2})
+			sm.ApplyDelta(Delta[string, int]{Reset: true, Upserts: map[string]int{"a": 1}})
+			if !slices.Equal(disposed, []string{"b"}) {
+				t.Errorf("Expected [b] to be disposed, got %v", disposed)
+			}
+		},
+	)
+
+	t.Run(
+		"LoadAndDeleteHandsOver", func(t *testing.T) {
+			reset(map[string]int{"a": 1})
+			sm.LoadAndDelete("a")
+			if len(disposed) != 0 {
+				t.Errorf("Expected nothing to be disposed, got %v", disposed)
+			}
+		},
+	)
+
+	t.Run(
+		"OutsideLock", func(t *testing.T) {
+			var sm *SyncMap[string, int]
+			sm = New[string, int](
+				10, WithDisposer[string, int](
+					func(k string, v int) {
+						if sm.LenLocked() != 0 {
+							t.Errorf("Expected the map to be empty, got %d entries", sm.LenLocked())
+						}
+					},
+				),
+			)
+			sm.Store("a", 1)
+			sm.Remove("a")
+		},
+	)
+}
+
+func TestWithNameAndLabels(t *testing.T) {
+	labels := map[string]string{"team": "payments"}
+	sm := New[string, int](
+		10,
+		WithName[string, int]("sessions"),
+		WithLabels[string, int](labels),
+		WithZeroKeyForbidden[string, int](),
+		WithStrictMode[string, int](),
+	)
+	labels["team"] = "search"
+
+	if sm.Name() != "sessions" {
+		t.Errorf("Expected name sessions, got %q", sm.Name())
+	}
+	if got := sm.Labels(); !maps.Equal(got, map[string]string{"team": "payments"}) {
+		t.Errorf("Unexpected labels %v", got)
+	}
+
+	func() {
+		defer func() {
+			err, _ := recover().(error)
+			if err == nil || !strings.Contains(err.Error(), `map "sessions"`) {
+				t.Errorf("Expected the strict mode panic to name the map, got %v", err)
+			}
+		}()
+		sm.Store("", 1)
+	}()
+
+	if unnamed := New[string, int](10); unnamed.Name() != "" || unnamed.Labels() != nil {
+		t.Error("Expected no name and no labels by default")
+	}
+}
+
+func TestWithReadMostly(t *testing.T) {
+	sm := New[string, int](10, WithReadMostly[string, int]())
+
+	if _, ok := sm.Load("key1"); ok {
+		t.Error("Load should return false for non-existent key")
+	}
+
+	sm.Store("key1", 1)
+	if v, ok := sm.Load("key1"); !ok || v != 1 {
+		t.Errorf("Expected (1, true), got (%v, %v)", v, ok)
+	}
+
+	sm.DoLocked(
+		func(m LockedMap[string, int]) {
+			m.Store("key2", 2)
+			m.Remove("key1")
+		},
+	)
+	if _, ok := sm.Load("key1"); ok {
+		t.Error("Expected key1 to be removed")
+	}
+	if v, ok := sm.Load("key2"); !ok || v != 2 {
+		t.Errorf("Expected (2, true), got (%v, %v)", v, ok)
+	}
+
+	// Load must not block on the write lock
+	sm.DoLocked(
+		func(m LockedMap[string, int]) {
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				if v, ok := sm.Load("key2"); !ok || v != 2 {
+					t.Errorf("Expected (2, true), got (%v, %v)", v, ok)
+				}
+			}()
+			select {
+			case <-done:
+			case <-time.After(time.Second):
+				t.Error("Load blocked while the write lock was held")
+			}
+		},
+	)
+
+	sm.Purge()
+	if _, ok := sm.Load("key2"); ok {
+		t.Error("Expected the map to be empty after Purge")
+	}
+}
+
+func TestWithMaxValueSize(t *testing.T) {
+	sizer := func(v []byte) int {
+		return len(v)
+	}
+
+	t.Run(
+		"TryStore", func(t *testing.T) {
+			sm := New[string, []byte](10, WithMaxValueSize[string, []byte](4, sizer))
+
+			if err := sm.TryStore("small", []byte("abcd")); err != nil {
+				t.Errorf("Expected no error, got %v", err)
+			}
+			err := sm.TryStore("large", []byte("abcde"))
+			if !errors.Is(err, ErrValueTooLarge) {
+				t.Errorf("Expected ErrValueTooLarge, got %v", err)
+			}
+			if err != nil && !strings.Contains(err.Error(), "5 bytes, limit is 4") {
+				t.Errorf("Expected the error to report the sizes, got %v", err)
+			}
+
+			sm.Store("large", []byte("abcde"))
+			if _, ok := sm.Load("large"); ok {
+				t.Error("Expected the large value to be rejected")
+			}
+		},
+	)
+
+	t.Run(
+		"StrictMode", func(t *testing.T) {
+			sm := New[string, []byte](
+				10, WithMaxValueSize[string, []byte](4, sizer), WithStrictMode[string, []byte](),
+			)
+			defer func() {
+				err, _ := recover().(error)
+				if !errors.Is(err, ErrValueTooLarge) {
+					t.Errorf("Expected a panic wrapping ErrValueTooLarge, got %v", err)
+				}
+			}()
+			sm.DoLocked(
+				func(m LockedMap[string, []byte]) {
+					m.Store("large", []byte("abcde"))
+				},
+			)
+		},
+	)
+}
+
+func TestWithAutoCompaction(t *testing.T) {
+	sm := New[int, int](0, WithAutoCompaction[int, int](100))
+	for i := 0; i < 100; i++ {
+		sm.Store(i, i)
+	}
+
+	// removing entries while iterating must not be disrupted by compaction
+	visited := 0
+	sm.RangeMut(
+		func(m LockedMap[int, int], key int, value int) bool {
+			visited++
+			if key >= 10 {
+				m.Remove(key)
+			}
+			return true
+		},
+	)
+	if visited != 100 {
+		t.Errorf("Expected 100 entries to be visited, got %d", visited)
+	}
+	if sm.peak != 10 {
+		t.Errorf("Expected the map to be compacted to 10 entries, got %d", sm.peak)
+	}
+	for i := 0; i < 10; i++ {
+		if v, ok := sm.Load(i); !ok || v != i {
+			t.Errorf("Expected (%d, true), got (%v, %v)", i, v, ok)
+		}
+	}
+
+	small := New[int, int](0, WithAutoCompaction[int, int](100))
+	for i := 0; i < 50; i++ {
+		small.Store(i, i)
+	}
+	for i := 0; i < 50; i++ {
+		small.Remove(i)
+	}
+	if small.peak != 50 {
+		t.Errorf("Expected maps below the minimum peak not to be compacted, got peak %d", small.peak)
+	}
+}
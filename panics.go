@@ -0,0 +1,63 @@
+package syncmap
+
+import (
+	"fmt"
+	"runtime/debug"
+)
+
+// CallbackPanicError is the error reported by a SyncMap created WithPanicIsolation
+// when a user-supplied callback panics.
+type CallbackPanicError struct {
+	// Map is the name of the SyncMap, see WithName.
+	Map string
+	// Op is the name of the SyncMap method whose callback panicked, e.g. "Range".
+	Op string
+	// Value is the value the callback panicked with.
+	Value any
+	// Stack is the stack trace of the goroutine that panicked.
+	Stack []byte
+}
+
+func (e *CallbackPanicError) Error() string {
+	if e.Map != "" {
+		return fmt.Sprintf("syncmap: callback passed to %s of map %q panicked: %v", e.Op, e.Map, e.Value)
+	}
+	return fmt.Sprintf("syncmap: callback passed to %s panicked: %v", e.Op, e.Value)
+}
+
+// Unwrap returns the panic value if it is an error.
+func (e *CallbackPanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+// WithPanicIsolation makes Range, Map, Filter and ParallelRange recover panics of the callbacks passed to them.
+// The panic is turned into a *CallbackPanicError, which is passed to onError once the locks have been released,
+// and the operation returns early with zero results. If onError is nil, the *CallbackPanicError is re-panicked
+// in the calling goroutine instead; this matters for ParallelRange, whose callbacks run in other goroutines
+// where a panic would otherwise crash the program.
+func WithPanicIsolation[K comparable, V any](onError func(err error)) Option[K, V] {
+	return func(m *SyncMap[K, V]) {
+		m.isolatePanics = true
+		m.onPanic = onError
+	}
+}
+
+// recoverCallback recovers a panic of a callback of op if panic isolation is enabled, and reports it.
+// It must be deferred before acquiring the lock, so that the panic is reported after the lock is released.
+func (m *SyncMap[K, V]) recoverCallback(op string) {
+	if !m.isolatePanics {
+		return
+	}
+	if r := recover(); r != nil {
+		m.reportPanic(&CallbackPanicError{Map: m.name, Op: op, Value: r, Stack: debug.Stack()})
+	}
+}
+
+// reportPanic passes err to the panic isolation handler, or re-panics with it if there is none.
+func (m *SyncMap[K, V]) reportPanic(err *CallbackPanicError) {
+	if m.onPanic == nil {
+		panic(err)
+	}
+	m.onPanic(err)
+}
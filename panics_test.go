@@ -0,0 +1,96 @@
+package syncmap
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestWithPanicIsolation(t *testing.T) {
+	errBoom := errors.New("boom")
+
+	t.Run(
+		"OnError", func(t *testing.T) {
+			var reported []error
+			sm := New[string, int](10, WithPanicIsolation[string, int](func(err error) { reported = append(reported, err) }))
+			sm.Store("key1", 1)
+
+			sm.Range(
+				func(key string, value int) bool {
+					panic(errBoom)
+				},
+			)
+			if res := sm.Filter(func(k string, v int) bool { panic("filter") }); res != nil {
+				t.Errorf("Expected nil result, got %v", res)
+			}
+			sm.Map(func(k string, v int) int { panic("map") })
+			sm.ParallelRange(4, func(key string, value int) { panic("parallel") })
+
+			var ops []string
+			for _, err := range reported {
+				var cpe *CallbackPanicError
+				if !errors.As(err, &cpe) {
+					t.Fatalf("Expected a *CallbackPanicError, got %T", err)
+				}
+				ops = append(ops, cpe.Op)
+			}
+			if strings.Join(ops, ",") != "Range,Filter,Map,ParallelRange" {
+				t.Errorf("Unexpected reported operations %v", ops)
+			}
+			if !errors.Is(reported[0], errBoom) {
+				t.Error("Expected the error to wrap the panic value")
+			}
+
+			// the locks must have been released
+			sm.Store("key2", 2)
+			if sm.Len() != 2 {
+				t.Errorf("Expected 2 entries, got %d", sm.Len())
+			}
+		},
+	)
+
+	t.Run(
+		"Rethrow", func(t *testing.T) {
+			sm := New[string, int](10, WithPanicIsolation[string, int](nil))
+			sm.Store("key1", 1)
+
+			func() {
+				defer func() {
+					err, ok := recover().(*CallbackPanicError)
+					if !ok || err.Op != "ParallelRange" || err.Value != "parallel" {
+						t.Errorf("Expected a *CallbackPanicError from ParallelRange, got %v", err)
+					}
+				}()
+				sm.ParallelRange(4, func(key string, value int) { panic("parallel") })
+			}()
+
+			sm.Store("key2", 2)
+			if sm.Len() != 2 {
+				t.Errorf("Expected 2 entries, got %d", sm.Len())
+			}
+		},
+	)
+
+	t.Run(
+		"OnErrorMayUseMap", func(t *testing.T) {
+			var sm *SyncMap[string, int]
+			sm = New[string, int](
+				10, WithPanicIsolation[string, int](
+					func(err error) {
+						sm.Store("failed", 1)
+					},
+				),
+			)
+			sm.Store("key1", 1)
+
+			sm.Range(
+				func(key string, value int) bool {
+					panic("range")
+				},
+			)
+			if _, ok := sm.Load("failed"); !ok {
+				t.Error("Expected the error handler to have stored a key")
+			}
+		},
+	)
+}
@@ -0,0 +1,79 @@
+package syncmap
+
+import (
+	"maps"
+)
+
+// Pin protects the entry of k from automatic removal: while pinned, it neither expires nor is evicted,
+// whatever its TTL and the eviction policy of the map. Explicit removals (Remove, Purge, etc.) still
+// remove it, and unpin it. A bounded map whose entries are all pinned grows beyond its bound.
+// It reports whether the key is present.
+// It acquires a write lock to ensure thread-safe access to the underlying data.
+func (m *SyncMap[K, V]) Pin(k K) bool {
+	m.lock()
+	defer m.unlock()
+
+	k = m.key(k)
+	if _, ok := m.load(k); !ok {
+		return false
+	}
+	if _, ok := m.pinned[k]; ok {
+		return true
+	}
+
+	if m.pinned == nil {
+		m.pinned = make(map[K]struct{})
+	}
+	m.pinned[k] = struct{}{}
+	// the eviction policy only knows the keys it may evict
+	m.trackRemoved(k)
+	m.version.Add(1)
+	return true
+}
+
+// Unpin undoes Pin: the entry of k can be removed automatically again.
+// If its TTL has elapsed in the meantime, it expires right away.
+// It reports whether the key was pinned.
+// It acquires a write lock to ensure thread-safe access to the underlying data.
+func (m *SyncMap[K, V]) Unpin(k K) bool {
+	m.lock()
+	defer m.unlock()
+
+	k = m.key(k)
+	if _, ok := m.pinned[k]; !ok {
+		return false
+	}
+
+	delete(m.pinned, k)
+	m.trackAdded(k)
+	m.version.Add(1)
+	return true
+}
+
+// Pinned reports whether the entry of k is pinned.
+// It acquires a read lock to ensure thread-safe access to the underlying data.
+func (m *SyncMap[K, V]) Pinned(k K) bool {
+	m.rlock()
+	defer m.runlock()
+
+	_, ok := m.pinned[m.key(k)]
+	return ok
+}
+
+// isPinned reports whether the entry of k is pinned. It assumes that the caller holds at least the read lock.
+func (m *SyncMap[K, V]) isPinned(k K) bool {
+	if len(m.pinned) == 0 {
+		return false
+	}
+	_, ok := m.pinned[k]
+	return ok
+}
+
+// unpinned returns the expiration deadlines of the entries that are not pinned, for read-mostly snapshots.
+func (m *SyncMap[K, V]) unpinned() map[K]*deadline {
+	expiry := maps.Clone(m.expiry)
+	for k := range m.pinned {
+		delete(expiry, k)
+	}
+	return expiry
+}
@@ -0,0 +1,86 @@
+package syncmap
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSyncMapPin(t *testing.T) {
+	t.Run(
+		"Expiration", func(t *testing.T) {
+			for _, readMostly := range []bool{false, true} {
+				clock := newFakeClock()
+				opts := []Option[string, int]{WithClock[string, int](clock.Now)}
+				if readMostly {
+					opts = append(opts, WithReadMostly[string, int]())
+				}
+				sm := New[string, int](10, opts...)
+				sm.StoreWithTTL("config", 1, time.Second)
+
+				if !sm.Pin("config") || !sm.Pinned("config") {
+					t.Error("Expected the entry to be pinned")
+				}
+				clock.Advance(time.Hour)
+				if v, ok := sm.Load("config"); !ok || v != 1 {
+					t.Errorf("Expected the pinned entry not to expire, got (%v, %v) (read-mostly: %v)", v, ok, readMostly)
+				}
+				if n := sm.RemoveExpired(); n != 0 {
+					t.Errorf("Expected no entry to be removed, got %d", n)
+				}
+
+				if !sm.Unpin("config") || sm.Pinned("config") {
+					t.Error("Expected the entry to be unpinned")
+				}
+				if _, ok := sm.Load("config"); ok {
+					t.Errorf("Expected the unpinned entry to expire right away (read-mostly: %v)", readMostly)
+				}
+			}
+		},
+	)
+
+	t.Run(
+		"Eviction", func(t *testing.T) {
+			sm := New[string, int](2, WithMaxEntries[string, int](2), WithPressureEviction[string, int](1, 1))
+			sm.Store("config", 0)
+			sm.Pin("config")
+			sm.Store("a", 1)
+			sm.Store("b", 2) // evicts a, the least recently used entry that is not pinned
+			if _, ok := sm.Load("config"); !ok {
+				t.Error("Expected the pinned entry not to be evicted")
+			}
+			if _, ok := sm.Load("a"); ok {
+				t.Error("Expected a to be evicted")
+			}
+
+			if n := sm.OnMemoryPressure(PressureCritical); n != 1 || sm.Len() != 1 {
+				t.Errorf("Expected all the entries but the pinned one to be evicted, got %d evicted", n)
+			}
+
+			sm.Unpin("config")
+			sm.Store("c", 3)
+			sm.Store("d", 4) // evicts config
+			if _, ok := sm.Load("config"); ok {
+				t.Error("Expected the unpinned entry to be evicted")
+			}
+		},
+	)
+
+	t.Run(
+		"ExplicitRemoval", func(t *testing.T) {
+			sm := New[string, int](10)
+			if sm.Pin("missing") || sm.Unpin("missing") {
+				t.Error("Expected absent keys not to be pinned")
+			}
+
+			sm.Store("a", 1)
+			sm.Pin("a")
+			if !sm.Remove("a") {
+				t.Error("Expected Remove to remove the pinned entry")
+			}
+			sm.Store("a", 1)
+			if sm.Pinned("a") {
+				t.Error("Expected the removal to unpin the entry")
+			}
+		},
+	)
+}
@@ -0,0 +1,136 @@
+package syncmap
+
+import (
+	"context"
+	"math"
+	"runtime/metrics"
+	"time"
+)
+
+// PressureLevel is the severity of memory pressure, see OnMemoryPressure.
+type PressureLevel int
+
+const (
+	// PressureNone means that the process is comfortably below its memory limit.
+	PressureNone PressureLevel = iota
+	// PressureModerate means that the process is approaching its memory limit.
+	PressureModerate
+	// PressureCritical means that the process is at or very close to its memory limit.
+	PressureCritical
+)
+
+// Thresholds of MemoryPressure, as fractions of the memory limit.
+const (
+	moderatePressureThreshold = 0.80
+	criticalPressureThreshold = 0.95
+)
+
+// MemoryPressureHandler is implemented by maps that can shed entries under memory pressure,
+// such as SyncMap and ShardedMap.
+type MemoryPressureHandler interface {
+	OnMemoryPressure(level PressureLevel) int
+}
+
+// WithPressureEviction sets the fractions (between 0 and 1) of the entries of the SyncMap
+// that OnMemoryPressure evicts at moderate and critical pressure.
+// Without it, OnMemoryPressure only removes expired entries.
+func WithPressureEviction[K comparable, V any](moderate, critical float64) Option[K, V] {
+	return func(m *SyncMap[K, V]) {
+		m.pressureEviction = [...]float64{PressureModerate: moderate, PressureCritical: critical}
+	}
+}
+
+// OnMemoryPressure responds to memory pressure: it removes all expired entries, then evicts
+// the fraction of the entries set by WithPressureEviction for the level (least recently used first
+// if the map was created WithMaxEntries), and returns how many entries it removed.
+// It is called by WatchMemoryLimit, or can be called directly by applications that have their own signals.
+// Evicted entries are reported as OpEvict events and handed to the Disposer, if any.
+// It acquires a write lock to ensure thread-safe access to the underlying data.
+func (m *SyncMap[K, V]) OnMemoryPressure(level PressureLevel) int {
+	m.lock()
+	defer m.unlock()
+
+	now := m.clock()
+	n := 0
+	for k := range m.expiry {
+		if m.expiredAt(k, now) {
+			m.expire(k)
+			n++
+		}
+	}
+
+	if level <= PressureNone || int(level) >= len(m.pressureEviction) {
+		return n
+	}
+	fraction := min(max(m.pressureEviction[level], 0), 1)
+	evict := int(math.Ceil(fraction * float64(len(m.data))))
+	for ; evict > 0; evict-- {
+		k, ok := m.victim()
+		if !ok {
+			break
+		}
+		m.evict(k, EvictionMemoryPressure)
+		n++
+	}
+	return n
+}
+
+// OnMemoryPressure calls OnMemoryPressure on every shard and returns how many entries were removed in total.
+func (m *ShardedMap[K, V]) OnMemoryPressure(level PressureLevel) int {
+	n := 0
+	for _, shard := range m.shards {
+		n += shard.OnMemoryPressure(level)
+	}
+	return n
+}
+
+// MemoryPressure returns the current memory pressure of the process, based on how close the memory
+// it uses is to the soft memory limit set with debug.SetMemoryLimit (or the GOMEMLIMIT environment variable).
+// Without a memory limit, it always returns PressureNone.
+func MemoryPressure() PressureLevel {
+	samples := []metrics.Sample{
+		{Name: "/gc/gomemlimit:bytes"},
+		{Name: "/memory/classes/total:bytes"},
+		{Name: "/memory/classes/heap/released:bytes"},
+	}
+	metrics.Read(samples)
+
+	limit := samples[0].Value.Uint64()
+	if limit == 0 || limit == math.MaxInt64 {
+		return PressureNone
+	}
+
+	// the memory limit accounts for all memory mapped by the runtime, except released heap memory
+	used := float64(samples[1].Value.Uint64() - samples[2].Value.Uint64())
+	switch {
+	case used >= criticalPressureThreshold*float64(limit):
+		return PressureCritical
+	case used >= moderatePressureThreshold*float64(limit):
+		return PressureModerate
+	default:
+		return PressureNone
+	}
+}
+
+// WatchMemoryLimit starts a background goroutine that checks MemoryPressure every interval
+// and calls OnMemoryPressure on the given maps whenever there is pressure.
+// The goroutine stops when ctx is done.
+func WatchMemoryLimit(ctx context.Context, interval time.Duration, maps ...MemoryPressureHandler) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if level := MemoryPressure(); level != PressureNone {
+					for _, m := range maps {
+						m.OnMemoryPressure(level)
+					}
+				}
+			}
+		}
+	}()
+}
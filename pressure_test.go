@@ -0,0 +1,105 @@
+package syncmap
+
+import (
+	"context"
+	"math"
+	"runtime/debug"
+	"testing"
+	"time"
+)
+
+func TestOnMemoryPressure(t *testing.T) {
+	t.Run(
+		"Eviction", func(t *testing.T) {
+			var evicted, disposed int
+			sm := New[int, int](
+				100,
+				WithPressureEviction[int, int](0.1, 0.5),
+				WithEventHook[int, int](
+					func(e Event[int, int]) {
+						if e.Op == OpEvict {
+							evicted++
+						}
+					},
+				),
+				WithDisposer[int, int](
+					func(k, v int) {
+						disposed++
+					},
+				),
+			)
+			for i := range 100 {
+				sm.Store(i, i)
+			}
+
+			if n := sm.OnMemoryPressure(PressureNone); n != 0 {
+				t.Errorf("Expected no eviction without pressure, got %d", n)
+			}
+			if n := sm.OnMemoryPressure(PressureModerate); n != 10 {
+				t.Errorf("Expected 10 evictions, got %d", n)
+			}
+			if n := sm.OnMemoryPressure(PressureCritical); n != 45 {
+				t.Errorf("Expected 45 evictions, got %d", n)
+			}
+			if sm.Len() != 45 || evicted != 55 || disposed != 55 {
+				t.Errorf("Expected 45 entries left and 55 evicted, got %d entries, %d evicted and %d disposed", sm.Len(), evicted, disposed)
+			}
+		},
+	)
+
+	t.Run(
+		"ExpiredFirst", func(t *testing.T) {
+			clock := newFakeClock()
+			sm := New[string, int](10, WithClock[string, int](clock.Now))
+			sm.StoreWithTTL("a", 1, time.Second)
+			sm.Store("b", 2)
+			clock.Advance(time.Second)
+
+			if n := sm.OnMemoryPressure(PressureCritical); n != 1 {
+				t.Errorf("Expected only the expired entry to be removed, got %d", n)
+			}
+			if v, ok := sm.Load("b"); !ok || v != 2 {
+				t.Errorf("Expected (2, true), got (%v, %v)", v, ok)
+			}
+		},
+	)
+
+	t.Run(
+		"Sharded", func(t *testing.T) {
+			m := NewSharded[int, int](4, 100, WithPressureEviction[int, int](1, 1))
+			for i := range 100 {
+				m.Store(i, i)
+			}
+			if n := m.OnMemoryPressure(PressureModerate); n != 100 || m.Len() != 0 {
+				t.Errorf("Expected all 100 entries to be evicted, got %d and %d left", n, m.Len())
+			}
+		},
+	)
+}
+
+func TestMemoryPressure(t *testing.T) {
+	defer debug.SetMemoryLimit(debug.SetMemoryLimit(math.MaxInt64))
+	if level := MemoryPressure(); level != PressureNone {
+		t.Errorf("Expected no pressure without a memory limit, got %v", level)
+	}
+
+	// a limit below what the runtime already uses
+	debug.SetMemoryLimit(1)
+	if level := MemoryPressure(); level != PressureCritical {
+		t.Errorf("Expected critical pressure, got %v", level)
+	}
+
+	sm := New[int, int](10, WithPressureEviction[int, int](0, 1))
+	sm.Store(1, 1)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	WatchMemoryLimit(ctx, time.Millisecond, sm)
+
+	deadline := time.Now().Add(time.Second)
+	for sm.Len() != 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if sm.Len() != 0 {
+		t.Error("Expected the watcher to evict the entries")
+	}
+}
@@ -0,0 +1,65 @@
+package syncmap
+
+// Protocol buffers map fields, such as `map<string, Item> items = 1;`, are generated as Go maps
+// (map[string]*Item), so the helpers below bridge them without depending on a protobuf library.
+
+// FromProtoMap creates a SyncMap with the given options, holding the entries of the protobuf map field pm.
+// The pairs rejected by the map are skipped, or FromProtoMap panics if the map was created WithStrictMode.
+// As protobuf messages must not be copied, message values are shared by pm and the map, not cloned.
+func FromProtoMap[K comparable, V any](pm map[K]V, opts ...Option[K, V]) *SyncMap[K, V] {
+	m := New[K, V](len(pm), opts...)
+	m.MergeProtoMap(pm)
+	return m
+}
+
+// ToProtoMap returns a copy of the entries of the map, to be assigned to a protobuf map field.
+// It acquires a read lock to ensure thread-safe access to the underlying data.
+func (m *SyncMap[K, V]) ToProtoMap() map[K]V {
+	m.rlock()
+	defer m.runlock()
+
+	pm := make(map[K]V, len(m.data))
+	for k, v := range m.entries() {
+		pm[k] = v
+	}
+	return pm
+}
+
+// MergeProtoMap stores the entries of the protobuf map field pm into the map, under a single write lock,
+// e.g. to assemble the chunks of a map received from a stream, see StreamProtoMap.
+// The pairs rejected by the map are skipped, or MergeProtoMap panics if the map was created WithStrictMode.
+func (m *SyncMap[K, V]) MergeProtoMap(pm map[K]V) {
+	m.lock()
+	defer m.unlock()
+
+	for k, v := range pm {
+		m.check("MergeProtoMap", m.store(m.key(k), v))
+	}
+}
+
+// StreamProtoMap calls send with chunks of at most batch entries of the map, for maps too large to be sent
+// as a single protobuf message, e.g. on a gRPC stream; a batch of 1 sends the map entry by entry.
+// The chunks are built as with RangeStable: send is called without holding the lock and the map is not copied.
+// If send returns an error, StreamProtoMap stops and returns it. The chunks must not be retained
+// after send returns, as their maps are reused; a batch below 1 is treated as 1.
+func (m *SyncMap[K, V]) StreamProtoMap(batch int, send func(chunk map[K]V) error) error {
+	batch = max(batch, 1)
+	chunk := make(map[K]V, batch)
+
+	var err error
+	m.RangeStable(
+		func(k K, v V) bool {
+			chunk[k] = v
+			if len(chunk) < batch {
+				return true
+			}
+			err = send(chunk)
+			clear(chunk)
+			return err == nil
+		},
+	)
+	if err != nil || len(chunk) == 0 {
+		return err
+	}
+	return send(chunk)
+}
@@ -0,0 +1,94 @@
+package syncmap
+
+import (
+	"errors"
+	"maps"
+	"testing"
+)
+
+// protoItem stands in for a generated protobuf message, which is handled by pointer.
+type protoItem struct {
+	Name string
+}
+
+func TestSyncMapProto(t *testing.T) {
+	t.Run(
+		"RoundTrip", func(t *testing.T) {
+			pm := map[string]*protoItem{"a": {Name: "A"}, "b": {Name: "B"}}
+			sm := FromProtoMap(pm, WithMaxEntries[string, *protoItem](10))
+			if sm.Len() != 2 {
+				t.Errorf("Expected 2 entries, got %d", sm.Len())
+			}
+
+			out := sm.ToProtoMap()
+			if !maps.Equal(pm, out) {
+				t.Errorf("Expected the same messages, got %v", out)
+			}
+			delete(out, "a")
+			if sm.Len() != 2 {
+				t.Error("Expected ToProtoMap to return a copy")
+			}
+		},
+	)
+
+	t.Run(
+		"Stream", func(t *testing.T) {
+			sm := New[int, int](100)
+			for i := range 25 {
+				sm.Store(i, i*i)
+			}
+
+			for _, batch := range []int{0, 1, 10, 25, 100} {
+				received := New[int, int](0)
+				chunks := 0
+				err := sm.StreamProtoMap(
+					batch, func(chunk map[int]int) error {
+						if len(chunk) > max(batch, 1) {
+							t.Errorf("Expected at most %d entries per chunk, got %d", batch, len(chunk))
+						}
+						chunks++
+						received.MergeProtoMap(chunk)
+						return nil
+					},
+				)
+				if err != nil {
+					t.Fatalf("Expected no error, got %v", err)
+				}
+				if !Equal(sm, received) {
+					t.Errorf("Expected all the entries to be streamed with a batch of %d, got %d", batch, received.Len())
+				}
+				if expected := (25 + max(batch, 1) - 1) / max(batch, 1); chunks != expected {
+					t.Errorf("Expected %d chunks with a batch of %d, got %d", expected, batch, chunks)
+				}
+			}
+
+			if err := New[int, int](0).StreamProtoMap(10, func(map[int]int) error { return errors.New("unexpected") }); err != nil {
+				t.Errorf("Expected no chunk for an empty map, got %v", err)
+			}
+		},
+	)
+
+	t.Run(
+		"StreamError", func(t *testing.T) {
+			sm := New[int, int](100)
+			for i := range 25 {
+				sm.Store(i, i)
+			}
+
+			broken := errors.New("stream broken")
+			calls := 0
+			err := sm.StreamProtoMap(
+				5, func(map[int]int) error {
+					calls++
+					if calls == 2 {
+						return broken
+					}
+					return nil
+				},
+			)
+			if !errors.Is(err, broken) || calls != 2 {
+				t.Errorf("Expected the stream to stop at the error, got %d calls and %v", calls, err)
+			}
+		},
+	)
+}
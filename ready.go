@@ -0,0 +1,77 @@
+package syncmap
+
+import (
+	"context"
+)
+
+// closedChan is returned by Ready for maps without a ready gate.
+var closedChan = func() chan struct{} {
+	ch := make(chan struct{})
+	close(ch)
+	return ch
+}()
+
+// WithReadyGate creates the SyncMap in a not-ready state, which lasts until the initial hydration completes:
+// the first successful AutoRefresh or AutoRefreshDelta, the load of a snapshot (LoadFrom, LoadFromFile),
+// the replay of a journal (Replay), or an explicit call to MarkReady.
+// Readers can wait for hydration with Ready or WaitReady, or use TryLoad, which fails with ErrNotReady
+// until then, so that services do not serve empty results during boot.
+// Maps created without this option are ready immediately.
+func WithReadyGate[K comparable, V any]() Option[K, V] {
+	return func(m *SyncMap[K, V]) {
+		m.ready = make(chan struct{})
+	}
+}
+
+// Ready returns a channel that is closed once the SyncMap is ready.
+func (m *SyncMap[K, V]) Ready() <-chan struct{} {
+	if m.ready == nil {
+		return closedChan
+	}
+	return m.ready
+}
+
+// IsReady reports whether the SyncMap is ready.
+func (m *SyncMap[K, V]) IsReady() bool {
+	select {
+	case <-m.Ready():
+		return true
+	default:
+		return false
+	}
+}
+
+// WaitReady blocks until the SyncMap is ready or ctx is done, in which case it returns the context's error.
+func (m *SyncMap[K, V]) WaitReady(ctx context.Context) error {
+	select {
+	case <-m.Ready():
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// MarkReady marks the SyncMap as ready, releasing all waiters. Calling it more than once has no effect.
+func (m *SyncMap[K, V]) MarkReady() {
+	if m.ready == nil {
+		return
+	}
+
+	m.readyOnce.Do(
+		func() {
+			close(m.ready)
+		},
+	)
+}
+
+// TryLoad is like Load, but returns ErrNotReady while the SyncMap is not ready,
+// and the error of the loader if the map was created WithLoader and loading the key failed, as LoadCached does.
+// It acquires a read lock to ensure thread-safe access to the underlying data.
+func (m *SyncMap[K, V]) TryLoad(k K) (V, bool, error) {
+	if !m.IsReady() {
+		var zero V
+		return zero, false, ErrNotReady
+	}
+
+	return m.LoadCached(k)
+}
@@ -0,0 +1,71 @@
+package syncmap
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestReadyGate(t *testing.T) {
+	t.Run(
+		"Without gate", func(t *testing.T) {
+			sm := New[string, int](10)
+			if !sm.IsReady() {
+				t.Error("Map without a ready gate should be ready")
+			}
+			if err := sm.WaitReady(context.Background()); err != nil {
+				t.Errorf("Expected no error, got %v", err)
+			}
+		},
+	)
+
+	t.Run(
+		"MarkReady", func(t *testing.T) {
+			sm := New[string, int](10, WithReadyGate[string, int]())
+			sm.Store("key1", 1)
+
+			if _, _, err := sm.TryLoad("key1"); !errors.Is(err, ErrNotReady) {
+				t.Errorf("Expected ErrNotReady, got %v", err)
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+			defer cancel()
+			if err := sm.WaitReady(ctx); !errors.Is(err, context.DeadlineExceeded) {
+				t.Errorf("Expected deadline exceeded, got %v", err)
+			}
+
+			sm.MarkReady()
+			sm.MarkReady()
+
+			if v, ok, err := sm.TryLoad("key1"); err != nil || !ok || v != 1 {
+				t.Errorf("Expected 1, got %v, %v, %v", v, ok, err)
+			}
+		},
+	)
+
+	t.Run(
+		"AutoRefresh", func(t *testing.T) {
+			sm := New[string, int](10, WithReadyGate[string, int]())
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			sm.AutoRefresh(
+				ctx, time.Hour, func(ctx context.Context) (map[string]int, error) {
+					return map[string]int{"key1": 1}, nil
+				},
+			)
+
+			select {
+			case <-sm.Ready():
+			case <-time.After(5 * time.Second):
+				t.Fatal("Timed out waiting for the map to become ready")
+			}
+
+			if v, ok, err := sm.TryLoad("key1"); err != nil || !ok || v != 1 {
+				t.Errorf("Expected 1, got %v, %v, %v", v, ok, err)
+			}
+		},
+	)
+}
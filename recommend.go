@@ -0,0 +1,114 @@
+package syncmap
+
+import (
+	"runtime"
+)
+
+// Implementation is the set of operations shared by the maps of this package, as returned by Recommend.
+// The concrete type is *SyncMap or *ShardedMap: type-assert it to use their specific methods.
+type Implementation[K comparable, V any] interface {
+	Store(k K, v V)
+	TryStore(k K, v V) error
+	Load(k K) (V, bool)
+	Remove(k K) bool
+	LoadOrStore(key K, value V) (V, bool)
+	LoadAndDelete(key K) (V, bool)
+	Range(f func(key K, value V) bool)
+	Map(mapFn func(k K, v V) V) map[K]V
+	Filter(predicateFn func(k K, v V) bool) map[K]V
+	Purge()
+	Len() int
+	OnMemoryPressure(level PressureLevel) int
+}
+
+// to complain if a type does not implement the required methods
+var (
+	_ Implementation[any, any] = (*SyncMap[any, any])(nil)
+	_ Implementation[any, any] = (*ShardedMap[any, any])(nil)
+)
+
+// WorkloadProfile describes the expected use of a map, see Recommend.
+// Zero fields are treated as unknown.
+type WorkloadProfile struct {
+	// ReadRatio is the fraction of operations that are reads, between 0 and 1.
+	ReadRatio float64
+	// Goroutines is the number of goroutines using the map concurrently; 0 means GOMAXPROCS.
+	Goroutines int
+	// Keys is the expected number of distinct keys held by the map.
+	Keys int
+	// ValueSize is the typical size of a value in bytes.
+	ValueSize int
+	// MaxEntries bounds the map as a cache, see WithMaxEntries. 0 means unbounded.
+	MaxEntries int
+	// ScanHeavy reports that many keys are used only once, which makes LRU caches thrash.
+	ScanHeavy bool
+}
+
+// Thresholds of Recommend.
+const (
+	// from this read ratio, copying the map on every write pays for lock-free reads...
+	readMostlyRatio = 0.99
+	// ...as long as the copy is small
+	readMostlyMaxBytes = 1 << 20
+	// below this read ratio, many goroutines contend on the write lock
+	shardingReadRatio = 0.9
+	// from this number of goroutines, the write lock is contended
+	shardingGoroutines = 8
+	maxShards          = 256
+)
+
+// Recommend returns a new map suited to the workload described by profile:
+//   - a SyncMap bounded WithMaxEntries (with LFU eviction if the workload is scan-heavy) for caches;
+//   - a SyncMap WithReadMostly for small maps that are almost only read, as lock-free reads outweigh
+//     the cost of copying the map on writes;
+//   - a ShardedMap for maps written by many goroutines, with about 4 shards per goroutine,
+//     as the write lock of a single SyncMap would be contended;
+//   - otherwise, a plain SyncMap.
+//
+// The maps are sized for the expected number of keys.
+// The opts are applied after the options chosen by Recommend, so they can override them.
+func Recommend[K comparable, V any](profile WorkloadProfile, opts ...Option[K, V]) Implementation[K, V] {
+	goroutines := profile.Goroutines
+	if goroutines <= 0 {
+		goroutines = runtime.GOMAXPROCS(0)
+	}
+	contended := goroutines >= shardingGoroutines && profile.ReadRatio < shardingReadRatio
+
+	var tuned []Option[K, V]
+	size := profile.Keys
+	shards := 1
+	switch {
+	case profile.MaxEntries > 0:
+		size = min(size, profile.MaxEntries)
+		if profile.ScanHeavy {
+			tuned = append(tuned, WithEvictionPolicy[K, V](EvictLFU))
+		}
+		if contended {
+			// the bound applies per shard: round it up so that the map holds at least MaxEntries
+			shards = shardCount(goroutines)
+			tuned = append(tuned, WithMaxEntries[K, V]((profile.MaxEntries+shards-1)/shards))
+		} else {
+			tuned = append(tuned, WithMaxEntries[K, V](profile.MaxEntries))
+		}
+	case profile.ReadRatio >= readMostlyRatio && profile.Keys*max(profile.ValueSize, 1) <= readMostlyMaxBytes:
+		tuned = append(tuned, WithReadMostly[K, V]())
+	case contended:
+		shards = shardCount(goroutines)
+	}
+
+	opts = append(tuned, opts...)
+	if shards > 1 {
+		return NewSharded[K, V](shards, size/shards, opts...)
+	}
+	return New[K, V](size, opts...)
+}
+
+// shardCount returns the number of shards for the given number of goroutines:
+// the power of two closest above 4 per goroutine, so that two goroutines rarely hit the same shard.
+func shardCount(goroutines int) int {
+	shards := 1
+	for shards < 4*goroutines && shards < maxShards {
+		shards *= 2
+	}
+	return shards
+}
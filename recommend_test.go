@@ -0,0 +1,117 @@
+package syncmap
+
+import (
+	"fmt"
+	"sync/atomic"
+	"testing"
+)
+
+func TestRecommend(t *testing.T) {
+	t.Run(
+		"Cache", func(t *testing.T) {
+			m := Recommend[string, int](WorkloadProfile{Goroutines: 1, Keys: 1000, MaxEntries: 100, ScanHeavy: true})
+			sm, ok := m.(*SyncMap[string, int])
+			if !ok {
+				t.Fatalf("Expected a SyncMap, got %T", m)
+			}
+			if sm.maxEntries != 100 {
+				t.Errorf("Expected at most 100 entries, got %d", sm.maxEntries)
+			}
+			if _, ok := sm.policy.(*lfuPolicy[string]); !ok {
+				t.Errorf("Expected LFU eviction, got %T", sm.policy)
+			}
+		},
+	)
+
+	t.Run(
+		"ShardedCache", func(t *testing.T) {
+			m := Recommend[int, int](WorkloadProfile{Goroutines: 16, ReadRatio: 0.5, MaxEntries: 1000})
+			sharded, ok := m.(*ShardedMap[int, int])
+			if !ok {
+				t.Fatalf("Expected a ShardedMap, got %T", m)
+			}
+			if n := sharded.Shards() * sharded.shards[0].maxEntries; n < 1000 {
+				t.Errorf("Expected room for at least 1000 entries, got %d", n)
+			}
+		},
+	)
+
+	t.Run(
+		"ReadMostly", func(t *testing.T) {
+			m := Recommend[string, int](WorkloadProfile{ReadRatio: 0.999, Keys: 100, ValueSize: 8})
+			if sm, ok := m.(*SyncMap[string, int]); !ok || !sm.readMostly {
+				t.Errorf("Expected a read-mostly SyncMap, got %T", m)
+			}
+
+			// too large to be copied on every write
+			m = Recommend[string, int](WorkloadProfile{ReadRatio: 0.999, Goroutines: 1, Keys: 1 << 20, ValueSize: 8})
+			if sm, ok := m.(*SyncMap[string, int]); !ok || sm.readMostly {
+				t.Errorf("Expected a plain SyncMap, got %T", m)
+			}
+		},
+	)
+
+	t.Run(
+		"Sharded", func(t *testing.T) {
+			m := Recommend[string, int](WorkloadProfile{ReadRatio: 0.5, Goroutines: 16})
+			sharded, ok := m.(*ShardedMap[string, int])
+			if !ok {
+				t.Fatalf("Expected a ShardedMap, got %T", m)
+			}
+			if sharded.Shards() != 64 {
+				t.Errorf("Expected 64 shards, got %d", sharded.Shards())
+			}
+		},
+	)
+
+	t.Run(
+		"Plain", func(t *testing.T) {
+			m := Recommend[string, int](WorkloadProfile{ReadRatio: 0.5, Goroutines: 2}, WithName[string, int]("plain"))
+			sm, ok := m.(*SyncMap[string, int])
+			if !ok {
+				t.Fatalf("Expected a SyncMap, got %T", m)
+			}
+			if sm.Name() != "plain" {
+				t.Errorf("Expected the given options to be applied, got name %q", sm.Name())
+			}
+		},
+	)
+}
+
+// BenchmarkImplementations compares the implementations chosen by Recommend under mixed workloads.
+func BenchmarkImplementations(b *testing.B) {
+	implementations := map[string]func() Implementation[int, int]{
+		"Plain":      func() Implementation[int, int] { return New[int, int](1024) },
+		"ReadMostly": func() Implementation[int, int] { return New[int, int](1024, WithReadMostly[int, int]()) },
+		"Sharded":    func() Implementation[int, int] { return NewSharded[int, int](64, 16) },
+		"Cache":      func() Implementation[int, int] { return New[int, int](1024, WithMaxEntries[int, int](512)) },
+	}
+
+	for _, readRatio := range []int{50, 90, 99} {
+		for name, newMap := range implementations {
+			b.Run(
+				fmt.Sprintf("%s/Reads%d", name, readRatio), func(b *testing.B) {
+					m := newMap()
+					for i := range 1024 {
+						m.Store(i, i)
+					}
+
+					var seq atomic.Int64
+					b.RunParallel(
+						func(pb *testing.PB) {
+							i := int(seq.Add(1)) * 7919
+							for pb.Next() {
+								i++
+								if i%100 < readRatio {
+									m.Load(i % 1024)
+								} else {
+									m.Store(i%1024, i)
+								}
+							}
+						},
+					)
+				},
+			)
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package syncmap
+
+import (
+	"context"
+	"math/rand/v2"
+	"time"
+)
+
+// RefreshOption configures AutoRefresh.
+type RefreshOption func(c *refreshConfig)
+
+type refreshConfig struct {
+	jitter  float64
+	onError func(err error)
+}
+
+// WithRefreshJitter randomizes each refresh interval by up to ±fraction of its length
+// (for example, 0.1 for ±10%), so that many processes polling the same source do not synchronize.
+func WithRefreshJitter(fraction float64) RefreshOption {
+	return func(c *refreshConfig) {
+		c.jitter = fraction
+	}
+}
+
+// WithRefreshErrorHandler sets a function called with every error returned by the fetch function.
+func WithRefreshErrorHandler(onError func(err error)) RefreshOption {
+	return func(c *refreshConfig) {
+		c.onError = onError
+	}
+}
+
+// Delta is an incremental change set returned by the fetch function of AutoRefreshDelta.
+type Delta[K comparable, V any] struct {
+	// Upserts are stored in the map, replacing existing values.
+	Upserts map[K]V
+	// Deletes are removed from the map. Deletes are applied before Upserts.
+	Deletes []K
+	// Reset replaces the whole contents of the map with Upserts,
+	// e.g. for the initial load or when the source no longer knows the cursor.
+	Reset bool
+	// Cursor identifies the state of the source after this delta.
+	// It is passed to the next call of the fetch function.
+	Cursor string
+}
+
+// AutoRefresh starts a background goroutine that periodically rebuilds the contents of the SyncMap
+// from fetch, replacing them atomically with ReplaceAll.
+// The first fetch happens immediately, the following ones every interval (adjusted by the jitter, if any).
+// When fetch returns an error, the current contents are kept and the error is reported
+// to the error handler, if one is configured.
+// The goroutine stops when ctx is done; ctx is also passed to fetch.
+// The first successful refresh marks the map as ready (see WithReadyGate).
+func (m *SyncMap[K, V]) AutoRefresh(
+	ctx context.Context, interval time.Duration, fetch func(ctx context.Context) (map[K]V, error),
+	opts ...RefreshOption,
+) {
+	runRefresh(
+		ctx, interval, opts, func() error {
+			data, err := fetch(ctx)
+			if err != nil || ctx.Err() != nil {
+				return err
+			}
+
+			m.ReplaceAll(data)
+			m.MarkReady()
+			return nil
+		},
+	)
+}
+
+// AutoRefreshDelta is like AutoRefresh, but fetch returns incremental changes since the given cursor
+// instead of the full contents, so that large datasets don't need to be reloaded every interval.
+// The first call receives an empty cursor, every following call the Cursor of the last applied Delta.
+// Each Delta is applied atomically with ApplyDelta. On error, the delta is discarded
+// and the same cursor is used on the next attempt.
+func (m *SyncMap[K, V]) AutoRefreshDelta(
+	ctx context.Context, interval time.Duration,
+	fetch func(ctx context.Context, cursor string) (Delta[K, V], error),
+	opts ...RefreshOption,
+) {
+	cursor := ""
+
+	runRefresh(
+		ctx, interval, opts, func() error {
+			delta, err := fetch(ctx, cursor)
+			if err != nil || ctx.Err() != nil {
+				return err
+			}
+
+			m.ApplyDelta(delta)
+			m.MarkReady()
+			cursor = delta.Cursor
+			return nil
+		},
+	)
+}
+
+// ApplyDelta atomically applies the changes described by d:
+// with d.Reset the contents are replaced by d.Upserts, as with ReplaceAll, otherwise d.Deletes are removed
+// and then d.Upserts are stored.
+// It acquires a write lock to ensure thread-safe access to the underlying data.
+func (m *SyncMap[K, V]) ApplyDelta(d Delta[K, V]) {
+	m.lock()
+	defer m.unlock()
+
+	if d.Reset {
+		m.check("ApplyDelta", m.replace(d.Upserts))
+		return
+	}
+	for _, k := range d.Deletes {
+		m.drop(m.key(k))
+	}
+	for k, v := range d.Upserts {
+		m.check("ApplyDelta", m.store(m.key(k), v))
+	}
+}
+
+// runRefresh calls step immediately and then every interval until ctx is done.
+func runRefresh(ctx context.Context, interval time.Duration, opts []RefreshOption, step func() error) {
+	var cfg refreshConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	go func() {
+		for {
+			if err := step(); err != nil && cfg.onError != nil && ctx.Err() == nil {
+				cfg.onError(err)
+			}
+
+			timer := time.NewTimer(cfg.interval(interval))
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			case <-timer.C:
+			}
+		}
+	}()
+}
+
+// interval returns the base interval randomized by the configured jitter.
+func (c *refreshConfig) interval(base time.Duration) time.Duration {
+	if c.jitter <= 0 {
+		return base
+	}
+
+	delta := time.Duration((rand.Float64()*2 - 1) * c.jitter * float64(base))
+	if base+delta <= 0 {
+		return base
+	}
+	return base + delta
+}
@@ -0,0 +1,162 @@
+package syncmap
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestReplaceAll(t *testing.T) {
+	sm := New[string, int](10)
+	sm.Store("key1", 1)
+	sm.Store("key2", 2)
+
+	sm.ReplaceAll(map[string]int{"key2": 20, "key3": 30})
+
+	expected := map[string]int{"key2": 20, "key3": 30}
+	if actual := sm.Map(
+		func(k string, v int) int {
+			return v
+		},
+	); !maps.Equal(actual, expected) {
+		t.Errorf("Expected %v, got %v", expected, actual)
+	}
+}
+
+func TestAutoRefresh(t *testing.T) {
+	sm := New[string, int](10)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var calls atomic.Int64
+	errs := make(chan error, 10)
+	fetchErr := errors.New("source unavailable")
+
+	sm.AutoRefresh(
+		ctx, 5*time.Millisecond, func(ctx context.Context) (map[string]int, error) {
+			n := calls.Add(1)
+			if n == 2 {
+				return nil, fetchErr
+			}
+			return map[string]int{"calls": int(n)}, nil
+		},
+		WithRefreshJitter(0.5),
+		WithRefreshErrorHandler(
+			func(err error) {
+				errs <- err
+			},
+		),
+	)
+
+	deadline := time.After(5 * time.Second)
+	for {
+		if v, ok := sm.Load("calls"); ok && v >= 3 {
+			break
+		}
+		select {
+		case <-deadline:
+			t.Fatal("Timed out waiting for refreshes")
+		case <-time.After(time.Millisecond):
+		}
+	}
+
+	select {
+	case err := <-errs:
+		if !errors.Is(err, fetchErr) {
+			t.Errorf("Expected fetch error, got %v", err)
+		}
+	default:
+		t.Error("Expected the fetch error to be reported")
+	}
+
+	cancel()
+	time.Sleep(20 * time.Millisecond)
+	stopped := calls.Load()
+	time.Sleep(20 * time.Millisecond)
+	if calls.Load() != stopped {
+		t.Error("Expected refreshes to stop after the context is cancelled")
+	}
+}
+
+func TestApplyDelta(t *testing.T) {
+	sm := New[string, int](10)
+	sm.Store("key1", 1)
+	sm.Store("key2", 2)
+
+	sm.ApplyDelta(
+		Delta[string, int]{
+			Upserts: map[string]int{"key2": 20, "key3": 30},
+			Deletes: []string{"key1"},
+		},
+	)
+
+	expected := map[string]int{"key2": 20, "key3": 30}
+	if actual := sm.Filter(
+		func(k string, v int) bool {
+			return true
+		},
+	); !maps.Equal(actual, expected) {
+		t.Errorf("Expected %v, got %v", expected, actual)
+	}
+
+	sm.ApplyDelta(Delta[string, int]{Upserts: map[string]int{"key4": 4}, Reset: true})
+
+	expected = map[string]int{"key4": 4}
+	if actual := sm.Filter(
+		func(k string, v int) bool {
+			return true
+		},
+	); !maps.Equal(actual, expected) {
+		t.Errorf("Expected %v, got %v", expected, actual)
+	}
+}
+
+func TestAutoRefreshDelta(t *testing.T) {
+	sm := New[string, int](10)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	cursors := make(chan string, 100)
+
+	sm.AutoRefreshDelta(
+		ctx, time.Millisecond, func(ctx context.Context, cursor string) (Delta[string, int], error) {
+			cursors <- cursor
+			switch cursor {
+			case "":
+				return Delta[string, int]{Upserts: map[string]int{"key1": 1, "key2": 2}, Reset: true, Cursor: "c1"}, nil
+			case "c1":
+				return Delta[string, int]{Deletes: []string{"key1"}, Cursor: "c2"}, nil
+			case "c2":
+				return Delta[string, int]{}, errors.New("temporary failure")
+			default:
+				return Delta[string, int]{Cursor: cursor}, nil
+			}
+		},
+	)
+
+	expected := []string{"", "c1", "c2", "c2"}
+	for i, want := range expected {
+		select {
+		case got := <-cursors:
+			if got != want {
+				t.Fatalf("Call %d: expected cursor %q, got %q", i, want, got)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("Timed out waiting for refreshes")
+		}
+	}
+
+	cancel()
+
+	if _, ok := sm.Load("key1"); ok {
+		t.Error("key1 should have been deleted by the delta")
+	}
+	if v, ok := sm.Load("key2"); !ok || v != 2 {
+		t.Errorf("Expected 2, got %v", v)
+	}
+}
@@ -0,0 +1,193 @@
+package syncmap
+
+import (
+	"hash/maphash"
+	"maps"
+)
+
+// ShardedMap is a thread-safe map that partitions its keys across several SyncMaps (shards),
+// each with its own lock, to cut contention between many concurrent writers.
+// Operations on a single key only lock the shard owning it. Operations over the whole map
+// (Range, Len, Map, Filter, Purge) visit the shards one after another, locking each in turn,
+// so unlike their SyncMap counterparts they do not observe a single point-in-time view of the map.
+//
+// Type parameters:
+//
+//	K: must be a comparable type (used as map keys)
+//	V: can be any type (used as map values)
+type ShardedMap[K comparable, V any] struct {
+	seed   maphash.Seed
+	shards []*SyncMap[K, V]
+}
+
+// NewSharded creates and returns a new ShardedMap with the given number of shards,
+// each created by New with the given initial size and options.
+// The options apply to every shard separately: for example, a soft limit is a limit per shard.
+// If shards is less than 1, a single shard is used.
+func NewSharded[K comparable, V any](shards, size int, opts ...Option[K, V]) *ShardedMap[K, V] {
+	if shards < 1 {
+		shards = 1
+	}
+
+	m := &ShardedMap[K, V]{
+		seed:   maphash.MakeSeed(),
+		shards: make([]*SyncMap[K, V], shards),
+	}
+	for i := range m.shards {
+		m.shards[i] = New[K, V](size, opts...)
+	}
+
+	return m
+}
+
+// Shard returns the shard owning the key, e.g. to run a critical section over it with DoLocked.
+// The critical section only covers keys of the same shard.
+func (m *ShardedMap[K, V]) Shard(k K) *SyncMap[K, V] {
+	// keys are hashed in their canonical form, so that keys that are equal
+	// according to WithKeyFunc are owned by the same shard
+	k = m.shards[0].key(k)
+	return m.shards[maphash.Comparable(m.seed, k)%uint64(len(m.shards))]
+}
+
+// Shards returns the number of shards of the ShardedMap.
+func (m *ShardedMap[K, V]) Shards() int {
+	return len(m.shards)
+}
+
+// Store sets the value for a key.
+// It acquires the write lock of the shard owning the key.
+func (m *ShardedMap[K, V]) Store(k K, v V) {
+	m.Shard(k).Store(k, v)
+}
+
+// TryStore is like Store, but returns an error when the map rejects the pair.
+// It acquires the write lock of the shard owning the key.
+func (m *ShardedMap[K, V]) TryStore(k K, v V) error {
+	return m.Shard(k).TryStore(k, v)
+}
+
+// Load retrieves the value associated with the given key.
+// It acquires the read lock of the shard owning the key.
+func (m *ShardedMap[K, V]) Load(k K) (V, bool) {
+	return m.Shard(k).Load(k)
+}
+
+// Remove deletes the value associated with the given key.
+// It acquires the write lock of the shard owning the key.
+func (m *ShardedMap[K, V]) Remove(k K) bool {
+	return m.Shard(k).Remove(k)
+}
+
+// LoadOrStore returns the existing value for the key if present.
+// Otherwise, it stores and returns the given value.
+// The loaded result is true if the value was loaded, false if stored.
+// It acquires the write lock of the shard owning the key.
+func (m *ShardedMap[K, V]) LoadOrStore(key K, value V) (V, bool) {
+	return m.Shard(key).LoadOrStore(key, value)
+}
+
+// LoadAndDelete deletes the value for a key, returning the previous value if any.
+// The loaded result reports whether the key was present.
+// It acquires the write lock of the shard owning the key.
+func (m *ShardedMap[K, V]) LoadAndDelete(key K) (V, bool) {
+	return m.Shard(key).LoadAndDelete(key)
+}
+
+// Range calls f sequentially for each key and value present in the map.
+// If f returns false, Range stops the iteration.
+// It acquires the read lock of each shard in turn.
+func (m *ShardedMap[K, V]) Range(f func(key K, value V) bool) {
+	for _, shard := range m.shards {
+		stopped := false
+		shard.Range(
+			func(key K, value V) bool {
+				if !f(key, value) {
+					stopped = true
+				}
+				return !stopped
+			},
+		)
+		if stopped {
+			return
+		}
+	}
+}
+
+// Map applies a given function to all key-value pairs in the map and returns a new map with the results.
+// It acquires the read lock of each shard in turn.
+func (m *ShardedMap[K, V]) Map(mapFn func(k K, v V) V) map[K]V {
+	data := make(map[K]V)
+	for _, shard := range m.shards {
+		maps.Copy(data, shard.Map(mapFn))
+	}
+	return data
+}
+
+// Filter creates a new map containing key-value pairs from the map that satisfy the given predicate function.
+// It acquires the read lock of each shard in turn.
+func (m *ShardedMap[K, V]) Filter(predicateFn func(k K, v V) bool) map[K]V {
+	data := make(map[K]V)
+	for _, shard := range m.shards {
+		maps.Copy(data, shard.Filter(predicateFn))
+	}
+	return data
+}
+
+// Purge removes all key-value pairs from the map.
+// It acquires the write lock of each shard in turn.
+func (m *ShardedMap[K, V]) Purge() {
+	for _, shard := range m.shards {
+		shard.Purge()
+	}
+}
+
+// Len returns the number of items in the map, summing the counts of the shards.
+// It does not acquire any lock (see SyncMap.Len), so while shards are being written concurrently,
+// the total may not match the contents of the map at any single point in time.
+func (m *ShardedMap[K, V]) Len() int {
+	n := 0
+	for _, shard := range m.shards {
+		n += shard.Len()
+	}
+	return n
+}
+
+// ShardStat is the load of one shard of a ShardedMap.
+type ShardStat struct {
+	Entries int
+	// LoadFactor is Entries relative to a perfectly even distribution of the keys over the shards:
+	// 1 is an average shard, 2 holds twice as many entries. It is 0 when the map is empty.
+	LoadFactor float64
+}
+
+// ShardStats reports how the keys of a ShardedMap are distributed over its shards.
+type ShardStats struct {
+	Shards []ShardStat
+	// Total is the number of entries of the map.
+	Total int
+	// MaxLoadFactor is the highest LoadFactor of all shards: a value well above 1
+	// means that the keys are skewed towards some shards.
+	MaxLoadFactor float64
+}
+
+// Stats returns the number of entries and load factor of every shard, so that hash skew can be detected.
+// The counts are read without locking and may not add up to a consistent total under concurrent writes.
+func (m *ShardedMap[K, V]) Stats() ShardStats {
+	stats := ShardStats{Shards: make([]ShardStat, len(m.shards))}
+	for i, shard := range m.shards {
+		stats.Shards[i].Entries = shard.Len()
+		stats.Total += stats.Shards[i].Entries
+	}
+
+	if stats.Total == 0 {
+		return stats
+	}
+
+	mean := float64(stats.Total) / float64(len(m.shards))
+	for i := range stats.Shards {
+		stats.Shards[i].LoadFactor = float64(stats.Shards[i].Entries) / mean
+		stats.MaxLoadFactor = max(stats.MaxLoadFactor, stats.Shards[i].LoadFactor)
+	}
+
+	return stats
+}
@@ -0,0 +1,190 @@
+package syncmap
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestShardedMap(t *testing.T) {
+	sm := NewSharded[string, int](8, 10)
+	for i := 0; i < 100; i++ {
+		sm.Store(fmt.Sprint(i), i)
+	}
+
+	t.Run(
+		"LoadStoreRemove", func(t *testing.T) {
+			if v, ok := sm.Load("42"); !ok || v != 42 {
+				t.Errorf("Expected (42, true), got (%v, %v)", v, ok)
+			}
+			if v, loaded := sm.LoadOrStore("42", 0); !loaded || v != 42 {
+				t.Errorf("Expected (42, true), got (%v, %v)", v, loaded)
+			}
+			if v, loaded := sm.LoadAndDelete("42"); !loaded || v != 42 {
+				t.Errorf("Expected (42, true), got (%v, %v)", v, loaded)
+			}
+			if sm.Remove("42") {
+				t.Error("Remove should return false for a removed key")
+			}
+			if v, loaded := sm.LoadOrStore("42", 42); loaded || v != 42 {
+				t.Errorf("Expected (42, false), got (%v, %v)", v, loaded)
+			}
+		},
+	)
+
+	t.Run(
+		"Distribution", func(t *testing.T) {
+			if sm.Shards() != 8 {
+				t.Errorf("Expected 8 shards, got %d", sm.Shards())
+			}
+			used := 0
+			for _, shard := range sm.shards {
+				if shard.Len() > 0 {
+					used++
+				}
+			}
+			if used < 2 {
+				t.Errorf("Expected keys to be spread over several shards, got %d", used)
+			}
+		},
+	)
+
+	t.Run(
+		"WholeMap", func(t *testing.T) {
+			if sm.Len() != 100 {
+				t.Errorf("Expected 100, got %d", sm.Len())
+			}
+
+			sum := 0
+			sm.Range(
+				func(key string, value int) bool {
+					sum += value
+					return true
+				},
+			)
+			if sum != 4950 {
+				t.Errorf("Expected 4950, got %d", sum)
+			}
+
+			visited := 0
+			sm.Range(
+				func(key string, value int) bool {
+					visited++
+					return visited < 10
+				},
+			)
+			if visited != 10 {
+				t.Errorf("Expected Range to stop after 10 entries, got %d", visited)
+			}
+
+			even := sm.Filter(
+				func(k string, v int) bool {
+					return v%2 == 0
+				},
+			)
+			if len(even) != 50 {
+				t.Errorf("Expected 50 even values, got %d", len(even))
+			}
+
+			doubled := sm.Map(
+				func(k string, v int) int {
+					return v * 2
+				},
+			)
+			if len(doubled) != 100 || doubled["10"] != 20 {
+				t.Errorf("Unexpected mapped values %v", doubled)
+			}
+
+			sm.Purge()
+			if sm.Len() != 0 {
+				t.Errorf("Expected 0 after Purge, got %d", sm.Len())
+			}
+		},
+	)
+
+	t.Run(
+		"KeyFunc", func(t *testing.T) {
+			sm := NewSharded[string, int](16, 10, WithKeyFunc[string, int](strings.ToLower))
+			for i := 0; i < 20; i++ {
+				sm.Store(fmt.Sprintf("KEY%d", i), i)
+			}
+			for i := 0; i < 20; i++ {
+				if v, ok := sm.Load(fmt.Sprintf("key%d", i)); !ok || v != i {
+					t.Errorf("Expected (%d, true), got (%v, %v)", i, v, ok)
+				}
+			}
+		},
+	)
+
+	t.Run(
+		"Concurrent", func(t *testing.T) {
+			sm := NewSharded[int, int](4, 10)
+			var wg sync.WaitGroup
+			for w := 0; w < 32; w++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					for i := 0; i < 100; i++ {
+						sm.Shard(i).DoLocked(
+							func(m LockedMap[int, int]) {
+								v, _ := m.Load(i)
+								m.Store(i, v+1)
+							},
+						)
+					}
+				}()
+			}
+			wg.Wait()
+
+			expected := make(map[int]int)
+			for i := 0; i < 100; i++ {
+				expected[i] = 32
+			}
+			got := sm.Filter(
+				func(k, v int) bool {
+					return true
+				},
+			)
+			if !maps.Equal(got, expected) {
+				t.Errorf("Unexpected counters %v", slices.Sorted(maps.Values(got)))
+			}
+		},
+	)
+}
+
+func TestShardedMapStats(t *testing.T) {
+	sm := NewSharded[int, int](4, 10)
+	if stats := sm.Stats(); stats.Total != 0 || stats.MaxLoadFactor != 0 || len(stats.Shards) != 4 {
+		t.Errorf("Unexpected stats of an empty map %+v", stats)
+	}
+
+	for i := 0; i < 1000; i++ {
+		sm.Store(i, i)
+	}
+	stats := sm.Stats()
+	if stats.Total != 1000 {
+		t.Errorf("Expected a total of 1000, got %d", stats.Total)
+	}
+
+	entries := 0
+	loadFactors := 0.0
+	for _, shard := range stats.Shards {
+		entries += shard.Entries
+		loadFactors += shard.LoadFactor
+		if shard.LoadFactor > stats.MaxLoadFactor {
+			t.Errorf("Load factor %v exceeds the maximum %v", shard.LoadFactor, stats.MaxLoadFactor)
+		}
+	}
+	if entries != 1000 {
+		t.Errorf("Expected the shards to hold 1000 entries, got %d", entries)
+	}
+	if loadFactors < 3.99 || loadFactors > 4.01 {
+		t.Errorf("Expected the load factors to average 1, got a sum of %v", loadFactors)
+	}
+	if stats.MaxLoadFactor < 1 || stats.MaxLoadFactor > 1.5 {
+		t.Errorf("Expected an even distribution, got a maximum load factor of %v", stats.MaxLoadFactor)
+	}
+}
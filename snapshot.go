@@ -0,0 +1,129 @@
+package syncmap
+
+import (
+	"context"
+	"io"
+	"maps"
+	"time"
+)
+
+// SnapshotOption configures AutoSnapshot.
+type SnapshotOption func(c *snapshotConfig)
+
+type snapshotConfig struct {
+	onSave  func(version uint64)
+	onError func(err error)
+}
+
+// WithSnapshotHandler sets a function called after every snapshot saved by AutoSnapshot,
+// with the version of the map it holds (see Version).
+func WithSnapshotHandler(onSave func(version uint64)) SnapshotOption {
+	return func(c *snapshotConfig) {
+		c.onSave = onSave
+	}
+}
+
+// WithSnapshotErrorHandler sets a function called with every error of AutoSnapshot,
+// from encoding the map or from the sink.
+func WithSnapshotErrorHandler(onError func(err error)) SnapshotOption {
+	return func(c *snapshotConfig) {
+		c.onError = onError
+	}
+}
+
+// AutoSnapshot starts a background goroutine that saves a snapshot of the map every interval,
+// if it has changed since the last snapshot (or since AutoSnapshot was called, for the first one).
+// Each snapshot is encoded with codec, as by MarshalWith, and saved by sink. Rather than being handed an
+// io.Writer, sink is handed write, which writes the snapshot to the io.Writer it is given: sink opens the
+// destination of the snapshot, calls write with it, then commits it, or discards it if write failed.
+// This way, sink controls both ends of the write, which a plain io.Writer would not allow, so that it can
+// store the snapshot atomically, as AutoSnapshotToFile does, or in a single upload, e.g.:
+//
+//	sink := func(write func(w io.Writer) error) error {
+//		var buf bytes.Buffer
+//		if err := write(&buf); err != nil {
+//			return err
+//		}
+//		return upload(buf.Bytes())
+//	}
+//
+// The entries and the version of the map are read under the same read lock,
+// so that a snapshot never misses a change made concurrently, by Purge for instance.
+// When sink returns an error, the error is reported to the error handler, if one is configured,
+// and the snapshot is attempted again at the next interval.
+//
+// When ctx is done, a last snapshot is saved if the map has changed, then the goroutine stops
+// and the returned channel is closed: wait for it before exiting, so that the last changes are not lost.
+func (m *SyncMap[K, V]) AutoSnapshot(
+	ctx context.Context, interval time.Duration, codec Codec, sink func(write func(w io.Writer) error) error,
+	opts ...SnapshotOption,
+) <-chan struct{} {
+	var cfg snapshotConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	saved := m.Version()
+	save := func() {
+		m.rlock()
+		version := m.version.Load()
+		if version == saved {
+			m.runlock()
+			return
+		}
+		data := maps.Collect(m.entries())
+		m.runlock()
+
+		b, err := codec.Encode(data)
+		if err == nil {
+			err = sink(
+				func(w io.Writer) error {
+					_, err := w.Write(b)
+					return err
+				},
+			)
+		}
+		if err != nil {
+			if cfg.onError != nil {
+				cfg.onError(err)
+			}
+			return
+		}
+
+		saved = version
+		if cfg.onSave != nil {
+			cfg.onSave(version)
+		}
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				save()
+			case <-ctx.Done():
+				save()
+				return
+			}
+		}
+	}()
+	return done
+}
+
+// AutoSnapshotToFile is like AutoSnapshot, but saves each snapshot to the file at path,
+// atomically, as SaveToFile does. The map can be restored with LoadFromFile after a restart.
+func (m *SyncMap[K, V]) AutoSnapshotToFile(
+	ctx context.Context, interval time.Duration, path string, codec Codec, opts ...SnapshotOption,
+) <-chan struct{} {
+	return m.AutoSnapshot(
+		ctx, interval, codec, func(write func(w io.Writer) error) error {
+			return writeFile(path, write)
+		},
+		opts...,
+	)
+}
@@ -0,0 +1,134 @@
+package syncmap
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/antst/go-syncmap/codec/msgpack"
+)
+
+func TestAutoSnapshot(t *testing.T) {
+	t.Run(
+		"OnlyWhenChanged", func(t *testing.T) {
+			sm := New[string, int](10)
+			sm.Store("a", 1)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			snapshots := make(chan map[string]int, 10)
+			done := sm.AutoSnapshot(
+				ctx, time.Millisecond, msgpack.Codec{}, func(write func(w io.Writer) error) error {
+					var b bytes.Buffer
+					if err := write(&b); err != nil {
+						return err
+					}
+					var data map[string]int
+					if err := msgpack.Unmarshal(b.Bytes(), &data); err != nil {
+						return err
+					}
+					snapshots <- data
+					return nil
+				},
+			)
+
+			time.Sleep(10 * time.Millisecond)
+			if len(snapshots) != 0 {
+				t.Errorf("Expected no snapshot of an unchanged map, got %d", len(snapshots))
+			}
+
+			sm.Store("b", 2)
+			select {
+			case data := <-snapshots:
+				if len(data) != 2 || data["b"] != 2 {
+					t.Errorf("Expected a snapshot with a and b, got %v", data)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("Expected a snapshot after a change")
+			}
+			time.Sleep(10 * time.Millisecond)
+			if len(snapshots) != 0 {
+				t.Errorf("Expected a single snapshot per change, got %d more", len(snapshots))
+			}
+
+			// the last change is saved when ctx is done
+			sm.Purge()
+			cancel()
+			<-done
+			var last map[string]int
+			for len(snapshots) > 0 {
+				last = <-snapshots
+			}
+			if last == nil || len(last) != 0 {
+				t.Errorf("Expected a last snapshot of the purged map, got %v", last)
+			}
+		},
+	)
+
+	t.Run(
+		"Handlers", func(t *testing.T) {
+			sm := New[string, int](10)
+			sinkErr := errors.New("bucket unavailable")
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			errs := make(chan error, 10)
+			saved := make(chan uint64, 10)
+			fail := true
+			sm.AutoSnapshot(
+				ctx, time.Millisecond, msgpack.Codec{}, func(func(w io.Writer) error) error {
+					if fail {
+						fail = false
+						return sinkErr
+					}
+					return nil
+				},
+				WithSnapshotErrorHandler(func(err error) { errs <- err }),
+				WithSnapshotHandler(func(version uint64) { saved <- version }),
+			)
+			sm.Store("a", 1)
+
+			select {
+			case err := <-errs:
+				if !errors.Is(err, sinkErr) {
+					t.Errorf("Expected %v, got %v", sinkErr, err)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("Expected the error to be reported")
+			}
+			select {
+			case version := <-saved:
+				if version != sm.Version() {
+					t.Errorf("Expected version %d, got %d", sm.Version(), version)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("Expected the snapshot to be retried")
+			}
+		},
+	)
+
+	t.Run(
+		"File", func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "map.snapshot")
+			sm := New[string, int](10)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			done := sm.AutoSnapshotToFile(ctx, time.Hour, path, msgpack.Codec{})
+			sm.Store("a", 1)
+			sm.Store("b", 2)
+			cancel()
+			<-done
+
+			restored := New[string, int](10)
+			if err := restored.LoadFromFile(path, msgpack.Codec{}); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if !Equal(sm, restored) {
+				t.Errorf("Expected the snapshot to hold the contents of the map, got %d entries", restored.Len())
+			}
+		},
+	)
+}
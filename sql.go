@@ -0,0 +1,42 @@
+package syncmap
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+)
+
+// to complain if SyncMap does not implement the interfaces
+var (
+	_ driver.Valuer = (*SyncMap[string, any])(nil)
+	_ sql.Scanner   = (*SyncMap[string, any])(nil)
+)
+
+// Value implements driver.Valuer, so that a map can be written to a JSON or text column through database/sql:
+// it returns the JSON encoding of the map, see MarshalJSON. A nil *SyncMap is written as NULL.
+func (m *SyncMap[K, V]) Value() (driver.Value, error) {
+	if m == nil {
+		return nil, nil
+	}
+	return m.MarshalJSON()
+}
+
+// Scan implements sql.Scanner, so that a map can be read from a JSON or text column through database/sql:
+// it replaces the contents of the map with the entries of the JSON object read, see UnmarshalJSON.
+// A NULL column empties the map. It returns ErrNilMap when called on a nil *SyncMap.
+func (m *SyncMap[K, V]) Scan(src any) error {
+	if m == nil {
+		return ErrNilMap
+	}
+
+	switch src := src.(type) {
+	case nil:
+		m.Purge()
+		return nil
+	case []byte:
+		return m.UnmarshalJSON(src)
+	case string:
+		return m.UnmarshalJSON([]byte(src))
+	}
+	return fmt.Errorf("syncmap: cannot scan %T into a SyncMap", src)
+}
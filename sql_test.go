@@ -0,0 +1,72 @@
+package syncmap
+
+import (
+	"database/sql/driver"
+	"errors"
+	"testing"
+)
+
+func TestSyncMapSQL(t *testing.T) {
+	t.Run(
+		"RoundTrip", func(t *testing.T) {
+			sm := New[string, int](10)
+			sm.Store("a", 1)
+
+			v, err := sm.Value()
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if b, ok := v.([]byte); !ok || string(b) != `{"a":1}` {
+				t.Errorf("Expected the JSON encoding, got %#v", v)
+			}
+			if !driver.IsValue(v) {
+				t.Errorf("Expected a valid driver.Value, got %T", v)
+			}
+
+			for _, src := range []any{v, string(v.([]byte))} {
+				restored := New[string, int](10)
+				restored.Store("stale", 1)
+				if err := restored.Scan(src); err != nil {
+					t.Fatalf("Expected no error, got %v", err)
+				}
+				if !Equal(sm, restored) {
+					t.Errorf("Expected the contents to be replaced from %T, got %d entries", src, restored.Len())
+				}
+			}
+		},
+	)
+
+	t.Run(
+		"Null", func(t *testing.T) {
+			var nilMap *SyncMap[string, int]
+			if v, err := nilMap.Value(); v != nil || err != nil {
+				t.Errorf("Expected NULL, got %v and %v", v, err)
+			}
+			if err := nilMap.Scan(nil); !errors.Is(err, ErrNilMap) {
+				t.Errorf("Expected ErrNilMap, got %v", err)
+			}
+
+			sm := New[string, int](10)
+			sm.Store("a", 1)
+			if err := sm.Scan(nil); err != nil || sm.Len() != 0 {
+				t.Errorf("Expected NULL to empty the map, got %d entries and %v", sm.Len(), err)
+			}
+		},
+	)
+
+	t.Run(
+		"Invalid", func(t *testing.T) {
+			sm := New[string, int](10)
+			sm.Store("a", 1)
+			if err := sm.Scan(42); err == nil {
+				t.Error("Expected an error for an integer column")
+			}
+			if err := sm.Scan(`{"a":`); err == nil {
+				t.Error("Expected an error for invalid JSON")
+			}
+			if sm.Len() != 1 {
+				t.Errorf("Expected the map to be unchanged, got %d entries", sm.Len())
+			}
+		},
+	)
+}
@@ -0,0 +1,112 @@
+package syncmap
+
+import (
+	"time"
+)
+
+// WithStaleWhileRevalidate makes the SyncMap keep serving entries for up to maxStale after they expire,
+// while refresh is called in the background to repopulate them: Load and LoadStale return the stale value
+// right away, so hot keys expiring do not cause latency spikes for their readers.
+// A successful refresh stores the new value with the TTL of the expired entry; a failed one leaves
+// the stale entry in place, to be refreshed again on the next read until maxStale has elapsed.
+// There is at most one refresh in progress per key. refresh is called without holding the lock.
+//
+// Stale entries are only visible to Load and LoadStale: other operations treat them as absent.
+// RemoveExpired and the janitor remove entries once they have been expired for maxStale.
+func WithStaleWhileRevalidate[K comparable, V any](maxStale time.Duration, refresh func(k K) (V, error)) Option[K, V] {
+	return func(m *SyncMap[K, V]) {
+		m.maxStale = maxStale
+		m.revalidate = refresh
+	}
+}
+
+// LoadStale retrieves the value for a key, including an expired value that has not been removed yet,
+// which it reports as stale. If the map was created WithStaleWhileRevalidate, reading a stale value
+// starts its refresh. The ok result reports whether a value was found.
+// It acquires a read lock to ensure thread-safe access to the underlying data.
+func (m *SyncMap[K, V]) LoadStale(k K) (v V, stale bool, ok bool) {
+	k = m.key(k)
+
+	m.rlock()
+	v, ok = m.load(k)
+	if ok {
+		m.accessed(k)
+		m.runlock()
+		return v, false, true
+	}
+	m.stats.miss()
+
+	raw, present := m.data[k]
+	ttl := time.Duration(0)
+	if d, ok := m.expiry[k]; ok {
+		ttl = d.ttl
+	}
+	m.runlock()
+
+	if !present {
+		return v, false, false
+	}
+	if m.revalidate != nil {
+		m.revalidateLater(k, ttl, m.revalidate)
+	}
+	return m.decode(raw), true, true
+}
+
+// loadStale returns the value of the expired entry of k if it can still be served while it is refreshed,
+// along with its TTL. It assumes that the caller holds at least the read lock.
+func (m *SyncMap[K, V]) loadStale(k K) (v V, ttl time.Duration, ok bool) {
+	d, hasDeadline := m.expiry[k]
+	raw, present := m.data[k]
+	if m.revalidate == nil || !present || !hasDeadline {
+		return v, 0, false
+	}
+
+	now := m.clock()
+	if !d.expiredAt(now) || d.expiredAt(now.Add(-m.maxStale)) {
+		return v, 0, false
+	}
+	return m.decode(raw), d.ttl, true
+}
+
+// revalidateLater refreshes the entry of k in the background with refresh, unless it is already being refreshed.
+// The new value is stored with the given TTL.
+func (m *SyncMap[K, V]) revalidateLater(k K, ttl time.Duration, refresh func(k K) (V, error)) {
+	m.revalidateMu.Lock()
+	if _, ok := m.revalidating[k]; ok {
+		m.revalidateMu.Unlock()
+		return
+	}
+	if m.revalidating == nil {
+		m.revalidating = make(map[K]struct{})
+	}
+	m.revalidating[k] = struct{}{}
+	m.revalidateMu.Unlock()
+
+	go func() {
+		defer func() {
+			m.revalidateMu.Lock()
+			delete(m.revalidating, k)
+			m.revalidateMu.Unlock()
+		}()
+
+		if v, err := refresh(k); err == nil {
+			m.storeRefreshed(k, v, ttl)
+		}
+	}()
+}
+
+// storeRefreshed stores the refreshed value v for k with the given TTL.
+// As loaded values, refreshed values are not persisted by the writer of the map.
+func (m *SyncMap[K, V]) storeRefreshed(k K, v V, ttl time.Duration) {
+	m.lock()
+	defer m.unlock()
+
+	if stored, err := m.put(k, v, false); err != nil || !stored {
+		return
+	}
+	if m.expiry == nil {
+		m.expiry = make(map[K]*deadline)
+	}
+	m.expiry[k] = newDeadline(m.clock(), ttl)
+	m.recordDeadline(k)
+}
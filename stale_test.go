@@ -0,0 +1,115 @@
+package syncmap
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSyncMapLoadStale(t *testing.T) {
+	clock := newFakeClock()
+	sm := New[string, int](10, WithClock[string, int](clock.Now))
+	sm.StoreWithTTL("a", 1, time.Second)
+
+	if v, stale, ok := sm.LoadStale("a"); !ok || stale || v != 1 {
+		t.Errorf("Expected (1, false, true), got (%v, %v, %v)", v, stale, ok)
+	}
+	clock.Advance(time.Second)
+	if v, stale, ok := sm.LoadStale("a"); !ok || !stale || v != 1 {
+		t.Errorf("Expected (1, true, true), got (%v, %v, %v)", v, stale, ok)
+	}
+
+	// without revalidation, Load removes the expired entry
+	sm.Load("a")
+	if _, _, ok := sm.LoadStale("a"); ok {
+		t.Error("Expected the removed entry to be absent")
+	}
+}
+
+func TestWithStaleWhileRevalidate(t *testing.T) {
+	waitFor := func(cond func() bool) bool {
+		deadline := time.Now().Add(time.Second)
+		for !cond() && time.Now().Before(deadline) {
+			time.Sleep(time.Millisecond)
+		}
+		return cond()
+	}
+
+	t.Run(
+		"Refresh", func(t *testing.T) {
+			clock := newFakeClock()
+			var refreshes atomic.Int32
+			release := make(chan struct{})
+			sm := New[string, int](
+				10,
+				WithClock[string, int](clock.Now),
+				WithStaleWhileRevalidate[string, int](
+					time.Minute, func(k string) (int, error) {
+						refreshes.Add(1)
+						<-release
+						return 2, nil
+					},
+				),
+			)
+			sm.StoreWithTTL("a", 1, time.Second)
+			clock.Advance(time.Second)
+
+			// the stale value is served while the refresh is in progress
+			for range 3 {
+				if v, ok := sm.Load("a"); !ok || v != 1 {
+					t.Errorf("Expected (1, true), got (%v, %v)", v, ok)
+				}
+			}
+			if v, stale, ok := sm.LoadStale("a"); !ok || !stale || v != 1 {
+				t.Errorf("Expected (1, true, true), got (%v, %v, %v)", v, stale, ok)
+			}
+			if n := sm.RemoveExpired(); n != 0 {
+				t.Errorf("Expected the stale entry to be kept, got %d removed", n)
+			}
+			close(release)
+
+			if !waitFor(func() bool { v, _ := sm.Load("a"); return v == 2 }) {
+				t.Error("Expected the refresh to store the new value")
+			}
+			if refreshes.Load() != 1 {
+				t.Errorf("Expected a single refresh, got %d", refreshes.Load())
+			}
+			if ttl, ok := sm.GetTTL("a"); !ok || ttl != time.Second {
+				t.Errorf("Expected the refreshed entry to have the previous TTL, got (%v, %v)", ttl, ok)
+			}
+		},
+	)
+
+	t.Run(
+		"MaxStale", func(t *testing.T) {
+			clock := newFakeClock()
+			var refreshes atomic.Int32
+			sm := New[string, int](
+				10,
+				WithClock[string, int](clock.Now),
+				WithReadMostly[string, int](),
+				WithStaleWhileRevalidate[string, int](
+					time.Minute, func(k string) (int, error) {
+						refreshes.Add(1)
+						return 0, errors.New("source unavailable")
+					},
+				),
+			)
+			sm.StoreWithTTL("a", 1, time.Second)
+			clock.Advance(time.Second)
+
+			if v, ok := sm.Load("a"); !ok || v != 1 {
+				t.Errorf("Expected (1, true), got (%v, %v)", v, ok)
+			}
+			if !waitFor(func() bool { return refreshes.Load() == 1 }) {
+				t.Error("Expected a refresh")
+			}
+
+			clock.Advance(time.Minute)
+			if _, ok := sm.Load("a"); ok || sm.Len() != 0 {
+				t.Error("Expected the entry to be removed once maxStale has elapsed")
+			}
+		},
+	)
+}
@@ -0,0 +1,119 @@
+package syncmap
+
+import (
+	"sync/atomic"
+)
+
+// Stats is a snapshot of the operation counters of a SyncMap created WithStats.
+type Stats struct {
+	// Hits is the number of reads that found the key.
+	Hits uint64
+	// Misses is the number of reads that did not find the key, including reads of expired entries.
+	Misses uint64
+	// Stores is the number of values stored, including those loaded or computed by the map.
+	Stores uint64
+	// Deletes is the number of entries removed explicitly. Entries removed by Purge are not counted.
+	Deletes uint64
+	// Evictions is the number of entries evicted to respect the limits of the map or under memory pressure.
+	Evictions uint64
+	// Expirations is the number of expired entries removed from the map.
+	Expirations uint64
+}
+
+// HitRatio returns the fraction of reads that found the key, or 0 if there were no reads.
+func (s Stats) HitRatio() float64 {
+	if s.Hits+s.Misses == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(s.Hits+s.Misses)
+}
+
+// WithStats makes the map count hits, misses, stores, deletes, evictions and expirations,
+// which are returned by Stats, to measure the effectiveness of a cache without wrapping its call sites.
+// The counters are shared by all the goroutines that use the map, so they add contention on hot maps.
+func WithStats[K comparable, V any]() Option[K, V] {
+	return func(m *SyncMap[K, V]) {
+		m.stats = &statsCounters{}
+	}
+}
+
+// Stats returns the operation counters of the map since it was created or ResetStats was last called.
+// It returns zero Stats if the map was not created WithStats.
+// The counters are read one by one without locking, so they may be slightly inconsistent with one another.
+func (m *SyncMap[K, V]) Stats() Stats {
+	if m.stats == nil {
+		return Stats{}
+	}
+
+	s := m.stats
+	return Stats{
+		Hits:        s.hits.Load(),
+		Misses:      s.misses.Load(),
+		Stores:      s.stores.Load(),
+		Deletes:     s.deletes.Load(),
+		Evictions:   s.evictions.Load(),
+		Expirations: s.expirations.Load(),
+	}
+}
+
+// ResetStats sets all the operation counters of the map to zero.
+func (m *SyncMap[K, V]) ResetStats() {
+	if m.stats == nil {
+		return
+	}
+
+	s := m.stats
+	s.hits.Store(0)
+	s.misses.Store(0)
+	s.stores.Store(0)
+	s.deletes.Store(0)
+	s.evictions.Store(0)
+	s.expirations.Store(0)
+}
+
+// statsCounters holds the counters of a map created WithStats.
+// Its methods do nothing on a nil statsCounters, so that they can be called unconditionally.
+type statsCounters struct {
+	hits        atomic.Uint64
+	misses      atomic.Uint64
+	stores      atomic.Uint64
+	deletes     atomic.Uint64
+	evictions   atomic.Uint64
+	expirations atomic.Uint64
+}
+
+func (s *statsCounters) hit() {
+	if s != nil {
+		s.hits.Add(1)
+	}
+}
+
+func (s *statsCounters) miss() {
+	if s != nil {
+		s.misses.Add(1)
+	}
+}
+
+func (s *statsCounters) stored() {
+	if s != nil {
+		s.stores.Add(1)
+	}
+}
+
+func (s *statsCounters) deleted() {
+	if s != nil {
+		s.deletes.Add(1)
+	}
+}
+
+func (s *statsCounters) evicted() {
+	if s != nil {
+		s.evictions.Add(1)
+	}
+}
+
+func (s *statsCounters) expired() {
+	if s != nil {
+		s.expirations.Add(1)
+	}
+}
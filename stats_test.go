@@ -0,0 +1,62 @@
+package syncmap
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSyncMapStats(t *testing.T) {
+	t.Run(
+		"Counters", func(t *testing.T) {
+			for _, readMostly := range []bool{false, true} {
+				clock := newFakeClock()
+				opts := []Option[string, int]{
+					WithClock[string, int](clock.Now), WithStats[string, int](), WithMaxEntries[string, int](2),
+				}
+				if readMostly {
+					opts = append(opts, WithReadMostly[string, int]())
+				}
+				sm := New[string, int](10, opts...)
+				sm.Store("a", 1)
+				sm.Store("b", 2)
+				sm.Load("a")
+				sm.Load("a")
+				sm.Load("missing")
+				sm.LoadOrStore("a", 0)
+				sm.Store("c", 3) // evicts b
+				sm.Remove("c")
+				sm.StoreWithTTL("d", 4, time.Second)
+				clock.Advance(time.Minute)
+				sm.RemoveExpired()
+
+				expected := Stats{Hits: 3, Misses: 1, Stores: 4, Deletes: 1, Evictions: 1, Expirations: 1}
+				if s := sm.Stats(); s != expected {
+					t.Errorf("Expected %+v, got %+v (read-mostly: %v)", expected, s, readMostly)
+				}
+				if r := sm.Stats().HitRatio(); r != 0.75 {
+					t.Errorf("Expected a hit ratio of 0.75, got %v (read-mostly: %v)", r, readMostly)
+				}
+
+				sm.ResetStats()
+				if s := sm.Stats(); s != (Stats{}) {
+					t.Errorf("Expected zero stats after a reset, got %+v", s)
+				}
+				if r := sm.Stats().HitRatio(); r != 0 {
+					t.Errorf("Expected a hit ratio of 0 without reads, got %v", r)
+				}
+			}
+		},
+	)
+
+	t.Run(
+		"Disabled", func(t *testing.T) {
+			sm := New[string, int](10)
+			sm.Store("a", 1)
+			sm.Load("a")
+			sm.ResetStats()
+			if s := sm.Stats(); s != (Stats{}) {
+				t.Errorf("Expected zero stats without WithStats, got %+v", s)
+			}
+		},
+	)
+}
@@ -1,7 +1,19 @@
 package syncmap
 
 import (
+	"container/heap"
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"runtime"
+	"runtime/debug"
 	"sync"
+	"sync/atomic"
+	"time"
+	"unique"
+
+	"github.com/antst/go-syncmap/keycodec"
 )
 
 type noCopy struct{}
@@ -12,69 +24,320 @@ func (*noCopy) Unlock() {}
 
 // SyncMap is a generic, thread-safe map implementation.
 // It uses a read-write mutex to ensure safe concurrent access to the underlying map.
+// The zero SyncMap is empty and ready for use, so it can be embedded in other structs without calling New;
+// options can only be applied with New. A SyncMap must not be copied after first use.
 //
 // Type parameters:
 //
 //	K: must be a comparable type (used as map keys)
 //	V: can be any type (used as map values)
 type SyncMap[K comparable, V any] struct {
-	_    noCopy //nolint:unused // Prevent direct copying of SyncMap by embedding it in another struct.
-	mu   sync.RWMutex
-	data map[K]V
+	_     noCopy //nolint:unused // Prevent direct copying of SyncMap by embedding it in another struct.
+	mu    sync.RWMutex
+	data  map[K]V
+	keyFn func(K) K
+	// whether newly stored keys are interned, see WithKeyInterning;
+	// the handles of the interned keys, which keep their canonical copies alive
+	intern  bool
+	handles map[K]unique.Handle[K]
+
+	// see WithName and WithLabels
+	name   string
+	labels map[string]string
+
+	// incremented on every mutation, see Version
+	version atomic.Uint64
+	// number of entries, see Len
+	size atomic.Int64
+	// expiration times of the entries stored with a TTL, see StoreWithTTL
+	expiry map[K]*deadline
+	// see WithSlidingExpiration
+	sliding bool
+	// see WithDefaultTTL
+	defaultTTL time.Duration
+	// entries protected from expiration and eviction, see Pin
+	pinned map[K]struct{}
+	// see WithStaleWhileRevalidate
+	maxStale   time.Duration
+	revalidate func(k K) (V, error)
+	// keys being refreshed
+	revalidating map[K]struct{}
+	revalidateMu sync.Mutex
+	// see WithLoader
+	loader func(k K) (V, error)
+	// see WithMaxConcurrentLoads; holds a token per call to the loader in progress
+	loadSlots chan struct{}
+	// see WithRefreshAhead
+	refreshAhead  float64
+	refreshJitter float64
+	// see WithNegativeCaching; keys not found by the loader, with the time until when they are remembered
+	negativeTTL time.Duration
+	missing     map[K]time.Time
+	// nil unless values are persisted, see WithWriter
+	writer *writer[K, V]
+	// calls to the LoadOrCompute function in progress
+	computing map[K]*computation[V]
+	computeMu sync.Mutex
+	// the janitor running, if any, see StartJanitor
+	janitor   *janitor
+	janitorMu sync.Mutex
+	// largest number of entries since the map was last rebuilt
+	peak int
+	// see WithAutoCompaction
+	compactAt int
+
+	// copy of data published for lock-free reads, see WithReadMostly
+	readMostly bool
+	snapshot   atomic.Pointer[readSnapshot[K, V]]
+	// version of the published snapshot
+	published uint64
+
+	strict        bool
+	forbidZeroKey bool
+
+	// see WithMaxValueSize
+	maxValueSize int
+	sizer        func(v V) int
+
+	// orders the keys encoded by MarshalJSON, see WithSortedJSON
+	jsonKeyOrder func(a, b K) int
+
+	// see WithBinaryCodec
+	binaryKeys   keycodec.Codec[K]
+	binaryValues keycodec.Codec[V]
+
+	// applied to stored values, see WithValueTransformers
+	transformers []ValueTransformer[V]
+
+	// reports whether a new value is equal to the old one, see WithChangeDetector
+	unchanged func(old, new V) bool
+
+	scanChunk int
+	scanPause time.Duration
+
+	softLimit      int
+	onSoftLimit    func(size int)
+	softLimitFired bool
+
+	// see WithMaxEntries; the eviction policy is nil for unbounded maps
+	maxEntries int
+	policy     evictionPolicy[K]
+	// serializes calls to the eviction policy, which readers make concurrently under the read lock
+	policyMu sync.Mutex
+	// see WithMaxCost; costs holds the cost of every entry
+	maxCost   int64
+	cost      func(k K, v V) int64
+	costs     map[K]int64
+	totalCost int64
+	// metadata of the entries, see WithEntryMetadata
+	withMeta bool
+	meta     map[K]*entryMeta
+	// entries by tag and tags by entry, see StoreTagged
+	tags    map[string]map[K]struct{}
+	keyTags map[K][]string
+	// nil unless admission is filtered, see WithTinyLFUAdmission
+	admission *frequencySketch[K]
+	// called for every entry expired or evicted, see WithEvictionCallback
+	onEvict func(k K, v V, reason EvictionReason)
+
+	// fractions of the entries evicted by OnMemoryPressure, indexed by level, see WithPressureEviction
+	pressureEviction [3]float64
+
+	// called for every entry dropped from the map, see WithDisposer
+	dispose Disposer[K, V]
+
+	// called for every change, see WithEventHook
+	onEvent func(e Event[K, V])
+	// nil unless the event history is enabled, see WithEventHistory
+	history *eventRing[K, V]
+	// the clock of the map, see WithClock, and the one producing the timestamps of events, see WithEventClock
+	now      func() time.Time
+	eventNow func() time.Time
+
+	// nil unless changes are journaled, see WithJournal
+	journal *journal
+	// set while Replay applies the changes of a journal, which are not journaled again
+	replaying bool
+
+	// nil unless statistics are enabled, see WithStats
+	stats *statsCounters
+
+	// nil unless caller attribution is enabled, see WithCallerAttribution
+	callers *callerStats
+
+	// replaces mu if set, see WithLockStrategy
+	customLock rwLocker
+
+	// nil unless deadlock detection is enabled, see WithDeadlockDetection
+	holders *lockHolders
+
+	// see WithPanicIsolation
+	isolatePanics bool
+	onPanic       func(err error)
+
+	// closed once the map is hydrated, nil if the map has no ready gate
+	ready     chan struct{}
+	readyOnce sync.Once
+
+	// callbacks queued under the write lock, to be run once it is released
+	pending []func()
+}
+
+// Entry is a key-value pair of a SyncMap.
+type Entry[K comparable, V any] struct {
+	Key   K
+	Value V
 }
 
 // New creates and returns a new SyncMap with the specified initial size.
-// It initializes the internal map and mutex for thread-safe operations.
-func New[K comparable, V any](size int) *SyncMap[K, V] {
-	return &SyncMap[K, V]{
+// It initializes the internal map and mutex for thread-safe operations,
+// and applies the given options.
+func New[K comparable, V any](size int, opts ...Option[K, V]) *SyncMap[K, V] {
+	m := &SyncMap[K, V]{
 		mu:   sync.RWMutex{},
 		data: make(map[K]V, size),
 	}
+
+	for _, opt := range opts {
+		opt(m)
+	}
+
+	return m
+}
+
+// NewWithOptions creates and returns a new SyncMap configured by the given options.
+// It is equivalent to New with an initial size of 0; use WithCapacity to pre-size the map.
+func NewWithOptions[K comparable, V any](opts ...Option[K, V]) *SyncMap[K, V] {
+	return New[K, V](0, opts...)
 }
 
 // Store adds or updates a key-value pair in the SyncMap.
+// If the map rejects the pair (see WithZeroKeyForbidden), the write is dropped,
+// or Store panics if the map was created WithStrictMode. Use TryStore to handle rejections as errors.
 // It acquires a write lock to ensure thread-safe access to the underlying data.
 func (m *SyncMap[K, V]) Store(k K, v V) {
-	m.mu.Lock()
-	defer m.mu.Unlock()
+	m.lock()
+	defer m.unlock()
+
+	m.check("Store", m.store(m.key(k), v))
+}
+
+// TryStore is like Store, but returns an error instead of silently dropping the write or panicking
+// when the map rejects the pair. It returns ErrNilMap when called on a nil *SyncMap.
+// It acquires a write lock to ensure thread-safe access to the underlying data.
+func (m *SyncMap[K, V]) TryStore(k K, v V) error {
+	if m == nil {
+		return ErrNilMap
+	}
+
+	m.lock()
+	defer m.unlock()
+
+	return m.store(m.key(k), v)
+}
+
+// StoreUntilDone stores the value for a key and removes the key once ctx is done,
+// which suits request-scoped registrations such as in-flight request tables.
+// The removal drops whatever is stored under the key at that time, even if it was overwritten meanwhile.
+// Calling the returned stop function cancels the scheduled removal; it reports whether it did so
+// (false if the removal has already started or was stopped before).
+func (m *SyncMap[K, V]) StoreUntilDone(ctx context.Context, k K, v V) (stop func() bool) {
+	m.Store(k, v)
 
-	m.data[k] = v
+	return context.AfterFunc(
+		ctx, func() {
+			m.Remove(k)
+		},
+	)
 }
 
 // Load retrieves the value associated with the given key from the SyncMap.
-// It acquires a read lock to ensure thread-safe access to the underlying data.
+// It acquires a read lock to ensure thread-safe access to the underlying data,
+// unless the map was created WithReadMostly.
+// If the map was created WithLoader, a missing key is loaded, see WithLoader.
 func (m *SyncMap[K, V]) Load(k K) (V, bool) {
-	m.mu.RLock()
-	defer m.mu.RUnlock()
+	v, err := m.loadThrough(m.key(k))
+	return v, err == nil
+}
 
-	v, ok := m.data[k]
+// lookup retrieves the value associated with the given key, which must be normalized.
+// Unlike Load, it does not call the loader of the map.
+func (m *SyncMap[K, V]) lookup(k K) (V, bool) {
+	if m.readMostly {
+		snapshot := m.snapshot.Load()
+		v, ok := snapshot.load(k, m.clock)
+		if ok {
+			m.trackAccessed(k)
+			m.stats.hit()
+			snapshot.meta[k].accessed(m.clock())
+			d := snapshot.expiry[k]
+			if d != nil && m.sliding {
+				d.touch(m.clock())
+			}
+			if m.dueForRefresh(d) {
+				m.revalidateLater(k, d.ttl, m.callLoader)
+			}
+			v = m.decode(v)
+		} else {
+			m.trackMissed(k)
+			if m.revalidate == nil {
+				m.stats.miss()
+			}
+		}
+		if ok || m.revalidate == nil {
+			return v, ok
+		}
+		// the entry may be stale: look it up under the lock
+	}
+
+	m.rlock()
+	v, ok := m.load(k)
+	if ok {
+		m.accessed(k)
+	} else {
+		m.stats.miss()
+		if !m.readMostly {
+			m.trackMissed(k)
+		}
+	}
+	_, present := m.data[k]
+	stale, ttl, isStale := m.loadStale(k)
+	d := m.expiry[k]
+	m.runlock()
+
+	if isStale {
+		m.revalidateLater(k, ttl, m.revalidate)
+		return stale, true
+	}
+	if ok && m.dueForRefresh(d) {
+		m.revalidateLater(k, d.ttl, m.callLoader)
+	}
+	if present && !ok {
+		// the entry has expired: remove it on access
+		m.removeExpired(k)
+	}
 	return v, ok
 }
 
 // Remove deletes the value associated with the given key from the SyncMap.
 // It acquires a write lock to ensure thread-safe access to the underlying data.
 func (m *SyncMap[K, V]) Remove(k K) bool {
-	m.mu.Lock()
-	defer m.mu.Unlock()
-
-	if _, ok := m.data[k]; !ok {
-		return false
-	}
+	m.lock()
+	defer m.unlock()
 
-	delete(m.data, k)
-
-	return true
+	return m.drop(m.key(k))
 }
 
 // Map applies a given function to all key-value pairs in the SyncMap and returns a new map with the results.
 // It acquires a read lock to ensure thread-safe access to the underlying data.
 func (m *SyncMap[K, V]) Map(mapFn func(k K, v V) V) map[K]V {
-	m.mu.RLock()
-	defer m.mu.RUnlock()
+	defer m.recoverCallback("Map")
+	m.rlock()
+	defer m.runlock()
 
 	data := make(map[K]V, len(m.data))
 
-	for k, v := range m.data {
+	for k, v := range m.entries() {
 		data[k] = mapFn(k, v)
 	}
 
@@ -84,12 +347,13 @@ func (m *SyncMap[K, V]) Map(mapFn func(k K, v V) V) map[K]V {
 // Filter creates a new map containing key-value pairs from the SyncMap that satisfy the given predicate function.
 // It acquires a read lock to ensure thread-safe access to the underlying data.
 func (m *SyncMap[K, V]) Filter(predicateFn func(k K, v V) bool) map[K]V {
+	defer m.recoverCallback("Filter")
 	data := make(map[K]V)
 
-	m.mu.RLock()
-	defer m.mu.RUnlock()
+	m.rlock()
+	defer m.runlock()
 
-	for k, v := range m.data {
+	for k, v := range m.entries() {
 		if predicateFn(k, v) {
 			data[k] = v
 		}
@@ -101,75 +365,977 @@ func (m *SyncMap[K, V]) Filter(predicateFn func(k K, v V) bool) map[K]V {
 // Purge removes all key-value pairs from the SyncMap, effectively clearing its contents.
 // It acquires a write lock to ensure thread-safe access to the underlying data.
 func (m *SyncMap[K, V]) Purge() {
-	m.mu.Lock()
-	defer m.mu.Unlock()
+	m.lock()
+	defer m.unlock()
 
-	m.data = make(map[K]V)
+	m.purge()
 }
 
 // Len returns the number of key-value pairs in the SyncMap.
-// It acquires a read lock to ensure thread-safe access to the underlying data.
+// It does not acquire any lock: the count is maintained by every write,
+// so while a DoLocked critical section is running it may reflect some of its changes but not others.
+// Use LenLocked when the count must be consistent with a point in time between critical sections.
+// Expired entries (see StoreWithTTL) are counted until they are actually removed.
 func (m *SyncMap[K, V]) Len() int {
-	m.mu.RLock()
-	defer m.mu.RUnlock()
+	return int(m.size.Load())
+}
 
-	return len(m.data)
+// LenLocked returns the number of key-value pairs in the SyncMap, not counting expired entries.
+// It acquires a read lock to ensure thread-safe access to the underlying data.
+func (m *SyncMap[K, V]) LenLocked() int {
+	m.rlock()
+	defer m.runlock()
+
+	return m.count()
 }
 
 // DoLocked executes a function with exclusive access to the SyncMap.
 // It acquires a write lock before executing the function and releases it afterward.
 func (m *SyncMap[K, V]) DoLocked(f func(LockedMap[K, V])) {
-	m.mu.Lock()
-	defer m.mu.Unlock()
-	f(&lockedMap[K, V]{m: m})
+	m.lock()
+	defer m.unlock()
+	lm := &lockedMap[K, V]{m: m}
+	defer lm.invalidate()
+	f(lm)
 }
 
 // DoLockedWithResult executes a function with exclusive access to the SyncMap and returns its result.
 // It acquires a write lock before executing the function and releases it afterward.
 func (m *SyncMap[K, V]) DoLockedWithResult(f func(LockedMap[K, V]) any) any {
-	m.mu.Lock()
-	defer m.mu.Unlock()
-	return f(&lockedMap[K, V]{m: m})
+	m.lock()
+	defer m.unlock()
+	lm := &lockedMap[K, V]{m: m}
+	defer lm.invalidate()
+	return f(lm)
+}
+
+// DoLockedErr executes a function with exclusive access to the SyncMap and returns the error it returns,
+// so that transactional blocks can propagate failures.
+// Changes made by f before it fails are not rolled back.
+// It acquires a write lock before executing the function and releases it afterward.
+func (m *SyncMap[K, V]) DoLockedErr(f func(LockedMap[K, V]) error) error {
+	m.lock()
+	defer m.unlock()
+	lm := &lockedMap[K, V]{m: m}
+	defer lm.invalidate()
+	return f(lm)
+}
+
+// TryDoLocked is like DoLocked, but does not block when the SyncMap is locked:
+// if the write lock cannot be acquired immediately, f is not executed and TryDoLocked returns false.
+// This lets latency-sensitive callers skip or defer work when the map is contended.
+func (m *SyncMap[K, V]) TryDoLocked(f func(LockedMap[K, V])) bool {
+	if !m.tryLock() {
+		return false
+	}
+	defer m.unlock()
+	lm := &lockedMap[K, V]{m: m}
+	defer lm.invalidate()
+	f(lm)
+	return true
+}
+
+// DoLockedContext is like DoLocked, but gives up waiting for the lock when ctx is done,
+// returning the context's error without executing f. Otherwise, it returns the error returned by f.
+// This keeps request goroutines from piling up behind a long critical section.
+func (m *SyncMap[K, V]) DoLockedContext(ctx context.Context, f func(LockedMap[K, V]) error) error {
+	if err := m.lockContext(ctx); err != nil {
+		return err
+	}
+	defer m.unlock()
+	lm := &lockedMap[K, V]{m: m}
+	defer lm.invalidate()
+	return f(lm)
+}
+
+// DoRLocked executes a function with shared read access to the SyncMap.
+// The function receives a ReadOnlyLockedMap, so it cannot modify the map, and several
+// DoRLocked calls (and other readers) may run concurrently.
+// It acquires a read lock before executing the function and releases it afterward.
+func (m *SyncMap[K, V]) DoRLocked(f func(ReadOnlyLockedMap[K, V])) {
+	m.rlock()
+	defer m.runlock()
+	lm := &lockedMap[K, V]{m: m}
+	defer lm.invalidate()
+	f(&readOnlyLockedMap[K, V]{lm: lm})
 }
 
 // LoadOrStore returns the existing value for the key if present.
 // Otherwise, it stores and returns the given value.
 // The loaded result is true if the value was loaded, false if stored.
+// If the map rejects the pair, nothing is stored and the zero value is returned,
+// or LoadOrStore panics if the map was created WithStrictMode.
 // It acquires a write lock to ensure thread-safe access to the underlying data.
 func (m *SyncMap[K, V]) LoadOrStore(key K, value V) (V, bool) {
-	m.mu.Lock()
-	defer m.mu.Unlock()
+	m.lock()
+	defer m.unlock()
+
+	v, loaded, err := m.loadOrStore(m.key(key), value)
+	m.check("LoadOrStore", err)
+	return v, loaded
+}
 
-	if v, ok := m.data[key]; ok {
-		return v, true
+// LoadOrMerge stores the given value if the key is absent; otherwise it stores merge(old, value).
+// It returns the value now associated with the key, and whether an existing value was merged.
+// Both steps happen atomically, under a single write lock. merge must not call methods of the SyncMap.
+// If the map rejects the resulting value, the map is left unchanged and the zero value is returned,
+// or LoadOrMerge panics if the map was created WithStrictMode.
+func (m *SyncMap[K, V]) LoadOrMerge(key K, value V, merge func(old, new V) V) (V, bool) {
+	m.lock()
+	defer m.unlock()
+
+	k := m.key(key)
+	old, loaded := m.load(k)
+	if loaded {
+		value = merge(old, value)
 	}
 
-	m.data[key] = value
-	return value, false
+	if err := m.store(k, value); err != nil {
+		m.check("LoadOrMerge", err)
+		var zero V
+		return zero, loaded
+	}
+	return value, loaded
 }
 
 // LoadAndDelete removes the value for a key, returning the previous value if any.
 // The loaded result reports whether the key was present.
 // It acquires a write lock to ensure thread-safe access to the underlying data.
 func (m *SyncMap[K, V]) LoadAndDelete(key K) (V, bool) {
-	m.mu.Lock()
-	defer m.mu.Unlock()
-	v, ok := m.data[key]
-	if ok {
-		delete(m.data, key)
-	}
-	return v, ok
+	m.lock()
+	defer m.unlock()
+
+	return m.remove(m.key(key))
 }
 
 // Range calls f sequentially for each key and value present in the map.
 // If f returns false, range stops the iteration.
 // It acquires a read lock to ensure thread-safe access to the underlying data.
+// If the map was created WithScanThrottle, the read lock is released between chunks of entries.
 func (m *SyncMap[K, V]) Range(f func(key K, value V) bool) {
-	m.mu.RLock()
-	defer m.mu.RUnlock()
-	for k, v := range m.data {
+	defer m.recoverCallback("Range")
+	if m.scanChunk > 0 {
+		m.rangeThrottled(f)
+		return
+	}
+
+	m.rlock()
+	defer m.runlock()
+	for k, v := range m.entries() {
 		if !f(k, v) {
 			break
 		}
 	}
 }
+
+// rangeThrottled is Range releasing the read lock and pausing after every chunk of entries,
+// so that writers are not blocked for the duration of a long scan.
+func (m *SyncMap[K, V]) rangeThrottled(f func(key K, value V) bool) {
+	m.rlock()
+	locked := true
+	defer func() {
+		if locked {
+			m.runlock()
+		}
+	}()
+
+	n := 0
+	for k, v := range m.entries() {
+		if !f(k, v) {
+			return
+		}
+
+		n++
+		if n%m.scanChunk != 0 {
+			continue
+		}
+
+		m.runlock()
+		locked = false
+		if m.scanPause > 0 {
+			time.Sleep(m.scanPause)
+		} else {
+			runtime.Gosched()
+		}
+		m.rlock()
+		locked = true
+	}
+}
+
+// RangeMut calls f sequentially for each key and value present in the map, passing a LockedMap
+// that can be used to modify the map during iteration.
+// If f returns false, RangeMut stops the iteration.
+// Entries removed during iteration that have not yet been reached will not be visited;
+// entries added during iteration may or may not be visited.
+// It acquires a write lock to ensure thread-safe access to the underlying data.
+func (m *SyncMap[K, V]) RangeMut(f func(m LockedMap[K, V], key K, value V) bool) {
+	m.lock()
+	defer m.unlock()
+
+	lm := &lockedMap[K, V]{m: m}
+	defer lm.invalidate()
+	for k, v := range m.entries() {
+		if !f(lm, k, v) {
+			break
+		}
+	}
+}
+
+// RangeStable calls f sequentially for each key present in the map when RangeStable is called,
+// with the current value of the key: the key set is copied under a read lock, but values are read live
+// as the iteration reaches them. Each key is visited at most once; keys removed before they are reached
+// are skipped, and keys added during the iteration are not visited.
+// f is called without holding the lock, so it may freely call methods of the SyncMap.
+// If f returns false, RangeStable stops the iteration.
+// This is a middle ground between Range, which holds the read lock for the whole iteration,
+// and copying the whole map (e.g. with Filter) before iterating over it.
+func (m *SyncMap[K, V]) RangeStable(f func(key K, value V) bool) {
+	m.rlock()
+	keys := make([]K, 0, len(m.data))
+	for k := range m.entries() {
+		keys = append(keys, k)
+	}
+	m.runlock()
+
+	for _, k := range keys {
+		m.rlock()
+		v, ok := m.load(k)
+		m.runlock()
+
+		if ok && !f(k, v) {
+			return
+		}
+	}
+}
+
+// ParallelRange calls f for each key and value present in the map, using up to workers goroutines.
+// The entries are copied under a read lock before processing starts, so f operates on a snapshot
+// and may freely call methods of the SyncMap. If workers is less than 1, GOMAXPROCS is used.
+// ParallelRange returns after f has been called for every entry of the snapshot.
+func (m *SyncMap[K, V]) ParallelRange(workers int, f func(key K, value V)) {
+	m.rlock()
+	keys := make([]K, 0, len(m.data))
+	values := make([]V, 0, len(m.data))
+	for k, v := range m.entries() {
+		keys = append(keys, k)
+		values = append(values, v)
+	}
+	m.runlock()
+
+	if workers < 1 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	if workers > len(keys) {
+		workers = len(keys)
+	}
+
+	var next atomic.Int64
+	var panicked atomic.Pointer[CallbackPanicError]
+	var wg sync.WaitGroup
+	wg.Add(workers)
+
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer wg.Done()
+			if m.isolatePanics {
+				defer func() {
+					if r := recover(); r != nil {
+						err := &CallbackPanicError{Map: m.name, Op: "ParallelRange", Value: r, Stack: debug.Stack()}
+						panicked.CompareAndSwap(nil, err)
+					}
+				}()
+			}
+
+			for panicked.Load() == nil {
+				idx := int(next.Add(1) - 1)
+				if idx >= len(keys) {
+					return
+				}
+				f(keys[idx], values[idx])
+			}
+		}()
+	}
+
+	wg.Wait()
+	if err := panicked.Load(); err != nil {
+		m.reportPanic(err)
+	}
+}
+
+// ContainsValue reports whether the SyncMap contains a value equal to v, comparing values using eq,
+// which is called with the value of each entry and v. Use Any to test values with a predicate.
+// It acquires a read lock to ensure thread-safe access to the underlying data.
+func (m *SyncMap[K, V]) ContainsValue(v V, eq func(a, b V) bool) bool {
+	m.rlock()
+	defer m.runlock()
+
+	for _, value := range m.entries() {
+		if eq(value, v) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// FindKeys returns the keys of all entries whose value satisfies the given predicate function.
+// The order of the returned keys is not specified.
+// It acquires a read lock to ensure thread-safe access to the underlying data.
+func (m *SyncMap[K, V]) FindKeys(predicateFn func(v V) bool) []K {
+	m.rlock()
+	defer m.runlock()
+
+	keys := make([]K, 0)
+	for k, v := range m.entries() {
+		if predicateFn(v) {
+			keys = append(keys, k)
+		}
+	}
+
+	return keys
+}
+
+// Partition splits the SyncMap into two new maps in a single pass: match contains the key-value pairs
+// that satisfy the given predicate function, and rest contains all the others.
+// It acquires a read lock to ensure thread-safe access to the underlying data.
+func (m *SyncMap[K, V]) Partition(predicateFn func(k K, v V) bool) (match, rest map[K]V) {
+	match = make(map[K]V)
+	rest = make(map[K]V)
+
+	m.rlock()
+	defer m.runlock()
+
+	for k, v := range m.entries() {
+		if predicateFn(k, v) {
+			match[k] = v
+		} else {
+			rest[k] = v
+		}
+	}
+
+	return match, rest
+}
+
+// Any reports whether at least one key-value pair in the SyncMap satisfies the given predicate function.
+// It stops at the first match.
+// It acquires a read lock to ensure thread-safe access to the underlying data.
+func (m *SyncMap[K, V]) Any(predicateFn func(k K, v V) bool) bool {
+	m.rlock()
+	defer m.runlock()
+
+	for k, v := range m.entries() {
+		if predicateFn(k, v) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// All reports whether all key-value pairs in the SyncMap satisfy the given predicate function.
+// It stops at the first pair that does not match. All returns true for an empty map.
+// It acquires a read lock to ensure thread-safe access to the underlying data.
+func (m *SyncMap[K, V]) All(predicateFn func(k K, v V) bool) bool {
+	m.rlock()
+	defer m.runlock()
+
+	for k, v := range m.entries() {
+		if !predicateFn(k, v) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// None reports whether no key-value pair in the SyncMap satisfies the given predicate function.
+// It stops at the first match.
+// It acquires a read lock to ensure thread-safe access to the underlying data.
+func (m *SyncMap[K, V]) None(predicateFn func(k K, v V) bool) bool {
+	return !m.Any(predicateFn)
+}
+
+// Count returns the number of key-value pairs in the SyncMap that satisfy the given predicate function.
+// It acquires a read lock to ensure thread-safe access to the underlying data.
+func (m *SyncMap[K, V]) Count(predicateFn func(k K, v V) bool) int {
+	m.rlock()
+	defer m.runlock()
+
+	n := 0
+	for k, v := range m.entries() {
+		if predicateFn(k, v) {
+			n++
+		}
+	}
+
+	return n
+}
+
+// MaxBy returns the key-value pair with the greatest value according to less.
+// If several values are equally great, any of them may be returned.
+// The ok result is false if the map is empty.
+// It acquires a read lock to ensure thread-safe access to the underlying data.
+func (m *SyncMap[K, V]) MaxBy(less func(a, b V) bool) (key K, value V, ok bool) {
+	m.rlock()
+	defer m.runlock()
+
+	for k, v := range m.entries() {
+		if !ok || less(value, v) {
+			key, value, ok = k, v, true
+		}
+	}
+
+	return key, value, ok
+}
+
+// MinBy returns the key-value pair with the smallest value according to less.
+// If several values are equally small, any of them may be returned.
+// The ok result is false if the map is empty.
+// It acquires a read lock to ensure thread-safe access to the underlying data.
+func (m *SyncMap[K, V]) MinBy(less func(a, b V) bool) (key K, value V, ok bool) {
+	m.rlock()
+	defer m.runlock()
+
+	for k, v := range m.entries() {
+		if !ok || less(v, value) {
+			key, value, ok = k, v, true
+		}
+	}
+
+	return key, value, ok
+}
+
+// TopN returns the n greatest entries of the SyncMap according to less, ordered from the greatest.
+// If the map has fewer than n entries, all of them are returned.
+// It keeps a bounded heap of n entries during a single pass, so it does not copy the whole map.
+// It acquires a read lock to ensure thread-safe access to the underlying data.
+func (m *SyncMap[K, V]) TopN(n int, less func(a, b Entry[K, V]) bool) []Entry[K, V] {
+	if n <= 0 {
+		return []Entry[K, V]{}
+	}
+
+	m.rlock()
+	defer m.runlock()
+
+	h := &entryHeap[K, V]{less: less, entries: make([]Entry[K, V], 0, min(n, len(m.data)))}
+	for k, v := range m.entries() {
+		e := Entry[K, V]{Key: k, Value: v}
+		switch {
+		case h.Len() < n:
+			heap.Push(h, e)
+		case less(h.entries[0], e):
+			h.entries[0] = e
+			heap.Fix(h, 0)
+		}
+	}
+
+	// popping from the min-heap yields the entries from the smallest
+	top := make([]Entry[K, V], h.Len())
+	for i := len(top) - 1; i >= 0; i-- {
+		top[i] = heap.Pop(h).(Entry[K, V])
+	}
+
+	return top
+}
+
+// Name returns the name of the SyncMap set WithName, or "" if it has none.
+func (m *SyncMap[K, V]) Name() string {
+	return m.name
+}
+
+// Labels returns a copy of the labels of the SyncMap set WithLabels, or nil if it has none.
+func (m *SyncMap[K, V]) Labels() map[string]string {
+	return maps.Clone(m.labels)
+}
+
+// Version returns the generation of the SyncMap's contents.
+// It starts at 0 and increases with every mutation, so two equal versions of the same map
+// mean that its contents have not changed in between.
+// It does not acquire any lock.
+func (m *SyncMap[K, V]) Version() uint64 {
+	return m.version.Load()
+}
+
+// ReplaceAll atomically replaces the contents of the SyncMap with a copy of data.
+// Readers observe either the old or the new contents, never a mix of both.
+// Pairs rejected by the map are skipped (or cause a panic in strict mode).
+// The previous entries whose key is not in data are passed to the Disposer, if one is set;
+// as with any write to an existing key, the values replaced by data are not.
+// It acquires a write lock to ensure thread-safe access to the underlying data.
+func (m *SyncMap[K, V]) ReplaceAll(data map[K]V) {
+	m.lock()
+	defer m.unlock()
+
+	m.check("ReplaceAll", m.replace(data))
+}
+
+// Grow makes room for at least n more entries, so that a known bulk load does not repeatedly rehash the map.
+// Go maps cannot be resized in place, so the contents are copied into a new map pre-sized for
+// Len()+n entries; Grow thus costs O(Len()) and is worth calling before loading many entries at once.
+// It does not change the contents of the map nor its version.
+// It acquires a write lock to ensure thread-safe access to the underlying data.
+func (m *SyncMap[K, V]) Grow(n int) {
+	if n <= 0 {
+		return
+	}
+
+	m.lock()
+	defer m.unlock()
+
+	m.resize(len(m.data) + n)
+}
+
+// Compact releases the memory kept by the map after many entries have been removed:
+// Go maps never shrink, so the contents are copied into a new map sized for the current Len().
+// It costs O(Len()); see WithAutoCompaction to compact automatically.
+// It does not change the contents of the map nor its version.
+// It acquires a write lock to ensure thread-safe access to the underlying data.
+func (m *SyncMap[K, V]) Compact() {
+	m.lock()
+	defer m.unlock()
+
+	m.resize(len(m.data))
+}
+
+// lock acquires the write lock.
+func (m *SyncMap[K, V]) lock() {
+	m.attribute()
+	if m.holders == nil {
+		m.locker().Lock()
+		return
+	}
+
+	id := goroutineID()
+	if m.holders.enter(id, true) {
+		return
+	}
+	m.locker().Lock()
+	m.holders.writer.Store(id)
+}
+
+// tryLock tries to acquire the write lock without blocking and reports whether it succeeded.
+func (m *SyncMap[K, V]) tryLock() bool {
+	m.attribute()
+	if m.holders == nil {
+		return m.locker().TryLock()
+	}
+
+	id := goroutineID()
+	if m.holders.enter(id, true) {
+		return true
+	}
+	if !m.locker().TryLock() {
+		return false
+	}
+	m.holders.writer.Store(id)
+	return true
+}
+
+// lockContext acquires the write lock, unless ctx is done first.
+// A helper goroutine waits for the lock; if the caller gives up, it releases the lock as soon as it gets it.
+func (m *SyncMap[K, V]) lockContext(ctx context.Context) (err error) {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	m.attribute()
+	if m.holders != nil {
+		id := goroutineID()
+		if m.holders.enter(id, true) {
+			return nil
+		}
+		defer func() {
+			if err == nil {
+				m.holders.writer.Store(id)
+			}
+		}()
+	}
+
+	if m.locker().TryLock() {
+		return nil
+	}
+
+	acquired := make(chan struct{})
+	go func() {
+		m.locker().Lock()
+		close(acquired)
+	}()
+
+	select {
+	case <-acquired:
+		return nil
+	case <-ctx.Done():
+		go func() {
+			<-acquired
+			m.locker().Unlock()
+		}()
+		return ctx.Err()
+	}
+}
+
+// rlock acquires the read lock.
+func (m *SyncMap[K, V]) rlock() {
+	m.attribute()
+	if m.holders == nil {
+		m.locker().RLock()
+		return
+	}
+
+	id := goroutineID()
+	if m.holders.enter(id, false) {
+		return
+	}
+	m.locker().RLock()
+	m.holders.acquiredRead(id)
+}
+
+// runlock releases the read lock.
+func (m *SyncMap[K, V]) runlock() {
+	if m.holders != nil && m.holders.leaveRead() {
+		return
+	}
+	m.locker().RUnlock()
+}
+
+// unlock releases the write lock and then runs the callbacks queued while it was held,
+// so that user callbacks never run under the lock and may use the map.
+func (m *SyncMap[K, V]) unlock() {
+	for _, f := range m.release() {
+		f()
+	}
+}
+
+// release releases the write lock and returns the callbacks queued while it was held, for the caller to run.
+// A nested release (see WithReentrantLocking) keeps the lock and the queued callbacks.
+func (m *SyncMap[K, V]) release() []func() {
+	if m.holders != nil && m.holders.leaveWrite() {
+		return nil
+	}
+
+	if m.compactAt > 0 && m.peak >= m.compactAt && len(m.data) <= m.peak/4 {
+		m.resize(len(m.data))
+	}
+	if m.readMostly && m.version.Load() != m.published {
+		m.publish()
+	}
+
+	pending := m.pending
+	m.pending = nil
+	m.locker().Unlock()
+	return pending
+}
+
+// resize copies the contents into a new map with room for capacity entries.
+// It assumes that the caller holds the write lock.
+func (m *SyncMap[K, V]) resize(capacity int) {
+	data := make(map[K]V, capacity)
+	maps.Copy(data, m.data)
+	m.data = data
+	m.peak = len(data)
+}
+
+// publish publishes a copy of the contents of the map for lock-free reads.
+// It assumes that the caller holds the write lock.
+func (m *SyncMap[K, V]) publish() {
+	m.snapshot.Store(&readSnapshot[K, V]{data: maps.Clone(m.data), expiry: m.unpinned(), meta: maps.Clone(m.meta)})
+	m.published = m.version.Load()
+}
+
+// readSnapshot is an immutable copy of the contents of a map, see WithReadMostly.
+type readSnapshot[K comparable, V any] struct {
+	data   map[K]V
+	expiry map[K]*deadline
+	meta   map[K]*entryMeta
+}
+
+func (s *readSnapshot[K, V]) load(k K, now func() time.Time) (V, bool) {
+	v, ok := s.data[k]
+	if ok && len(s.expiry) > 0 {
+		if d, ok := s.expiry[k]; ok && d.expiredAt(now()) {
+			var zero V
+			return zero, false
+		}
+	}
+	return v, ok
+}
+
+// deferCallback queues f to be run after the write lock is released.
+func (m *SyncMap[K, V]) deferCallback(f func()) {
+	m.pending = append(m.pending, f)
+}
+
+// The methods below implement the operations shared by SyncMap and lockedMap.
+// They expect normalized keys and assume that the caller holds the appropriate lock.
+
+// key returns the canonical form of k, as configured with WithKeyFunc.
+func (m *SyncMap[K, V]) key(k K) K {
+	if m.keyFn == nil {
+		return k
+	}
+	return m.keyFn(k)
+}
+
+// internKey returns the canonical copy of the new key k, and keeps its handle, see WithKeyInterning.
+// It assumes that the caller holds the write lock.
+func (m *SyncMap[K, V]) internKey(k K) K {
+	h := unique.Make(k)
+	k = h.Value()
+	if m.handles == nil {
+		m.handles = make(map[K]unique.Handle[K])
+	}
+	m.handles[k] = h
+	return k
+}
+
+func (m *SyncMap[K, V]) load(k K) (V, bool) {
+	v, ok := m.data[k]
+	if ok && m.isExpired(k) {
+		var zero V
+		return zero, false
+	}
+	if ok {
+		v = m.decode(v)
+	}
+	return v, ok
+}
+
+func (m *SyncMap[K, V]) store(k K, v V) error {
+	_, err := m.put(k, v, true)
+	return err
+}
+
+// put stores v for k, persisting it with the writer of the map, if any, if persist is set.
+// It reports whether k is in the map afterwards: a new key may not be admitted (see WithTinyLFUAdmission),
+// in which case callers must not attach any state to it.
+func (m *SyncMap[K, V]) put(k K, v V, persist bool) (bool, error) {
+	if err := m.validate(k, v); err != nil {
+		return false, err
+	}
+	encoded, err := m.encode(v)
+	if err != nil {
+		return false, err
+	}
+	cost, err := m.costOf(k, v)
+	if err != nil {
+		return false, err
+	}
+
+	old, exists := m.load(k)
+	if _, present := m.data[k]; present && !exists {
+		m.expire(k)
+	}
+	if exists && m.unchanged != nil && m.unchanged(old, v) {
+		return true, nil
+	}
+
+	if m.writer != nil && persist {
+		if err := m.persist(k, v); err != nil {
+			return false, err
+		}
+	}
+	if !exists && !m.admit(k) {
+		return false, nil
+	}
+
+	if !exists {
+		m.makeRoom()
+		if m.intern {
+			k = m.internKey(k)
+		}
+	}
+
+	if m.data == nil {
+		// the zero SyncMap has no map yet
+		m.data = make(map[K]V)
+	}
+	m.data[k] = encoded
+	delete(m.expiry, k)
+	delete(m.missing, k)
+	m.untag(k)
+	m.stamp(k, exists)
+	if m.defaultTTL > 0 {
+		if m.expiry == nil {
+			m.expiry = make(map[K]*deadline)
+		}
+		m.expiry[k] = newDeadline(m.clock(), m.defaultTTL)
+	}
+	if exists {
+		m.trackAccessed(k)
+	} else {
+		m.size.Add(1)
+		m.peak = max(m.peak, len(m.data))
+		m.trackAdded(k)
+	}
+	m.charge(k, cost)
+	version := m.version.Add(1)
+	m.checkSoftLimit()
+	m.stats.stored()
+	m.emit(Event[K, V]{Op: OpStore, Key: k, Old: old, HasOld: exists, New: v, Version: version})
+	m.evictOverBudget(k)
+	return true, nil
+}
+
+func (m *SyncMap[K, V]) loadOrStore(k K, v V) (V, bool, error) {
+	if old, ok := m.load(k); ok {
+		m.accessed(k)
+		return old, true, nil
+	}
+	m.stats.miss()
+
+	if err := m.store(k, v); err != nil {
+		var zero V
+		return zero, false, err
+	}
+	return v, false, nil
+}
+
+// validate reports whether the pair may be stored in the map.
+func (m *SyncMap[K, V]) validate(k K, v V) error {
+	var zero K
+	if m.forbidZeroKey && k == zero {
+		return ErrZeroKey
+	}
+	if m.sizer != nil {
+		if size := m.sizer(v); size > m.maxValueSize {
+			return fmt.Errorf("%w: %d bytes, limit is %d", ErrValueTooLarge, size, m.maxValueSize)
+		}
+	}
+	return nil
+}
+
+// check applies the misuse policy of the map to the error returned by op.
+// In strict mode it panics, otherwise the error is dropped.
+func (m *SyncMap[K, V]) check(op string, err error) {
+	if err != nil && m.strict {
+		if m.name != "" {
+			panic(fmt.Errorf("%w (in %s, map %q is in strict mode)", err, op, m.name))
+		}
+		panic(fmt.Errorf("%w (in %s, map is in strict mode)", err, op))
+	}
+}
+
+func (m *SyncMap[K, V]) remove(k K) (V, bool) {
+	if m.isExpired(k) {
+		m.expire(k)
+		var zero V
+		return zero, false
+	}
+
+	v, ok := m.load(k)
+	if ok {
+		delete(m.data, k)
+		m.forget(k)
+		m.size.Add(-1)
+		version := m.version.Add(1)
+		m.checkSoftLimit()
+		m.stats.deleted()
+		m.emit(Event[K, V]{Op: OpDelete, Key: k, Old: v, HasOld: true, Version: version})
+	}
+	return v, ok
+}
+
+// forget drops everything the map keeps about the entry of k besides its value, which was removed.
+func (m *SyncMap[K, V]) forget(k K) {
+	delete(m.expiry, k)
+	delete(m.pinned, k)
+	m.trackRemoved(k)
+	m.refund(k)
+	m.untag(k)
+	delete(m.meta, k)
+	delete(m.handles, k)
+}
+
+// replace replaces the contents of the map with data, skipping the pairs rejected by the map,
+// which are reported in the returned error. Only the previous entries whose key is not stored again
+// are handed to the Disposer.
+func (m *SyncMap[K, V]) replace(data map[K]V) error {
+	dropped := m.data
+	// purge hands nothing to the Disposer: the dropped entries are handed once data is stored
+	m.data = nil
+	m.purge()
+
+	var errs []error
+	for k, v := range data {
+		stored, err := m.put(m.key(k), v, true)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("key %v: %w", k, err))
+		}
+		if stored {
+			delete(dropped, m.key(k))
+		}
+	}
+	if m.dispose != nil {
+		m.disposeLater(m.decodeAll(dropped))
+	}
+	return errors.Join(errs...)
+}
+
+// drop removes k and hands its value to the Disposer, if any.
+// Unlike remove, it is meant for removals where the value is not returned to the caller.
+func (m *SyncMap[K, V]) drop(k K) bool {
+	v, ok := m.remove(k)
+	if ok {
+		m.disposeLater(map[K]V{k: v})
+	}
+	return ok
+}
+
+func (m *SyncMap[K, V]) purge() {
+	if m.dispose != nil {
+		m.disposeLater(m.decodeAll(m.data))
+	}
+	m.data = make(map[K]V)
+	m.expiry = nil
+	m.pinned = nil
+	m.missing = nil
+	m.costs = nil
+	m.totalCost = 0
+	m.tags = nil
+	m.keyTags = nil
+	m.meta = nil
+	m.handles = nil
+	m.trackReset()
+	m.size.Store(0)
+	m.peak = 0
+	version := m.version.Add(1)
+	m.checkSoftLimit()
+	m.emit(Event[K, V]{Op: OpPurge, Version: version})
+}
+
+// disposeLater queues the Disposer, if any, to be called for the given entries once the lock is released.
+func (m *SyncMap[K, V]) disposeLater(entries map[K]V) {
+	if m.dispose == nil || len(entries) == 0 {
+		return
+	}
+
+	dispose := m.dispose
+	m.deferCallback(
+		func() {
+			for k, v := range entries {
+				dispose(k, v)
+			}
+		},
+	)
+}
+
+// checkSoftLimit notifies the soft limit callback when the map grows to the soft limit,
+// and re-arms it once the map shrinks below the limit again.
+func (m *SyncMap[K, V]) checkSoftLimit() {
+	if m.softLimit <= 0 {
+		return
+	}
+
+	size := len(m.data)
+	switch {
+	case size < m.softLimit:
+		m.softLimitFired = false
+	case !m.softLimitFired:
+		m.softLimitFired = true
+		onSoftLimit := m.onSoftLimit
+		m.deferCallback(
+			func() {
+				onSoftLimit(size)
+			},
+		)
+	}
+}
@@ -1,10 +1,16 @@
 package syncmap
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"maps"
+	"slices"
 	"sort"
+	"strings"
 	"sync"
 	"testing"
+	"time"
 )
 
 func TestSyncMap(t *testing.T) {
@@ -79,7 +85,7 @@ func TestSyncMap(t *testing.T) {
 			)
 
 			expected := map[string]int{"key2": 4, "key3": 6, "key4": 8}
-			if !mapsEqual(doubled, expected) {
+			if !maps.Equal(doubled, expected) {
 				t.Errorf("Expected %v, got %v", expected, doubled)
 			}
 		},
@@ -94,7 +100,7 @@ func TestSyncMap(t *testing.T) {
 			)
 
 			expected := map[string]int{"key3": 3, "key4": 4}
-			if !mapsEqual(filtered, expected) {
+			if !maps.Equal(filtered, expected) {
 				t.Errorf("Expected %v, got %v", expected, filtered)
 			}
 		},
@@ -139,12 +145,68 @@ func TestSyncMap(t *testing.T) {
 			sort.Strings(keys)
 			sort.Ints(values)
 
-			if !slicesEqual(keys, expectedKeys) || !slicesEqual(values, expectedValues) {
+			if !slices.Equal(keys, expectedKeys) || !slices.Equal(values, expectedValues) {
 				t.Errorf("Range didn't return expected results")
 			}
 		},
 	)
 
+	t.Run(
+		"RangeMut", func(t *testing.T) {
+			sm.Store("key7", 7)
+			sm.Store("key8", 8)
+
+			sm.RangeMut(
+				func(m LockedMap[string, int], key string, value int) bool {
+					if value%2 == 0 {
+						m.Store(key, value*10)
+					} else {
+						m.Remove(key)
+					}
+					return true
+				},
+			)
+
+			expected := map[string]int{"key2": 20, "key4": 40, "key8": 80}
+			actual := sm.Filter(
+				func(k string, v int) bool {
+					return true
+				},
+			)
+			if !maps.Equal(actual, expected) {
+				t.Errorf("Expected %v, got %v", expected, actual)
+			}
+
+			sm.Remove("key8")
+			sm.Store("key2", 2)
+			sm.Store("key4", 4)
+		},
+	)
+
+	t.Run(
+		"ParallelRange", func(t *testing.T) {
+			var mu sync.Mutex
+			seen := make(map[string]int)
+
+			sm.ParallelRange(
+				4, func(key string, value int) {
+					// the callback works on a snapshot, so calling the map is safe
+					if _, ok := sm.Load(key); !ok {
+						t.Errorf("Expected key %s to be present", key)
+					}
+					mu.Lock()
+					seen[key] = value
+					mu.Unlock()
+				},
+			)
+
+			expected := map[string]int{"key2": 2, "key4": 4}
+			if !maps.Equal(seen, expected) {
+				t.Errorf("Expected %v, got %v", expected, seen)
+			}
+		},
+	)
+
 	t.Run(
 		"DoLocked", func(t *testing.T) {
 			sm.DoLocked(
@@ -217,26 +279,641 @@ func TestSyncMap(t *testing.T) {
 	)
 }
 
-func mapsEqual[K comparable, V comparable](m1, m2 map[K]V) bool {
-	if len(m1) != len(m2) {
-		return false
+func TestSyncMapLen(t *testing.T) {
+	sm := New[string, int](10)
+	check := func(expected int) {
+		t.Helper()
+		if sm.Len() != expected || sm.LenLocked() != expected {
+			t.Errorf("Expected %d, got Len %d and LenLocked %d", expected, sm.Len(), sm.LenLocked())
+		}
+	}
+
+	check(0)
+	sm.Store("key1", 1)
+	sm.Store("key2", 2)
+	sm.Store("key1", 3)
+	check(2)
+	sm.LoadOrStore("key3", 3)
+	sm.LoadOrStore("key3", 4)
+	check(3)
+	sm.Remove("key1")
+	sm.Remove("missing")
+	sm.LoadAndDelete("key2")
+	check(1)
+	sm.ReplaceAll(map[string]int{"a": 1, "b": 2})
+	check(2)
+	sm.Purge()
+	check(0)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				key := fmt.Sprint(j)
+				sm.Store(key, j)
+				sm.Len()
+				if j%3 == 0 {
+					sm.Remove(key)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	check(66)
+}
+
+func TestSyncMapRangeStable(t *testing.T) {
+	sm := New[string, int](10)
+	sm.Store("key1", 1)
+	sm.Store("key2", 2)
+	sm.Store("key3", 3)
+
+	var visited []string
+	sum := 0
+	sm.RangeStable(
+		func(key string, value int) bool {
+			if len(visited) == 0 {
+				// f runs without the lock held, so it may modify the map
+				sm.Store("new", 4)
+				for _, k := range []string{"key1", "key2", "key3"} {
+					if k != key {
+						sm.Store(k, 10)
+					}
+				}
+			}
+			visited = append(visited, key)
+			sum += value
+			return true
+		},
+	)
+
+	if len(visited) != 3 || slices.Contains(visited, "new") {
+		t.Errorf("Expected only the initial keys to be visited once, got %v", visited)
 	}
-	for k, v1 := range m1 {
-		if v2, ok := m2[k]; !ok || v1 != v2 {
+	if sum != 21 && sum != 22 && sum != 23 {
+		t.Errorf("Expected the values to be read live, got a sum of %d", sum)
+	}
+
+	count := 0
+	sm.RangeStable(
+		func(key string, value int) bool {
+			count++
+			sm.Purge()
+			return true
+		},
+	)
+	if count != 1 {
+		t.Errorf("Expected removed keys to be skipped, visited %d", count)
+	}
+
+	sm.Store("key1", 1)
+	sm.Store("key2", 2)
+	count = 0
+	sm.RangeStable(
+		func(key string, value int) bool {
+			count++
 			return false
+		},
+	)
+	if count != 1 {
+		t.Errorf("Expected RangeStable to stop after 1 entry, got %d", count)
+	}
+}
+
+func TestSyncMapGrow(t *testing.T) {
+	sm := New[int, int](0)
+	sm.Store(1, 1)
+	sm.Store(2, 2)
+	version := sm.Version()
+
+	sm.Grow(1000)
+	sm.Grow(0)
+	if sm.Version() != version {
+		t.Errorf("Expected Grow to keep version %d, got %d", version, sm.Version())
+	}
+	if !maps.Equal(sm.Filter(func(k, v int) bool { return true }), map[int]int{1: 1, 2: 2}) {
+		t.Error("Expected Grow to preserve the contents")
+	}
+}
+
+func TestSyncMapCompact(t *testing.T) {
+	sm := New[int, int](0)
+	for i := 0; i < 100; i++ {
+		sm.Store(i, i)
+	}
+	for i := 0; i < 90; i++ {
+		sm.Remove(i)
+	}
+	version := sm.Version()
+
+	sm.Compact()
+	if sm.peak != 10 {
+		t.Errorf("Expected the map to be rebuilt for 10 entries, got %d", sm.peak)
+	}
+	if sm.Version() != version {
+		t.Errorf("Expected Compact to keep version %d, got %d", version, sm.Version())
+	}
+	if sm.LenLocked() != 10 {
+		t.Errorf("Expected 10, got %d", sm.LenLocked())
+	}
+	for i := 90; i < 100; i++ {
+		if v, ok := sm.Load(i); !ok || v != i {
+			t.Errorf("Expected (%d, true), got (%v, %v)", i, v, ok)
 		}
 	}
-	return true
 }
 
-func slicesEqual[T comparable](s1, s2 []T) bool {
-	if len(s1) != len(s2) {
-		return false
+func TestSyncMapZeroValue(t *testing.T) {
+	var registry struct {
+		sessions SyncMap[string, int]
 	}
-	for i := range s1 {
-		if s1[i] != s2[i] {
-			return false
+	sm := &registry.sessions
+
+	if _, ok := sm.Load("key1"); ok {
+		t.Error("Load should return false on an empty map")
+	}
+	if sm.Len() != 0 || sm.Remove("key1") {
+		t.Error("Expected an empty map")
+	}
+	sm.Range(
+		func(key string, value int) bool {
+			t.Error("Range should not visit any entry of an empty map")
+			return true
+		},
+	)
+
+	sm.Store("key1", 1)
+	if v, loaded := sm.LoadOrStore("key2", 2); loaded || v != 2 {
+		t.Errorf("Expected (2, false), got (%v, %v)", v, loaded)
+	}
+	sm.DoLocked(
+		func(m LockedMap[string, int]) {
+			m.Store("key3", 3)
+		},
+	)
+	expected := map[string]int{"key1": 1, "key2": 2, "key3": 3}
+	if !maps.Equal(sm.Filter(func(k string, v int) bool { return true }), expected) {
+		t.Error("Unexpected contents")
+	}
+
+	var other SyncMap[string, int]
+	other.Purge()
+	other.Compact()
+	other.Store("key1", 1)
+	if v, ok := other.Load("key1"); !ok || v != 1 {
+		t.Errorf("Expected (1, true), got (%v, %v)", v, ok)
+	}
+	if !other.IsReady() {
+		t.Error("Expected the zero map to be ready")
+	}
+}
+
+func TestSyncMapContainsValue(t *testing.T) {
+	sm := New[string, string](10)
+	sm.Store("user1", "token-a")
+	sm.Store("user2", "token-b")
+
+	if !sm.ContainsValue("TOKEN-B", strings.EqualFold) {
+		t.Error("Expected token-b to be found")
+	}
+
+	if sm.ContainsValue("token-c", strings.EqualFold) {
+		t.Error("Expected token-c not to be found")
+	}
+}
+
+func TestSyncMapPartition(t *testing.T) {
+	sm := New[string, int](10)
+	sm.Store("key1", 1)
+	sm.Store("key2", 2)
+	sm.Store("key3", 3)
+	sm.Store("key4", 4)
+
+	even, odd := sm.Partition(
+		func(k string, v int) bool {
+			return v%2 == 0
+		},
+	)
+
+	if expected := map[string]int{"key2": 2, "key4": 4}; !maps.Equal(even, expected) {
+		t.Errorf("Expected %v, got %v", expected, even)
+	}
+	if expected := map[string]int{"key1": 1, "key3": 3}; !maps.Equal(odd, expected) {
+		t.Errorf("Expected %v, got %v", expected, odd)
+	}
+}
+
+func TestSyncMapPredicates(t *testing.T) {
+	sm := New[string, int](10)
+
+	positive := func(k string, v int) bool {
+		return v > 0
+	}
+	even := func(k string, v int) bool {
+		return v%2 == 0
+	}
+
+	t.Run(
+		"Empty map", func(t *testing.T) {
+			if sm.Any(positive) {
+				t.Error("Any should be false for an empty map")
+			}
+			if !sm.All(positive) {
+				t.Error("All should be true for an empty map")
+			}
+			if !sm.None(positive) {
+				t.Error("None should be true for an empty map")
+			}
+			if n := sm.Count(positive); n != 0 {
+				t.Errorf("Expected count 0, got %d", n)
+			}
+		},
+	)
+
+	t.Run(
+		"Non-empty map", func(t *testing.T) {
+			sm.Store("key1", 1)
+			sm.Store("key2", 2)
+			sm.Store("key3", 3)
+
+			if !sm.Any(even) {
+				t.Error("Any should be true")
+			}
+			if sm.All(even) {
+				t.Error("All should be false")
+			}
+			if !sm.All(positive) {
+				t.Error("All should be true")
+			}
+			if sm.None(even) {
+				t.Error("None should be false")
+			}
+			if n := sm.Count(even); n != 1 {
+				t.Errorf("Expected count 1, got %d", n)
+			}
+		},
+	)
+
+	t.Run(
+		"Short-circuit", func(t *testing.T) {
+			calls := 0
+			sm.Any(
+				func(k string, v int) bool {
+					calls++
+					return true
+				},
+			)
+			if calls != 1 {
+				t.Errorf("Expected Any to stop after 1 call, got %d", calls)
+			}
+		},
+	)
+}
+
+func TestSyncMapMinMaxBy(t *testing.T) {
+	sm := New[string, int](10)
+	less := func(a, b int) bool {
+		return a < b
+	}
+
+	if _, _, ok := sm.MaxBy(less); ok {
+		t.Error("MaxBy should return false for an empty map")
+	}
+	if _, _, ok := sm.MinBy(less); ok {
+		t.Error("MinBy should return false for an empty map")
+	}
+
+	sm.Store("key1", 5)
+	sm.Store("key2", 1)
+	sm.Store("key3", 9)
+
+	if k, v, ok := sm.MaxBy(less); !ok || k != "key3" || v != 9 {
+		t.Errorf("Expected key3: 9, got %s: %d", k, v)
+	}
+	if k, v, ok := sm.MinBy(less); !ok || k != "key2" || v != 1 {
+		t.Errorf("Expected key2: 1, got %s: %d", k, v)
+	}
+}
+
+func TestSyncMapTopN(t *testing.T) {
+	sm := New[string, int](100)
+	for i := 0; i < 100; i++ {
+		sm.Store(fmt.Sprintf("key%d", i), (i*37)%100)
+	}
+
+	byValue := func(a, b Entry[string, int]) bool {
+		return a.Value < b.Value
+	}
+
+	top := sm.TopN(3, byValue)
+	values := make([]int, 0, len(top))
+	for _, e := range top {
+		if v, _ := sm.Load(e.Key); v != e.Value {
+			t.Errorf("Entry %+v does not match the map", e)
 		}
+		values = append(values, e.Value)
+	}
+	if !slices.Equal(values, []int{99, 98, 97}) {
+		t.Errorf("Expected [99 98 97], got %v", values)
+	}
+
+	if all := sm.TopN(1000, byValue); len(all) != 100 {
+		t.Errorf("Expected all 100 entries, got %d", len(all))
+	}
+	if none := sm.TopN(0, byValue); len(none) != 0 {
+		t.Errorf("Expected no entries, got %d", len(none))
+	}
+}
+
+func TestSyncMapFindKeys(t *testing.T) {
+	sm := New[string, string](10)
+	sm.Store("user1", "admin")
+	sm.Store("user2", "guest")
+	sm.Store("user3", "admin")
+
+	keys := sm.FindKeys(
+		func(v string) bool {
+			return v == "admin"
+		},
+	)
+	sort.Strings(keys)
+	if !slices.Equal(keys, []string{"user1", "user3"}) {
+		t.Errorf("Expected [user1 user3], got %v", keys)
+	}
+
+	keys = sm.FindKeys(
+		func(v string) bool {
+			return v == "owner"
+		},
+	)
+	if len(keys) != 0 {
+		t.Errorf("Expected no keys, got %v", keys)
+	}
+}
+
+func TestSyncMapTryDoLocked(t *testing.T) {
+	sm := New[string, int](10)
+
+	if !sm.TryDoLocked(
+		func(m LockedMap[string, int]) {
+			m.Store("key1", 1)
+		},
+	) {
+		t.Error("TryDoLocked should succeed on an uncontended map")
+	}
+	if v, ok := sm.Load("key1"); !ok || v != 1 {
+		t.Errorf("Expected 1, got %v", v)
 	}
-	return true
+
+	inside := make(chan struct{})
+	release := make(chan struct{})
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+		sm.DoLocked(
+			func(m LockedMap[string, int]) {
+				close(inside)
+				<-release
+			},
+		)
+	}()
+
+	<-inside
+	called := false
+	if sm.TryDoLocked(
+		func(m LockedMap[string, int]) {
+			called = true
+		},
+	) {
+		t.Error("TryDoLocked should fail while the map is locked")
+	}
+	if called {
+		t.Error("The function should not be called when the lock is not acquired")
+	}
+
+	close(release)
+	<-done
+}
+
+func TestSyncMapDoLockedContext(t *testing.T) {
+	sm := New[string, int](10)
+
+	t.Run(
+		"Uncontended", func(t *testing.T) {
+			errFailed := errors.New("failed")
+			err := sm.DoLockedContext(
+				context.Background(), func(m LockedMap[string, int]) error {
+					m.Store("key1", 1)
+					return errFailed
+				},
+			)
+			if !errors.Is(err, errFailed) {
+				t.Errorf("Expected the callback error, got %v", err)
+			}
+			if v, ok := sm.Load("key1"); !ok || v != 1 {
+				t.Errorf("Expected 1, got %v", v)
+			}
+		},
+	)
+
+	t.Run(
+		"Cancelled context", func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+
+			err := sm.DoLockedContext(
+				ctx, func(m LockedMap[string, int]) error {
+					t.Error("The callback should not be called")
+					return nil
+				},
+			)
+			if !errors.Is(err, context.Canceled) {
+				t.Errorf("Expected context.Canceled, got %v", err)
+			}
+		},
+	)
+
+	t.Run(
+		"Timeout while contended", func(t *testing.T) {
+			inside := make(chan struct{})
+			release := make(chan struct{})
+			done := make(chan struct{})
+
+			go func() {
+				defer close(done)
+				sm.DoLocked(
+					func(m LockedMap[string, int]) {
+						close(inside)
+						<-release
+					},
+				)
+			}()
+
+			<-inside
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+			defer cancel()
+
+			err := sm.DoLockedContext(
+				ctx, func(m LockedMap[string, int]) error {
+					t.Error("The callback should not be called")
+					return nil
+				},
+			)
+			if !errors.Is(err, context.DeadlineExceeded) {
+				t.Errorf("Expected context.DeadlineExceeded, got %v", err)
+			}
+
+			close(release)
+			<-done
+
+			// the abandoned lock attempt must not leave the map locked
+			if v, ok := sm.Load("key1"); !ok || v != 1 {
+				t.Errorf("Expected 1, got %v", v)
+			}
+			sm.Store("key2", 2)
+		},
+	)
+}
+
+func TestSyncMapLoadOrMerge(t *testing.T) {
+	sm := New[string, []string](10)
+	appendTags := func(old, new []string) []string {
+		return append(slices.Clone(old), new...)
+	}
+
+	v, merged := sm.LoadOrMerge("doc1", []string{"a"}, appendTags)
+	if merged || !slices.Equal(v, []string{"a"}) {
+		t.Errorf("Expected [a] to be inserted, got %v, %v", v, merged)
+	}
+
+	v, merged = sm.LoadOrMerge("doc1", []string{"b", "c"}, appendTags)
+	if !merged || !slices.Equal(v, []string{"a", "b", "c"}) {
+		t.Errorf("Expected [a b c] after merge, got %v, %v", v, merged)
+	}
+
+	if stored, _ := sm.Load("doc1"); !slices.Equal(stored, []string{"a", "b", "c"}) {
+		t.Errorf("Expected [a b c] to be stored, got %v", stored)
+	}
+
+	t.Run(
+		"Concurrent merges", func(t *testing.T) {
+			counters := New[string, int](10)
+			sum := func(old, new int) int {
+				return old + new
+			}
+
+			var wg sync.WaitGroup
+			wg.Add(10)
+			for i := 0; i < 10; i++ {
+				go func() {
+					defer wg.Done()
+					for j := 0; j < 100; j++ {
+						counters.LoadOrMerge("hits", 1, sum)
+					}
+				}()
+			}
+			wg.Wait()
+
+			if v, _ := counters.Load("hits"); v != 1000 {
+				t.Errorf("Expected 1000, got %d", v)
+			}
+		},
+	)
+}
+
+func TestSyncMapDoLockedErr(t *testing.T) {
+	sm := New[string, int](10)
+	sm.Store("alice", 10)
+	sm.Store("bob", 0)
+
+	errInsufficient := errors.New("insufficient funds")
+	transfer := func(from, to string, amount int) error {
+		return sm.DoLockedErr(
+			func(m LockedMap[string, int]) error {
+				balance, _ := m.Load(from)
+				if balance < amount {
+					return errInsufficient
+				}
+				m.Store(from, balance-amount)
+				received, _ := m.Load(to)
+				m.Store(to, received+amount)
+				return nil
+			},
+		)
+	}
+
+	if err := transfer("alice", "bob", 7); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+	if err := transfer("alice", "bob", 7); !errors.Is(err, errInsufficient) {
+		t.Errorf("Expected errInsufficient, got %v", err)
+	}
+
+	if v, _ := sm.Load("alice"); v != 3 {
+		t.Errorf("Expected 3, got %d", v)
+	}
+	if v, _ := sm.Load("bob"); v != 7 {
+		t.Errorf("Expected 7, got %d", v)
+	}
+}
+
+func TestSyncMapStoreUntilDone(t *testing.T) {
+	waitRemoved := func(t *testing.T, sm *SyncMap[string, int], key string) {
+		t.Helper()
+		deadline := time.Now().Add(time.Second)
+		for time.Now().Before(deadline) {
+			if _, ok := sm.Load(key); !ok {
+				return
+			}
+			time.Sleep(time.Millisecond)
+		}
+		t.Errorf("Expected %q to be removed after the context was done", key)
+	}
+
+	t.Run(
+		"RemovedOnCancel", func(t *testing.T) {
+			sm := New[string, int](10)
+			ctx, cancel := context.WithCancel(context.Background())
+
+			sm.StoreUntilDone(ctx, "req1", 1)
+			if v, ok := sm.Load("req1"); !ok || v != 1 {
+				t.Errorf("Expected (1, true), got (%v, %v)", v, ok)
+			}
+
+			cancel()
+			waitRemoved(t, sm, "req1")
+		},
+	)
+
+	t.Run(
+		"AlreadyDone", func(t *testing.T) {
+			sm := New[string, int](10)
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+
+			sm.StoreUntilDone(ctx, "req1", 1)
+			waitRemoved(t, sm, "req1")
+		},
+	)
+
+	t.Run(
+		"Stop", func(t *testing.T) {
+			sm := New[string, int](10)
+			ctx, cancel := context.WithCancel(context.Background())
+
+			stop := sm.StoreUntilDone(ctx, "req1", 1)
+			if !stop() {
+				t.Error("Expected stop to cancel the pending removal")
+			}
+			cancel()
+
+			time.Sleep(10 * time.Millisecond)
+			if _, ok := sm.Load("req1"); !ok {
+				t.Error("Expected the entry to survive after stop")
+			}
+		},
+	)
 }
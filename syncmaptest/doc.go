@@ -0,0 +1,8 @@
+// Package syncmaptest provides utilities for testing map implementations
+// that expose the syncmap API.
+//
+// The Recorder captures the history of concurrent operations issued against
+// a map and CheckLinearizable verifies that history against a sequential map
+// model, which allows alternative backends (sharded, lock-free, ...) to be
+// validated with the same rigor as the reference SyncMap.
+package syncmaptest
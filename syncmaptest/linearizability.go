@@ -0,0 +1,158 @@
+package syncmaptest
+
+import (
+	"fmt"
+	"sort"
+)
+
+// CheckLinearizable reports whether the given history of operations is linearizable
+// with respect to a sequential map that starts out empty.
+//
+// Every operation in the history touches exactly one key, so the history is split
+// per key and each sub-history is checked independently (a map is linearizable
+// if and only if every key is). Each sub-history is checked with the
+// Wing & Gong search, memoizing already explored (linearized set, state) pairs.
+//
+// It returns nil if the history is linearizable, or an error naming the first key
+// whose sub-history could not be linearized.
+func CheckLinearizable[K comparable, V comparable](history []Operation[K, V]) error {
+	keys := make([]K, 0)
+	perKey := make(map[K][]Operation[K, V])
+
+	for _, op := range history {
+		if op.Return < op.Call {
+			return fmt.Errorf("syncmaptest: %s(%v) returned before it was called", op.Kind, op.Key)
+		}
+		if _, ok := perKey[op.Key]; !ok {
+			keys = append(keys, op.Key)
+		}
+		perKey[op.Key] = append(perKey[op.Key], op)
+	}
+
+	for _, k := range keys {
+		ops := perKey[k]
+		if !newChecker(ops).check() {
+			return fmt.Errorf("syncmaptest: history of key %v is not linearizable (%d operations)", k, len(ops))
+		}
+	}
+
+	return nil
+}
+
+// keyState is the sequential model of a single map entry.
+type keyState[V comparable] struct {
+	val     V
+	present bool
+}
+
+// apply executes op against the model and reports whether the results observed
+// by the real call match the ones the model produces.
+func (s keyState[V]) apply(kind OpKind, arg V, out V, ok bool) (keyState[V], bool) {
+	var zero V
+
+	switch kind {
+	case OpLoad:
+		if !s.present {
+			return s, !ok && out == zero
+		}
+		return s, ok && out == s.val
+	case OpStore:
+		return keyState[V]{val: arg, present: true}, true
+	case OpRemove:
+		return keyState[V]{}, ok == s.present
+	case OpLoadOrStore:
+		if s.present {
+			return s, ok && out == s.val
+		}
+		return keyState[V]{val: arg, present: true}, !ok && out == arg
+	case OpLoadAndDelete:
+		if !s.present {
+			return s, !ok && out == zero
+		}
+		return keyState[V]{}, ok && out == s.val
+	default:
+		return s, false
+	}
+}
+
+type cacheKey[V comparable] struct {
+	linearized string
+	state      keyState[V]
+}
+
+type checker[K comparable, V comparable] struct {
+	ops        []Operation[K, V]
+	linearized []bool
+	seen       map[cacheKey[V]]struct{}
+}
+
+func newChecker[K comparable, V comparable](ops []Operation[K, V]) *checker[K, V] {
+	sorted := make([]Operation[K, V], len(ops))
+	copy(sorted, ops)
+	sort.Slice(
+		sorted, func(i, j int) bool {
+			return sorted[i].Call < sorted[j].Call
+		},
+	)
+
+	return &checker[K, V]{
+		ops:        sorted,
+		linearized: make([]bool, len(sorted)),
+		seen:       make(map[cacheKey[V]]struct{}),
+	}
+}
+
+func (c *checker[K, V]) check() bool {
+	return c.search(keyState[V]{}, 0)
+}
+
+func (c *checker[K, V]) search(state keyState[V], done int) bool {
+	if done == len(c.ops) {
+		return true
+	}
+
+	// Only operations invoked before the earliest pending return may be linearized next.
+	minReturn := int64(-1)
+	for i, op := range c.ops {
+		if !c.linearized[i] && (minReturn < 0 || op.Return < minReturn) {
+			minReturn = op.Return
+		}
+	}
+
+	for i, op := range c.ops {
+		if op.Call > minReturn {
+			break
+		}
+		if c.linearized[i] {
+			continue
+		}
+
+		next, ok := state.apply(op.Kind, op.Arg, op.Out, op.OK)
+		if !ok {
+			continue
+		}
+
+		c.linearized[i] = true
+		key := cacheKey[V]{linearized: c.fingerprint(), state: next}
+		if _, explored := c.seen[key]; !explored {
+			c.seen[key] = struct{}{}
+			if c.search(next, done+1) {
+				return true
+			}
+		}
+		c.linearized[i] = false
+	}
+
+	return false
+}
+
+// fingerprint encodes the set of linearized operations as a comparable string.
+func (c *checker[K, V]) fingerprint() string {
+	buf := make([]byte, (len(c.linearized)+7)/8)
+	for i, l := range c.linearized {
+		if l {
+			buf[i/8] |= 1 << (i % 8)
+		}
+	}
+	return string(buf)
+}
@@ -0,0 +1,91 @@
+package syncmaptest
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/antst/go-syncmap"
+)
+
+func TestCheckLinearizable(t *testing.T) {
+	t.Run(
+		"SyncMap", func(t *testing.T) {
+			const goroutines = 8
+			const iterations = 200
+
+			rec := NewRecorder[int, int](syncmap.New[int, int](10))
+
+			var wg sync.WaitGroup
+			wg.Add(goroutines)
+
+			for i := 0; i < goroutines; i++ {
+				go func(id int) {
+					defer wg.Done()
+					for j := 0; j < iterations; j++ {
+						key := j % 3
+						switch (id + j) % 5 {
+						case 0:
+							rec.Store(key, id*iterations+j)
+						case 1:
+							rec.Load(key)
+						case 2:
+							rec.Remove(key)
+						case 3:
+							rec.LoadOrStore(key, id*iterations+j)
+						case 4:
+							rec.LoadAndDelete(key)
+						}
+					}
+				}(i)
+			}
+
+			wg.Wait()
+
+			if n := len(rec.History()); n != goroutines*iterations {
+				t.Fatalf("Expected %d recorded operations, got %d", goroutines*iterations, n)
+			}
+			if err := rec.Check(); err != nil {
+				t.Error(err)
+			}
+		},
+	)
+
+	t.Run(
+		"Concurrent operations may be reordered", func(t *testing.T) {
+			// Load overlaps with Store, so it may observe either state.
+			history := []Operation[string, int]{
+				{Kind: OpStore, Key: "a", Arg: 1, Call: 1, Return: 4},
+				{Kind: OpLoad, Key: "a", Out: 1, OK: true, Call: 2, Return: 3},
+			}
+			if err := CheckLinearizable(history); err != nil {
+				t.Error(err)
+			}
+		},
+	)
+
+	t.Run(
+		"Stale read is detected", func(t *testing.T) {
+			// Load starts after Store returned, so it must observe the stored value.
+			history := []Operation[string, int]{
+				{Kind: OpStore, Key: "a", Arg: 1, Call: 1, Return: 2},
+				{Kind: OpLoad, Key: "a", Call: 3, Return: 4},
+			}
+			if err := CheckLinearizable(history); err == nil {
+				t.Error("Expected stale read to be reported as not linearizable")
+			}
+		},
+	)
+
+	t.Run(
+		"Lost removal is detected", func(t *testing.T) {
+			history := []Operation[string, int]{
+				{Kind: OpStore, Key: "a", Arg: 1, Call: 1, Return: 2},
+				{Kind: OpRemove, Key: "a", OK: true, Call: 3, Return: 4},
+				{Kind: OpRemove, Key: "a", OK: true, Call: 5, Return: 6},
+			}
+			if err := CheckLinearizable(history); err == nil {
+				t.Error("Expected double removal to be reported as not linearizable")
+			}
+		},
+	)
+}
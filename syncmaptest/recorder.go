@@ -0,0 +1,149 @@
+package syncmaptest
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// Map is the subset of the SyncMap API exercised by the Recorder.
+// Any backend that wants to be checked for linearizability has to implement it.
+//
+// Type parameters:
+//   - K: must be a comparable type (used as map keys)
+//   - V: must be a comparable type, so that observed values can be checked against the model
+type Map[K comparable, V comparable] interface {
+	Load(k K) (V, bool)
+	Store(k K, v V)
+	Remove(k K) bool
+	LoadOrStore(k K, v V) (V, bool)
+	LoadAndDelete(k K) (V, bool)
+}
+
+// OpKind identifies the map operation captured in an Operation.
+type OpKind int
+
+const (
+	OpLoad OpKind = iota
+	OpStore
+	OpRemove
+	OpLoadOrStore
+	OpLoadAndDelete
+)
+
+// String returns the name of the operation kind.
+func (k OpKind) String() string {
+	switch k {
+	case OpLoad:
+		return "Load"
+	case OpStore:
+		return "Store"
+	case OpRemove:
+		return "Remove"
+	case OpLoadOrStore:
+		return "LoadOrStore"
+	case OpLoadAndDelete:
+		return "LoadAndDelete"
+	default:
+		return "Unknown"
+	}
+}
+
+// Operation is a single completed call captured by the Recorder.
+//
+// Call and Return are logical timestamps taken from a shared counter right before
+// the call is issued and right after it returns, so that a.Return < b.Call means
+// that a happened before b in real time.
+type Operation[K comparable, V comparable] struct {
+	Kind OpKind
+	Key  K
+	// Arg is the value passed to Store or LoadOrStore.
+	Arg V
+	// Out is the value returned by Load, LoadOrStore or LoadAndDelete.
+	Out V
+	// OK is the boolean result of the call, if the operation has one.
+	OK     bool
+	Call   int64
+	Return int64
+}
+
+// Recorder wraps a Map and records every call made through it.
+// The wrapped map must be empty when recording starts, because the model used
+// by CheckLinearizable starts from an empty map.
+type Recorder[K comparable, V comparable] struct {
+	m     Map[K, V]
+	clock atomic.Int64
+
+	mu  sync.Mutex
+	ops []Operation[K, V]
+}
+
+// NewRecorder creates a Recorder that forwards calls to m.
+func NewRecorder[K comparable, V comparable](m Map[K, V]) *Recorder[K, V] {
+	return &Recorder[K, V]{m: m}
+}
+
+// Load calls Load on the wrapped map and records the operation.
+func (r *Recorder[K, V]) Load(k K) (V, bool) {
+	call := r.clock.Add(1)
+	v, ok := r.m.Load(k)
+	r.record(Operation[K, V]{Kind: OpLoad, Key: k, Out: v, OK: ok, Call: call, Return: r.clock.Add(1)})
+	return v, ok
+}
+
+// Store calls Store on the wrapped map and records the operation.
+func (r *Recorder[K, V]) Store(k K, v V) {
+	call := r.clock.Add(1)
+	r.m.Store(k, v)
+	r.record(Operation[K, V]{Kind: OpStore, Key: k, Arg: v, Call: call, Return: r.clock.Add(1)})
+}
+
+// Remove calls Remove on the wrapped map and records the operation.
+func (r *Recorder[K, V]) Remove(k K) bool {
+	call := r.clock.Add(1)
+	ok := r.m.Remove(k)
+	r.record(Operation[K, V]{Kind: OpRemove, Key: k, OK: ok, Call: call, Return: r.clock.Add(1)})
+	return ok
+}
+
+// LoadOrStore calls LoadOrStore on the wrapped map and records the operation.
+func (r *Recorder[K, V]) LoadOrStore(k K, v V) (V, bool) {
+	call := r.clock.Add(1)
+	out, loaded := r.m.LoadOrStore(k, v)
+	r.record(
+		Operation[K, V]{
+			Kind: OpLoadOrStore, Key: k, Arg: v, Out: out, OK: loaded, Call: call, Return: r.clock.Add(1),
+		},
+	)
+	return out, loaded
+}
+
+// LoadAndDelete calls LoadAndDelete on the wrapped map and records the operation.
+func (r *Recorder[K, V]) LoadAndDelete(k K) (V, bool) {
+	call := r.clock.Add(1)
+	v, ok := r.m.LoadAndDelete(k)
+	r.record(Operation[K, V]{Kind: OpLoadAndDelete, Key: k, Out: v, OK: ok, Call: call, Return: r.clock.Add(1)})
+	return v, ok
+}
+
+// History returns a copy of all operations recorded so far.
+func (r *Recorder[K, V]) History() []Operation[K, V] {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	ops := make([]Operation[K, V], len(r.ops))
+	copy(ops, r.ops)
+	return ops
+}
+
+// Check verifies that the recorded history is linearizable.
+// It is a shorthand for CheckLinearizable(r.History()).
+func (r *Recorder[K, V]) Check() error {
+	return CheckLinearizable(r.History())
+}
+
+func (r *Recorder[K, V]) record(op Operation[K, V]) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.ops = append(r.ops, op)
+}
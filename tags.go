@@ -0,0 +1,82 @@
+package syncmap
+
+import (
+	"slices"
+)
+
+// StoreTagged sets the value for a key and attaches the given tags to the entry (e.g. a tenant
+// or a configuration version), replacing the tags it had, so that all the entries with a tag can be
+// removed at once with InvalidateTag. Storing the key again with any other method removes its tags.
+// It acquires a write lock to ensure thread-safe access to the underlying data.
+func (m *SyncMap[K, V]) StoreTagged(k K, v V, tags ...string) {
+	m.lock()
+	defer m.unlock()
+
+	k = m.key(k)
+	stored, err := m.put(k, v, true)
+	if err != nil {
+		m.check("StoreTagged", err)
+		return
+	}
+	if !stored {
+		// the write was not admitted, see WithTinyLFUAdmission
+		return
+	}
+
+	if m.tags == nil {
+		m.tags = make(map[string]map[K]struct{})
+		m.keyTags = make(map[K][]string)
+	}
+	for _, tag := range tags {
+		if m.tags[tag] == nil {
+			m.tags[tag] = make(map[K]struct{})
+		}
+		m.tags[tag][k] = struct{}{}
+	}
+	m.keyTags[k] = slices.Clone(tags)
+}
+
+// InvalidateTag removes all the entries with the given tag, under a single write lock,
+// and returns how many were removed. The removals are reported as OpDelete events
+// and the values are handed to the Disposer, if any, as with Remove.
+func (m *SyncMap[K, V]) InvalidateTag(tag string) int {
+	m.lock()
+	defer m.unlock()
+
+	n := 0
+	for k := range m.tags[tag] {
+		if m.drop(k) {
+			n++
+		}
+	}
+	return n
+}
+
+// Tags returns the tags of the entry of k, or nil if it has none.
+// It acquires a read lock to ensure thread-safe access to the underlying data.
+func (m *SyncMap[K, V]) Tags(k K) []string {
+	m.rlock()
+	defer m.runlock()
+
+	k = m.key(k)
+	if _, ok := m.load(k); !ok {
+		return nil
+	}
+	return slices.Clone(m.keyTags[k])
+}
+
+// untag removes the tags of the entry of k. It assumes that the caller holds the write lock.
+func (m *SyncMap[K, V]) untag(k K) {
+	tags, ok := m.keyTags[k]
+	if !ok {
+		return
+	}
+
+	for _, tag := range tags {
+		delete(m.tags[tag], k)
+		if len(m.tags[tag]) == 0 {
+			delete(m.tags, tag)
+		}
+	}
+	delete(m.keyTags, k)
+}
@@ -0,0 +1,81 @@
+package syncmap
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestSyncMapTags(t *testing.T) {
+	t.Run(
+		"InvalidateTag", func(t *testing.T) {
+			sm := New[string, int](10)
+			sm.StoreTagged("a", 1, "tenant:1")
+			sm.StoreTagged("b", 2, "tenant:1", "config:v2")
+			sm.StoreTagged("c", 3, "tenant:2")
+			sm.Store("d", 4)
+
+			if tags := sm.Tags("b"); !slices.Equal(tags, []string{"tenant:1", "config:v2"}) {
+				t.Errorf("Expected the tags of b, got %v", tags)
+			}
+			if n := sm.InvalidateTag("tenant:1"); n != 2 {
+				t.Errorf("Expected 2 entries to be removed, got %d", n)
+			}
+			for _, k := range []string{"a", "b"} {
+				if _, ok := sm.Load(k); ok {
+					t.Errorf("Expected %s to be removed", k)
+				}
+			}
+			if sm.Len() != 2 {
+				t.Errorf("Expected 2 entries to remain, got %d", sm.Len())
+			}
+			if n := sm.InvalidateTag("config:v2"); n != 0 {
+				t.Errorf("Expected no entry to be left with the tag, got %d", n)
+			}
+			if n := sm.InvalidateTag("unknown"); n != 0 {
+				t.Errorf("Expected no entry to be removed, got %d", n)
+			}
+		},
+	)
+
+	t.Run(
+		"Retag", func(t *testing.T) {
+			sm := New[string, int](10)
+			sm.StoreTagged("a", 1, "x")
+			sm.StoreTagged("a", 2, "y")
+			if n := sm.InvalidateTag("x"); n != 0 {
+				t.Errorf("Expected the old tag to be replaced, got %d removed", n)
+			}
+
+			sm.Store("a", 3)
+			if tags := sm.Tags("a"); tags != nil {
+				t.Errorf("Expected a plain store to remove the tags, got %v", tags)
+			}
+			if n := sm.InvalidateTag("y"); n != 0 || sm.Len() != 1 {
+				t.Errorf("Expected the entry to be kept, got %d removed", n)
+			}
+		},
+	)
+
+	t.Run(
+		"RemovedEntries", func(t *testing.T) {
+			clock := newFakeClock()
+			sm := New[string, int](10, WithClock[string, int](clock.Now), WithMaxEntries[string, int](2))
+			sm.StoreTagged("a", 1, "t")
+			sm.StoreTagged("b", 2, "t")
+			sm.StoreTagged("c", 3, "t") // evicts a
+			sm.Remove("b")
+			sm.ExpireAt("c", clock.Now().Add(time.Second))
+			clock.Advance(time.Minute)
+			sm.RemoveExpired()
+
+			sm.Store("a", 1)
+			if n := sm.InvalidateTag("t"); n != 0 {
+				t.Errorf("Expected the tags of removed entries to be dropped, got %d removed", n)
+			}
+			if len(sm.tags) != 0 || len(sm.keyTags) != 0 {
+				t.Errorf("Expected the tag index to be empty, got %v and %v", sm.tags, sm.keyTags)
+			}
+		},
+	)
+}
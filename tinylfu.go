@@ -0,0 +1,140 @@
+package syncmap
+
+import (
+	"hash/maphash"
+	"math/bits"
+)
+
+// WithTinyLFUAdmission adds a TinyLFU admission filter to a SyncMap bounded WithMaxEntries:
+// when the map is full, a new key is only stored if it has been used more often recently than
+// the entry that would be evicted for it. Otherwise, the write is dropped (without error or event),
+// so that a long tail of keys used once cannot flush frequently used entries out of the map.
+// Uses are counted by Load (hit or miss) and writes, admitted or not, in a compact frequency sketch that is
+// halved periodically, so that past popularity fades.
+func WithTinyLFUAdmission[K comparable, V any]() Option[K, V] {
+	return func(m *SyncMap[K, V]) {
+		m.admission = &frequencySketch[K]{seed: maphash.MakeSeed()}
+	}
+}
+
+// admit reports whether a new key may be stored, evicting another entry if the map is full.
+// A rejected write is still counted as a use of k (admitted ones are counted by trackAdded),
+// so that a key written repeatedly, by Store, LoadOrStore, etc., is eventually admitted.
+// It assumes that the caller holds the write lock.
+func (m *SyncMap[K, V]) admit(k K) bool {
+	if m.admission == nil || m.maxEntries <= 0 || len(m.data) < m.maxEntries {
+		return true
+	}
+
+	victim, ok := m.victim()
+	if !ok {
+		return true
+	}
+	m.policyMu.Lock()
+	defer m.policyMu.Unlock()
+	if m.admission.estimate(k) > m.admission.estimate(victim) {
+		return true
+	}
+	m.recordUse(k)
+	return false
+}
+
+// trackMissed reports a Load of a key that is absent to the admission filter, if any.
+// Callers hold at least the read lock.
+func (m *SyncMap[K, V]) trackMissed(k K) {
+	if m.admission == nil {
+		return
+	}
+	m.policyMu.Lock()
+	m.recordUse(k)
+	m.policyMu.Unlock()
+}
+
+// recordUse counts a use of k in the admission filter, if any. It assumes that the caller holds policyMu.
+func (m *SyncMap[K, V]) recordUse(k K) {
+	if m.admission == nil {
+		return
+	}
+	if m.admission.rows[0] == nil {
+		m.admission.init(m.maxEntries)
+	}
+	m.admission.increment(k)
+}
+
+// sketchDepth is the number of counters per key of a frequencySketch.
+const sketchDepth = 4
+
+// frequencySketch is a count-min sketch estimating how often keys were used recently, with 4-bit counters.
+// After a number of increments proportional to its size, all its counters are halved.
+type frequencySketch[K comparable] struct {
+	seed maphash.Seed
+	// sketchDepth rows of counters, each packed 2 per byte
+	rows [sketchDepth][]byte
+	// increments since the counters were last halved
+	samples int
+	// number of increments after which the counters are halved
+	period int
+}
+
+// init sizes the sketch for the given number of keys: 4 counters per key and row keep collisions rare,
+// and halving the counters after 10 increments per key lets the sketch adapt quickly.
+func (s *frequencySketch[K]) init(keys int) {
+	keys = max(keys, 16)
+	width := 1 << bits.Len(uint(4*keys-1))
+	for i := range s.rows {
+		s.rows[i] = make([]byte, width/2)
+	}
+	s.samples = 0
+	s.period = 10 * keys
+}
+
+// indexes returns the position of the counter of k in each row.
+func (s *frequencySketch[K]) indexes(k K) [sketchDepth]int {
+	h := maphash.Comparable(s.seed, k)
+	width := uint64(2 * len(s.rows[0]))
+	var indexes [sketchDepth]int
+	for i := range indexes {
+		// derive independent positions from the two halves of the hash
+		indexes[i] = int((h + uint64(i)*(h>>32|1)) % width)
+	}
+	return indexes
+}
+
+func (s *frequencySketch[K]) counter(row, i int) byte {
+	return s.rows[row][i/2] >> (4 * (i % 2)) & 0xF
+}
+
+func (s *frequencySketch[K]) increment(k K) {
+	for row, i := range s.indexes(k) {
+		if s.counter(row, i) < 0xF {
+			s.rows[row][i/2] += 1 << (4 * (i % 2))
+		}
+	}
+
+	s.samples++
+	if s.samples >= s.period {
+		s.halve()
+	}
+}
+
+func (s *frequencySketch[K]) estimate(k K) byte {
+	if s.rows[0] == nil {
+		return 0
+	}
+
+	estimate := byte(0xF)
+	for row, i := range s.indexes(k) {
+		estimate = min(estimate, s.counter(row, i))
+	}
+	return estimate
+}
+
+// halve divides all the counters by two.
+func (s *frequencySketch[K]) halve() {
+	for _, row := range s.rows {
+		for i, b := range row {
+			row[i] = (b >> 1) & 0x77
+		}
+	}
+	s.samples /= 2
+}
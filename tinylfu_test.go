@@ -0,0 +1,151 @@
+package syncmap
+
+import (
+	"hash/maphash"
+	"testing"
+	"time"
+)
+
+func TestWithTinyLFUAdmission(t *testing.T) {
+	t.Run(
+		"OneHitWonders", func(t *testing.T) {
+			// the hot keys keep being used amid a long tail of keys used once,
+			// read then stored on a miss as a cache-aside reader would
+			hotKeysLeft := func(opts ...Option[int, int]) int {
+				sm := New[int, int](100, append(opts, WithMaxEntries[int, int](100))...)
+				for i := range 10000 {
+					if _, ok := sm.Load(i % 100); !ok {
+						sm.Store(i%100, i)
+					}
+					if _, ok := sm.Load(1000 + i); !ok {
+						sm.Store(1000+i, i)
+					}
+				}
+
+				n := 0
+				for i := range 100 {
+					if _, ok := sm.Load(i); ok {
+						n++
+					}
+				}
+				return n
+			}
+
+			lru, tinyLFU := hotKeysLeft(), hotKeysLeft(WithTinyLFUAdmission[int, int]())
+			if tinyLFU < 90 || tinyLFU <= lru {
+				t.Errorf("Expected most hot keys to stay with TinyLFU admission, got %d (%d with LRU alone)", tinyLFU, lru)
+			}
+		},
+	)
+
+	t.Run(
+		"FrequentKeysAreAdmitted", func(t *testing.T) {
+			sm := New[int, int](2, WithMaxEntries[int, int](2), WithTinyLFUAdmission[int, int]())
+			sm.Store(1, 1)
+			sm.Store(2, 2)
+			for range 5 {
+				sm.Load(3)
+			}
+			sm.Store(3, 3)
+			if _, ok := sm.Load(3); !ok {
+				t.Error("Expected the frequently requested key to be admitted")
+			}
+			if sm.Len() != 2 {
+				t.Errorf("Expected 2 entries, got %d", sm.Len())
+			}
+		},
+	)
+
+	t.Run(
+		"RepeatedWrites", func(t *testing.T) {
+			for name, write := range map[string]func(sm *SyncMap[int, int]){
+				"Store":       func(sm *SyncMap[int, int]) { sm.Store(3, 3) },
+				"LoadOrStore": func(sm *SyncMap[int, int]) { sm.LoadOrStore(3, 3) },
+			} {
+				sm := New[int, int](2, WithMaxEntries[int, int](2), WithTinyLFUAdmission[int, int]())
+				sm.Store(1, 1)
+				sm.Store(2, 2)
+				for range 5 {
+					write(sm)
+				}
+				if _, ok := sm.Load(3); !ok {
+					t.Errorf("Expected the key written repeatedly with %s to be admitted", name)
+				}
+				if sm.Len() != 2 {
+					t.Errorf("Expected 2 entries with %s, got %d", name, sm.Len())
+				}
+			}
+		},
+	)
+
+	t.Run(
+		"RejectedWithTTL", func(t *testing.T) {
+			clock := newFakeClock()
+			var evicted []string
+			sm := New[string, int](
+				2,
+				WithClock[string, int](clock.Now),
+				WithMaxEntries[string, int](2),
+				WithTinyLFUAdmission[string, int](),
+				WithEvictionCallback(func(k string, v int, reason EvictionReason) { evicted = append(evicted, k) }),
+			)
+			sm.Store("a", 1)
+			sm.Store("b", 2)
+			for range 5 {
+				sm.Load("a")
+				sm.Load("b")
+			}
+
+			sm.StoreWithTTL("c", 3, time.Second)
+			if _, ok := sm.GetTTL("c"); ok {
+				t.Error("Expected no TTL for a key that was not admitted")
+			}
+			clock.Advance(time.Minute)
+			if n := sm.RemoveExpired(); n != 0 {
+				t.Errorf("Expected no expired entry, got %d", n)
+			}
+			if sm.Len() != 2 || sm.LenLocked() != 2 {
+				t.Errorf("Expected 2 entries, got %d (%d locked)", sm.Len(), sm.LenLocked())
+			}
+			if len(evicted) != 0 {
+				t.Errorf("Expected no eviction, got %v", evicted)
+			}
+		},
+	)
+
+	t.Run(
+		"NotFull", func(t *testing.T) {
+			sm := New[int, int](10, WithMaxEntries[int, int](10), WithTinyLFUAdmission[int, int]())
+			for i := range 10 {
+				sm.Store(i, i)
+			}
+			if sm.Len() != 10 {
+				t.Errorf("Expected all the keys to be admitted while the map is not full, got %d", sm.Len())
+			}
+		},
+	)
+}
+
+func TestFrequencySketch(t *testing.T) {
+	s := &frequencySketch[string]{seed: maphash.MakeSeed()}
+	if s.estimate("a") != 0 {
+		t.Error("Expected a zero estimate before any use")
+	}
+
+	s.init(100)
+	for range 20 {
+		s.increment("a")
+	}
+	s.increment("b")
+	if e := s.estimate("a"); e != 15 {
+		t.Errorf("Expected the estimate to saturate at 15, got %d", e)
+	}
+	if e := s.estimate("b"); e < 1 {
+		t.Errorf("Expected an estimate of at least 1, got %d", e)
+	}
+
+	s.halve()
+	if e := s.estimate("a"); e != 7 {
+		t.Errorf("Expected the halved estimate to be 7, got %d", e)
+	}
+}
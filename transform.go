@@ -0,0 +1,81 @@
+package syncmap
+
+import (
+	"fmt"
+)
+
+// ValueTransformer is a reversible transformation of the values of a SyncMap, such as compression or encryption.
+// Decode must reverse Encode: Decode(Encode(v)) must return v.
+type ValueTransformer[V any] interface {
+	// Encode transforms a value before it is stored. An error rejects the write.
+	Encode(v V) (V, error)
+	// Decode reverses Encode when a value is read.
+	// Decode must not fail for values returned by Encode; if it does, the read panics.
+	Decode(v V) (V, error)
+}
+
+// WithValueTransformers sets an ordered chain of ValueTransformers: every stored value is encoded
+// by each transformer in turn, and every value read is decoded by each transformer in reverse order,
+// so that e.g. validation, compression and encryption compose predictably:
+//
+//	WithValueTransformers[string, []byte](Validator(checkSchema), compressor, encryptor)
+//
+// The map holds encoded values, but all its methods, events and the Disposer deal in decoded values.
+// Values are encoded after the validation of WithMaxValueSize, so the limit applies to decoded values.
+// Rejected writes are reported as ErrTransform errors.
+func WithValueTransformers[K comparable, V any](transformers ...ValueTransformer[V]) Option[K, V] {
+	return func(m *SyncMap[K, V]) {
+		m.transformers = transformers
+	}
+}
+
+// Validator returns a ValueTransformer that leaves values unchanged, but rejects the writes
+// of values for which validate returns an error.
+func Validator[V any](validate func(v V) error) ValueTransformer[V] {
+	return validator[V](validate)
+}
+
+type validator[V any] func(v V) error
+
+func (f validator[V]) Encode(v V) (V, error) {
+	return v, f(v)
+}
+
+func (f validator[V]) Decode(v V) (V, error) {
+	return v, nil
+}
+
+// encode applies the transformers of the map to v, in order.
+func (m *SyncMap[K, V]) encode(v V) (V, error) {
+	for i, t := range m.transformers {
+		var err error
+		if v, err = t.Encode(v); err != nil {
+			return v, fmt.Errorf("%w: transformer %d: %w", ErrTransform, i, err)
+		}
+	}
+	return v, nil
+}
+
+// decode reverses the transformers of the map on v, in reverse order.
+func (m *SyncMap[K, V]) decode(v V) V {
+	for i := len(m.transformers) - 1; i >= 0; i-- {
+		var err error
+		if v, err = m.transformers[i].Decode(v); err != nil {
+			panic(fmt.Errorf("syncmap: decoding a stored value: transformer %d: %w", i, err))
+		}
+	}
+	return v
+}
+
+// decodeAll returns the entries of data with decoded values.
+func (m *SyncMap[K, V]) decodeAll(data map[K]V) map[K]V {
+	if len(m.transformers) == 0 {
+		return data
+	}
+
+	decoded := make(map[K]V, len(data))
+	for k, v := range data {
+		decoded[k] = m.decode(v)
+	}
+	return decoded
+}
@@ -0,0 +1,135 @@
+package syncmap
+
+import (
+	"errors"
+	"maps"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// prefixer is a reversible transformer that prefixes values, recording the order of its calls.
+type prefixer struct {
+	prefix string
+	calls  *[]string
+}
+
+func (p prefixer) Encode(v string) (string, error) {
+	*p.calls = append(*p.calls, "encode "+p.prefix)
+	return p.prefix + v, nil
+}
+
+func (p prefixer) Decode(v string) (string, error) {
+	*p.calls = append(*p.calls, "decode "+p.prefix)
+	if !strings.HasPrefix(v, p.prefix) {
+		return "", errors.New("missing prefix")
+	}
+	return strings.TrimPrefix(v, p.prefix), nil
+}
+
+func TestWithValueTransformers(t *testing.T) {
+	t.Run(
+		"Chain", func(t *testing.T) {
+			var calls []string
+			sm := New[string, string](
+				10,
+				WithValueTransformers[string, string](prefixer{"a:", &calls}, prefixer{"b:", &calls}),
+			)
+			sm.Store("k", "v")
+			if v, ok := sm.Load("k"); !ok || v != "v" {
+				t.Errorf("Expected (v, true), got (%v, %v)", v, ok)
+			}
+			expected := []string{"encode a:", "encode b:", "decode b:", "decode a:"}
+			if !slices.Equal(calls, expected) {
+				t.Errorf("Expected %v, got %v", expected, calls)
+			}
+
+			sm.DoLocked(
+				func(m LockedMap[string, string]) {
+					if raw := m.syncMap().data["k"]; raw != "b:a:v" {
+						t.Errorf("Expected the map to hold b:a:v, got %v", raw)
+					}
+				},
+			)
+		},
+	)
+
+	t.Run(
+		"ReadsAreDecoded", func(t *testing.T) {
+			var calls []string
+			var disposed, deleted []string
+			sm := New[string, string](
+				10,
+				WithValueTransformers[string, string](prefixer{"x:", &calls}),
+				WithDisposer[string, string](
+					func(k, v string) {
+						disposed = append(disposed, v)
+					},
+				),
+				WithEventHook[string, string](
+					func(e Event[string, string]) {
+						if e.Op == OpDelete {
+							deleted = append(deleted, e.Old)
+						}
+					},
+				),
+			)
+			sm.Store("a", "1")
+			sm.Store("b", "2")
+
+			expected := map[string]string{"a": "1", "b": "2"}
+			if got := sm.Filter(func(k, v string) bool { return true }); !maps.Equal(got, expected) {
+				t.Errorf("Expected %v, got %v", expected, got)
+			}
+			if v, ok := sm.LoadAndDelete("a"); !ok || v != "1" {
+				t.Errorf("Expected (1, true), got (%v, %v)", v, ok)
+			}
+			sm.Purge()
+			if !slices.Equal(deleted, []string{"1"}) || !slices.Equal(disposed, []string{"2"}) {
+				t.Errorf("Expected decoded values in events and the Disposer, got %v and %v", deleted, disposed)
+			}
+		},
+	)
+
+	t.Run(
+		"Validator", func(t *testing.T) {
+			sm := New[string, string](
+				10,
+				WithValueTransformers[string, string](
+					Validator(
+						func(v string) error {
+							if v == "" {
+								return errors.New("empty value")
+							}
+							return nil
+						},
+					),
+				),
+			)
+			if err := sm.TryStore("k", ""); !errors.Is(err, ErrTransform) {
+				t.Errorf("Expected ErrTransform, got %v", err)
+			}
+			if err := sm.TryStore("k", "v"); err != nil {
+				t.Errorf("Expected no error, got %v", err)
+			}
+			if sm.Len() != 1 {
+				t.Errorf("Expected 1 entry, got %d", sm.Len())
+			}
+		},
+	)
+
+	t.Run(
+		"ReadMostly", func(t *testing.T) {
+			var calls []string
+			sm := New[string, string](
+				10,
+				WithValueTransformers[string, string](prefixer{"x:", &calls}),
+				WithReadMostly[string, string](),
+			)
+			sm.Store("k", "v")
+			if v, ok := sm.Load("k"); !ok || v != "v" {
+				t.Errorf("Expected (v, true), got (%v, %v)", v, ok)
+			}
+		},
+	)
+}
@@ -0,0 +1,387 @@
+package syncmap
+
+import (
+	"context"
+	"iter"
+	"slices"
+	"sync/atomic"
+	"time"
+)
+
+// deadline is the expiration time of an entry stored with a TTL.
+// It is updated atomically, so that entries can be touched under the read lock.
+type deadline struct {
+	// in nanoseconds since the Unix epoch
+	at  atomic.Int64
+	ttl time.Duration
+}
+
+func newDeadline(now time.Time, ttl time.Duration) *deadline {
+	d := &deadline{ttl: ttl}
+	d.at.Store(now.Add(ttl).UnixNano())
+	return d
+}
+
+func (d *deadline) expiredAt(now time.Time) bool {
+	return now.UnixNano() >= d.at.Load()
+}
+
+// touch postpones the deadline to a full TTL after now.
+func (d *deadline) touch(now time.Time) {
+	d.at.Store(now.Add(d.ttl).UnixNano())
+}
+
+// WithDefaultTTL makes every write that does not set a TTL of its own (Store, LoadOrStore, etc.)
+// store its value with the given TTL, as StoreWithTTL does.
+func WithDefaultTTL[K comparable, V any](ttl time.Duration) Option[K, V] {
+	return func(m *SyncMap[K, V]) {
+		m.defaultTTL = ttl
+	}
+}
+
+// StoreWithTTL sets the value for a key, which expires once ttl has elapsed:
+// from then on, the entry is treated as absent by all operations, and it is removed on the next access
+// to the key, by RemoveExpired, or by the sweeper started with SweepExpired.
+// Storing the key again with Store (or any method other than StoreWithTTL) clears its expiration,
+// unless the map was created WithDefaultTTL.
+// Touch restarts the TTL, as does every Load if the map was created WithSlidingExpiration.
+// Time is measured with the clock of the map, see WithClock.
+// It acquires a write lock to ensure thread-safe access to the underlying data.
+func (m *SyncMap[K, V]) StoreWithTTL(k K, v V, ttl time.Duration) {
+	m.lock()
+	defer m.unlock()
+
+	k = m.key(k)
+	stored, err := m.put(k, v, true)
+	if err != nil {
+		m.check("StoreWithTTL", err)
+		return
+	}
+	if !stored {
+		// the write was not admitted, see WithTinyLFUAdmission
+		return
+	}
+
+	if m.expiry == nil {
+		m.expiry = make(map[K]*deadline)
+	}
+	m.expiry[k] = newDeadline(m.clock(), ttl)
+	m.recordDeadline(k)
+}
+
+// Touch restarts the TTL of the entry of k, so that it expires once its TTL has elapsed from now,
+// giving idle-timeout semantics to entries stored with StoreWithTTL.
+// It reports whether the key is present; touching an entry without TTL has no effect.
+// It only acquires a read lock, as deadlines are updated atomically.
+func (m *SyncMap[K, V]) Touch(k K) bool {
+	m.rlock()
+	defer m.runlock()
+
+	k = m.key(k)
+	if _, ok := m.load(k); !ok {
+		return false
+	}
+	m.touch(k)
+	return true
+}
+
+// ExpireAt sets the expiration time of the entry of k, which may or may not have had one, to t;
+// a time in the past expires the entry right away.
+// With WithSlidingExpiration, reads restart the TTL with the duration between now and t.
+// It reports whether the key is present.
+// It acquires a write lock to ensure thread-safe access to the underlying data.
+func (m *SyncMap[K, V]) ExpireAt(k K, t time.Time) bool {
+	m.lock()
+	defer m.unlock()
+
+	k = m.key(k)
+	if _, ok := m.load(k); !ok {
+		return false
+	}
+
+	now := m.clock()
+	if !now.Before(t) && !m.isPinned(k) {
+		m.expire(k)
+		return true
+	}
+
+	if m.expiry == nil {
+		m.expiry = make(map[K]*deadline)
+	}
+	m.expiry[k] = newDeadline(now, t.Sub(now))
+	// expiration changes are published to read-mostly snapshots like any other change
+	m.version.Add(1)
+	m.recordDeadline(k)
+	return true
+}
+
+// GetTTL returns the time left before the entry of k expires.
+// The ok result is false if the key is absent or has no expiration.
+// It acquires a read lock to ensure thread-safe access to the underlying data.
+func (m *SyncMap[K, V]) GetTTL(k K) (ttl time.Duration, ok bool) {
+	m.rlock()
+	defer m.runlock()
+
+	k = m.key(k)
+	if _, ok := m.load(k); !ok {
+		return 0, false
+	}
+	d, ok := m.expiry[k]
+	if !ok {
+		return 0, false
+	}
+	return time.Duration(d.at.Load() - m.clock().UnixNano()), true
+}
+
+// Persist removes the expiration of the entry of k, so that it stays until it is removed.
+// It reports whether the key is present and had an expiration.
+// It acquires a write lock to ensure thread-safe access to the underlying data.
+func (m *SyncMap[K, V]) Persist(k K) bool {
+	m.lock()
+	defer m.unlock()
+
+	k = m.key(k)
+	if _, ok := m.load(k); !ok {
+		return false
+	}
+	if _, ok := m.expiry[k]; !ok {
+		return false
+	}
+
+	delete(m.expiry, k)
+	m.version.Add(1)
+	m.recordDeadline(k)
+	return true
+}
+
+// TTLDistribution returns a histogram of the time left before the entries of the map expire, to predict
+// how much of it will expire in the coming minutes. buckets are upper bounds, in increasing order:
+// the i-th count is the number of entries expiring within buckets[i] but after buckets[i-1],
+// and the last one, at index len(buckets), the number of entries expiring after the last bound.
+// Entries without expiration, pinned entries and entries already expired are not counted.
+// It acquires a read lock to ensure thread-safe access to the underlying data.
+func (m *SyncMap[K, V]) TTLDistribution(buckets []time.Duration) []int {
+	m.rlock()
+	defer m.runlock()
+
+	counts := make([]int, len(buckets)+1)
+	now := m.clock().UnixNano()
+	for k, d := range m.expiry {
+		left := time.Duration(d.at.Load() - now)
+		if left <= 0 || m.isPinned(k) {
+			continue
+		}
+		i, _ := slices.BinarySearch(buckets, left)
+		counts[i]++
+	}
+	return counts
+}
+
+// RemoveExpired removes all expired entries from the map and returns how many were removed.
+// Entries that can still be served stale (see WithStaleWhileRevalidate) are kept.
+// It also forgets the keys remembered by negative caching whose TTL has elapsed.
+// It acquires a write lock to ensure thread-safe access to the underlying data.
+func (m *SyncMap[K, V]) RemoveExpired() int {
+	m.lock()
+	defer m.unlock()
+
+	now := m.clock()
+	for k, t := range m.missing {
+		if !now.Before(t) {
+			delete(m.missing, k)
+		}
+	}
+
+	// stale entries can still be served until they have been expired for maxStale
+	expiredBefore := now.Add(-m.maxStale)
+	n := 0
+	for k := range m.expiry {
+		if m.expiredAt(k, expiredBefore) {
+			m.expire(k)
+			n++
+		}
+	}
+	return n
+}
+
+// SweepExpired starts a background goroutine that calls RemoveExpired every interval,
+// so that expired entries that are never accessed again do not keep using memory.
+// The goroutine stops when ctx is done. See also StartJanitor, which is stopped by the map itself.
+func (m *SyncMap[K, V]) SweepExpired(ctx context.Context, interval time.Duration) {
+	go m.sweep(interval, ctx.Done())
+}
+
+// StartJanitor starts a background goroutine, the janitor, that calls RemoveExpired every interval,
+// replacing the janitor already running, if any. It runs until StopJanitor or Close is called.
+// Without a janitor, expired entries are still removed when they are accessed.
+func (m *SyncMap[K, V]) StartJanitor(interval time.Duration) {
+	m.janitorMu.Lock()
+	defer m.janitorMu.Unlock()
+
+	m.stopJanitor()
+	j := &janitor{stop: make(chan struct{}), done: make(chan struct{})}
+	m.janitor = j
+	go func() {
+		defer close(j.done)
+		m.sweep(interval, j.stop)
+	}()
+}
+
+// StopJanitor stops the janitor started by StartJanitor, if any, and waits for it to return.
+func (m *SyncMap[K, V]) StopJanitor() {
+	m.janitorMu.Lock()
+	defer m.janitorMu.Unlock()
+
+	m.stopJanitor()
+}
+
+// Close stops the background goroutines owned by the map, i.e. the janitor, the write-behind writer
+// (after it has persisted the queued writes) and the periodic sync of the journal (after a final sync),
+// so that tests and short-lived programs do not leak them. The map remains usable, but writes to a map
+// with a write-behind writer are then rejected with ErrWriterClosed.
+// It returns the first error of the journal of the map, if any, see WithJournal.
+func (m *SyncMap[K, V]) Close() error {
+	m.StopJanitor()
+	if m.writer != nil {
+		m.writer.close()
+	}
+	if m.journal != nil {
+		return m.journal.close()
+	}
+	return nil
+}
+
+// janitor is the goroutine started by StartJanitor.
+type janitor struct {
+	// closed to stop the janitor
+	stop chan struct{}
+	// closed once the janitor has returned
+	done chan struct{}
+}
+
+// stopJanitor stops the janitor, if any. It assumes that the caller holds janitorMu.
+func (m *SyncMap[K, V]) stopJanitor() {
+	if m.janitor == nil {
+		return
+	}
+	close(m.janitor.stop)
+	<-m.janitor.done
+	m.janitor = nil
+}
+
+// sweep calls RemoveExpired every interval until stop is closed.
+func (m *SyncMap[K, V]) sweep(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			m.RemoveExpired()
+		}
+	}
+}
+
+// clock returns the current time according to the clock of the map, see WithClock.
+func (m *SyncMap[K, V]) clock() time.Time {
+	if m.now != nil {
+		return m.now()
+	}
+	return time.Now()
+}
+
+// The methods below assume that the caller holds the appropriate lock.
+
+// entries iterates over the entries of the map that have not expired, with decoded values.
+func (m *SyncMap[K, V]) entries() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		if len(m.expiry) == 0 {
+			for k, v := range m.data {
+				if !yield(k, m.decode(v)) {
+					return
+				}
+			}
+			return
+		}
+
+		now := m.clock()
+		for k, v := range m.data {
+			if m.expiredAt(k, now) {
+				continue
+			}
+			if !yield(k, m.decode(v)) {
+				return
+			}
+		}
+	}
+}
+
+// count returns the number of entries of the map that have not expired.
+func (m *SyncMap[K, V]) count() int {
+	if len(m.expiry) == 0 {
+		return len(m.data)
+	}
+
+	n := 0
+	for range m.entries() {
+		n++
+	}
+	return n
+}
+
+// isExpired reports whether the entry of k has expired.
+func (m *SyncMap[K, V]) isExpired(k K) bool {
+	if len(m.expiry) == 0 {
+		return false
+	}
+	return m.expiredAt(k, m.clock())
+}
+
+func (m *SyncMap[K, V]) expiredAt(k K, now time.Time) bool {
+	d, ok := m.expiry[k]
+	return ok && d.expiredAt(now) && !m.isPinned(k)
+}
+
+// touch restarts the TTL of the entry of k, if it has one.
+func (m *SyncMap[K, V]) touch(k K) {
+	if d, ok := m.expiry[k]; ok {
+		d.touch(m.clock())
+	}
+}
+
+// accessed reports a read of the entry of k: to the eviction policy, the statistics, its metadata,
+// and the TTL if expiration is sliding.
+func (m *SyncMap[K, V]) accessed(k K) {
+	m.trackAccessed(k)
+	m.stats.hit()
+	m.meta[k].accessed(m.clock())
+	if m.sliding {
+		m.touch(k)
+	}
+}
+
+// expire removes the expired entry of k.
+func (m *SyncMap[K, V]) expire(k K) {
+	v := m.decode(m.data[k])
+	delete(m.data, k)
+	m.forget(k)
+	m.size.Add(-1)
+	version := m.version.Add(1)
+	m.checkSoftLimit()
+	m.stats.expired()
+	m.emit(Event[K, V]{Op: OpExpire, Key: k, Old: v, HasOld: true, Version: version})
+	m.evictedLater(k, v, EvictionExpired)
+	m.disposeLater(map[K]V{k: v})
+}
+
+// removeExpired acquires the write lock and removes the entry of k if it has expired.
+func (m *SyncMap[K, V]) removeExpired(k K) {
+	m.lock()
+	defer m.unlock()
+
+	if _, ok := m.data[k]; ok && m.isExpired(k) {
+		m.expire(k)
+	}
+}
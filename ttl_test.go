@@ -0,0 +1,370 @@
+package syncmap
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock is a manually advanced clock for WithClock.
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Unix(1000, 0)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func TestStoreWithTTL(t *testing.T) {
+	t.Run(
+		"Expiration", func(t *testing.T) {
+			clock := newFakeClock()
+			var events []Event[string, int]
+			sm := New[string, int](
+				10,
+				WithClock[string, int](clock.Now),
+				WithEventHook[string, int](
+					func(e Event[string, int]) {
+						events = append(events, e)
+					},
+				),
+			)
+
+			sm.StoreWithTTL("session", 1, time.Minute)
+			sm.Store("permanent", 2)
+
+			clock.Advance(59 * time.Second)
+			if v, ok := sm.Load("session"); !ok || v != 1 {
+				t.Errorf("Expected (1, true) before expiration, got (%v, %v)", v, ok)
+			}
+
+			clock.Advance(time.Second)
+			if _, ok := sm.Load("session"); ok {
+				t.Error("Expected the entry to be absent once expired")
+			}
+			if sm.LenLocked() != 1 || sm.Len() != 1 {
+				t.Errorf("Expected the expired entry to be removed on access, got %d entries", sm.LenLocked())
+			}
+			if e := events[len(events)-1]; e.Op != OpExpire || e.Key != "session" || e.Old != 1 {
+				t.Errorf("Expected an expire event, got %+v", e)
+			}
+		},
+	)
+
+	t.Run(
+		"ExpiredEntriesAreInvisible", func(t *testing.T) {
+			clock := newFakeClock()
+			sm := New[string, int](10, WithClock[string, int](clock.Now))
+			sm.StoreWithTTL("a", 1, time.Second)
+			sm.Store("b", 2)
+			clock.Advance(time.Second)
+
+			var keys []string
+			sm.Range(
+				func(key string, value int) bool {
+					keys = append(keys, key)
+					return true
+				},
+			)
+			if !slices.Equal(keys, []string{"b"}) {
+				t.Errorf("Expected Range to skip expired entries, got %v", keys)
+			}
+			if sm.LenLocked() != 1 || sm.Count(func(k string, v int) bool { return true }) != 1 {
+				t.Error("Expected expired entries not to be counted")
+			}
+			sm.DoLocked(
+				func(m LockedMap[string, int]) {
+					if m.Contains("a") || m.Len() != 1 || len(m.Clone()) != 1 {
+						t.Error("Expected expired entries to be invisible to LockedMap")
+					}
+				},
+			)
+			if sm.Remove("a") {
+				t.Error("Remove should return false for an expired entry")
+			}
+			if v, loaded := sm.LoadOrStore("a", 3); loaded || v != 3 {
+				t.Errorf("Expected (3, false), got (%v, %v)", v, loaded)
+			}
+
+			// storing without a TTL clears the expiration
+			clock.Advance(time.Hour)
+			if v, ok := sm.Load("a"); !ok || v != 3 {
+				t.Errorf("Expected (3, true), got (%v, %v)", v, ok)
+			}
+		},
+	)
+
+	t.Run(
+		"RemoveExpired", func(t *testing.T) {
+			clock := newFakeClock()
+			var disposed []string
+			sm := New[string, int](
+				10,
+				WithClock[string, int](clock.Now),
+				WithDisposer[string, int](
+					func(k string, v int) {
+						disposed = append(disposed, k)
+					},
+				),
+			)
+			sm.StoreWithTTL("a", 1, time.Second)
+			sm.StoreWithTTL("b", 2, time.Minute)
+			sm.Store("c", 3)
+
+			clock.Advance(time.Second)
+			if n := sm.RemoveExpired(); n != 1 {
+				t.Errorf("Expected 1 entry to be removed, got %d", n)
+			}
+			if sm.Len() != 2 || !slices.Equal(disposed, []string{"a"}) {
+				t.Errorf("Expected [a] to be removed and disposed, got %d entries and %v", sm.Len(), disposed)
+			}
+		},
+	)
+
+	t.Run(
+		"SweepExpired", func(t *testing.T) {
+			sm := New[string, int](10)
+			sm.StoreWithTTL("a", 1, time.Millisecond)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			sm.SweepExpired(ctx, time.Millisecond)
+
+			deadline := time.Now().Add(time.Second)
+			for sm.Len() != 0 && time.Now().Before(deadline) {
+				time.Sleep(time.Millisecond)
+			}
+			if sm.Len() != 0 {
+				t.Error("Expected the sweeper to remove the expired entry")
+			}
+		},
+	)
+
+	t.Run(
+		"ReadMostly", func(t *testing.T) {
+			clock := newFakeClock()
+			sm := New[string, int](10, WithClock[string, int](clock.Now), WithReadMostly[string, int]())
+			sm.StoreWithTTL("a", 1, time.Second)
+			if _, ok := sm.Load("a"); !ok {
+				t.Error("Expected the entry to be present")
+			}
+			clock.Advance(time.Second)
+			if _, ok := sm.Load("a"); ok {
+				t.Error("Expected the entry to be absent once expired")
+			}
+		},
+	)
+}
+
+func TestSyncMapTouch(t *testing.T) {
+	clock := newFakeClock()
+	sm := New[string, int](10, WithClock[string, int](clock.Now))
+	sm.StoreWithTTL("session", 1, time.Minute)
+	sm.Store("permanent", 2)
+
+	clock.Advance(50 * time.Second)
+	if !sm.Touch("session") {
+		t.Error("Expected Touch to report the key as present")
+	}
+	clock.Advance(50 * time.Second)
+	if _, ok := sm.Load("session"); !ok {
+		t.Error("Expected the touched entry to be present")
+	}
+	clock.Advance(10 * time.Second)
+	if _, ok := sm.Load("session"); ok {
+		t.Error("Expected the entry to expire a full TTL after it was touched")
+	}
+	if sm.Touch("session") {
+		t.Error("Expected Touch to report an expired key as absent")
+	}
+	if !sm.Touch("permanent") || sm.Touch("missing") {
+		t.Error("Expected Touch to report whether the key is present")
+	}
+}
+
+func TestWithSlidingExpiration(t *testing.T) {
+	for _, readMostly := range []bool{false, true} {
+		clock := newFakeClock()
+		opts := []Option[string, int]{WithClock[string, int](clock.Now), WithSlidingExpiration[string, int]()}
+		if readMostly {
+			opts = append(opts, WithReadMostly[string, int]())
+		}
+		sm := New[string, int](10, opts...)
+		sm.StoreWithTTL("session", 1, time.Minute)
+
+		for range 5 {
+			clock.Advance(50 * time.Second)
+			if _, ok := sm.Load("session"); !ok {
+				t.Fatalf("Expected the entry to stay while it is read (read-mostly: %v)", readMostly)
+			}
+		}
+
+		// iterations do not count as reads
+		sm.Range(
+			func(k string, v int) bool {
+				return true
+			},
+		)
+		clock.Advance(time.Minute)
+		if _, ok := sm.Load("session"); ok {
+			t.Errorf("Expected the idle entry to expire (read-mostly: %v)", readMostly)
+		}
+	}
+}
+
+func TestSyncMapExpirationControls(t *testing.T) {
+	t.Run(
+		"ExpireAt", func(t *testing.T) {
+			clock := newFakeClock()
+			sm := New[string, int](10, WithClock[string, int](clock.Now))
+			sm.Store("a", 1)
+
+			if !sm.ExpireAt("a", clock.Now().Add(time.Minute)) {
+				t.Error("Expected ExpireAt to report the key as present")
+			}
+			if ttl, ok := sm.GetTTL("a"); !ok || ttl != time.Minute {
+				t.Errorf("Expected (1m, true), got (%v, %v)", ttl, ok)
+			}
+			clock.Advance(time.Minute)
+			if _, ok := sm.Load("a"); ok {
+				t.Error("Expected the entry to expire at the given time")
+			}
+			if sm.ExpireAt("a", clock.Now().Add(time.Minute)) {
+				t.Error("Expected ExpireAt to report an expired key as absent")
+			}
+
+			sm.Store("b", 2)
+			if !sm.ExpireAt("b", clock.Now()) || sm.Len() != 0 {
+				t.Error("Expected a time in the past to expire the entry right away")
+			}
+		},
+	)
+
+	t.Run(
+		"GetTTL", func(t *testing.T) {
+			clock := newFakeClock()
+			sm := New[string, int](10, WithClock[string, int](clock.Now))
+			sm.StoreWithTTL("a", 1, time.Minute)
+			sm.Store("b", 2)
+
+			clock.Advance(15 * time.Second)
+			if ttl, ok := sm.GetTTL("a"); !ok || ttl != 45*time.Second {
+				t.Errorf("Expected (45s, true), got (%v, %v)", ttl, ok)
+			}
+			if _, ok := sm.GetTTL("b"); ok {
+				t.Error("Expected no TTL for an entry without expiration")
+			}
+			if _, ok := sm.GetTTL("c"); ok {
+				t.Error("Expected no TTL for an absent key")
+			}
+		},
+	)
+
+	t.Run(
+		"Persist", func(t *testing.T) {
+			clock := newFakeClock()
+			sm := New[string, int](10, WithClock[string, int](clock.Now), WithReadMostly[string, int]())
+			sm.StoreWithTTL("a", 1, time.Minute)
+			sm.Store("b", 2)
+
+			if !sm.Persist("a") {
+				t.Error("Expected Persist to report that the expiration was removed")
+			}
+			if sm.Persist("a") || sm.Persist("b") || sm.Persist("c") {
+				t.Error("Expected Persist to report false without an expiration")
+			}
+			clock.Advance(time.Hour)
+			if v, ok := sm.Load("a"); !ok || v != 1 {
+				t.Errorf("Expected (1, true), got (%v, %v)", v, ok)
+			}
+		},
+	)
+}
+
+func TestSyncMapTTLDistribution(t *testing.T) {
+	clock := newFakeClock()
+	sm := New[string, int](10, WithClock[string, int](clock.Now))
+	sm.Store("forever", 0)
+	sm.StoreWithTTL("gone", 0, time.Second)
+	sm.StoreWithTTL("pinned", 0, time.Minute)
+	sm.Pin("pinned")
+	sm.StoreWithTTL("a", 1, time.Minute)
+	sm.StoreWithTTL("b", 2, 5*time.Minute)
+	sm.StoreWithTTL("c", 3, 10*time.Minute)
+	sm.StoreWithTTL("d", 4, time.Hour)
+	clock.Advance(2 * time.Second)
+
+	expected := []int{1, 2, 1}
+	if counts := sm.TTLDistribution([]time.Duration{time.Minute, 10 * time.Minute}); !slices.Equal(counts, expected) {
+		t.Errorf("Expected %v, got %v", expected, counts)
+	}
+	if counts := sm.TTLDistribution(nil); !slices.Equal(counts, []int{4}) {
+		t.Errorf("Expected [4], got %v", counts)
+	}
+}
+
+func TestSyncMapJanitor(t *testing.T) {
+	waitFor := func(cond func() bool) bool {
+		deadline := time.Now().Add(time.Second)
+		for !cond() && time.Now().Before(deadline) {
+			time.Sleep(time.Millisecond)
+		}
+		return cond()
+	}
+
+	t.Run(
+		"StartStop", func(t *testing.T) {
+			sm := New[string, int](10)
+			sm.StartJanitor(time.Millisecond)
+			sm.StoreWithTTL("a", 1, time.Millisecond)
+			if !waitFor(func() bool { return sm.Len() == 0 }) {
+				t.Error("Expected the janitor to remove the expired entry")
+			}
+
+			sm.StopJanitor()
+			sm.StoreWithTTL("b", 2, time.Nanosecond)
+			time.Sleep(10 * time.Millisecond)
+			if sm.Len() != 1 {
+				t.Error("Expected the stopped janitor not to remove entries")
+			}
+			// lazy expiration still applies
+			if _, ok := sm.Load("b"); ok || sm.Len() != 0 {
+				t.Error("Expected the expired entry to be removed on access")
+			}
+			sm.StopJanitor()
+		},
+	)
+
+	t.Run(
+		"Restart", func(t *testing.T) {
+			sm := New[string, int](10)
+			sm.StartJanitor(time.Hour)
+			sm.StartJanitor(time.Millisecond)
+			sm.StoreWithTTL("a", 1, time.Millisecond)
+			if !waitFor(func() bool { return sm.Len() == 0 }) {
+				t.Error("Expected the new janitor to replace the previous one")
+			}
+			if err := sm.Close(); err != nil {
+				t.Errorf("Expected no error, got %v", err)
+			}
+			if sm.janitor != nil {
+				t.Error("Expected Close to stop the janitor")
+			}
+		},
+	)
+}
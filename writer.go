@@ -0,0 +1,194 @@
+package syncmap
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// WriterOption configures the writer set by WithWriter.
+type WriterOption[K comparable, V any] func(w *writer[K, V])
+
+// WithWriteBehind makes the writer asynchronous: writes are queued, up to queueSize of them,
+// and persisted in order by a background goroutine, so that writes to the map do not wait for the store.
+// What happens to the writes made while the queue is full depends on the error handler, see WithWriter.
+// Use Flush to wait for the queued writes to be persisted, and Close to stop the goroutine.
+func WithWriteBehind[K comparable, V any](queueSize int) WriterOption[K, V] {
+	return func(w *writer[K, V]) {
+		w.queue = make(chan writeOp[K, V], queueSize)
+	}
+}
+
+// WithWriteRetry makes the write-behind goroutine try each write up to attempts times,
+// waiting backoff after the first failure and doubling the wait after each further failure.
+func WithWriteRetry[K comparable, V any](attempts int, backoff time.Duration) WriterOption[K, V] {
+	return func(w *writer[K, V]) {
+		w.attempts = attempts
+		w.backoff = backoff
+	}
+}
+
+// WithWriteErrorHandler sets a function called with the writes that the write-behind goroutine
+// failed to persist, after all the attempts, and with the writes that were not queued because
+// the queue was full (with ErrWriteQueueFull), e.g. to persist them later. Without it, the writes made
+// while the queue is full are rejected.
+func WithWriteErrorHandler[K comparable, V any](onError func(k K, v V, err error)) WriterOption[K, V] {
+	return func(w *writer[K, V]) {
+		w.onError = onError
+	}
+}
+
+// WithWriter persists every value written to the map (by Store, LoadOrStore, a LockedMap, etc.) with write,
+// e.g. to a database or an API. By default, the map is written through: write is called synchronously,
+// under the write lock, before the map is modified, and an error rejects the write as validation errors do
+// (TryStore returns it). With WithWriteBehind, writes are persisted asynchronously instead, and a write made
+// while the queue is full is either rejected with ErrWriteQueueFull, if no error handler is set, or made to
+// the map without being persisted and reported to the error handler (see WithWriteErrorHandler), so that
+// no write is lost silently. Use Flush to wait for the queue to drain.
+// Removals are not persisted, nor are the values loaded by the loader of the map (see WithLoader),
+// refreshed in the background or computed by LoadOrCompute, which typically come from the same store. write must not use the map.
+func WithWriter[K comparable, V any](write func(k K, v V) error, opts ...WriterOption[K, V]) Option[K, V] {
+	return func(m *SyncMap[K, V]) {
+		w := &writer[K, V]{write: write, attempts: 1}
+		for _, opt := range opts {
+			opt(w)
+		}
+		if w.queue != nil {
+			w.done = make(chan struct{})
+			go w.run()
+		}
+		m.writer = w
+	}
+}
+
+// Flush waits until the writes queued before the call have been persisted, or ctx is done,
+// in which case it returns the context's error. It returns immediately if the map
+// has no write-behind writer, or once it is closed.
+func (m *SyncMap[K, V]) Flush(ctx context.Context) error {
+	w := m.writer
+	if w == nil || w.queue == nil {
+		return nil
+	}
+
+	w.mu.RLock()
+	if w.closed {
+		w.mu.RUnlock()
+		return nil
+	}
+	flushed := make(chan struct{})
+	select {
+	case w.queue <- writeOp[K, V]{flushed: flushed}:
+		w.mu.RUnlock()
+	case <-ctx.Done():
+		w.mu.RUnlock()
+		return ctx.Err()
+	}
+
+	select {
+	case <-flushed:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// writer persists the values written to a map, see WithWriter.
+type writer[K comparable, V any] struct {
+	write    func(k K, v V) error
+	attempts int
+	backoff  time.Duration
+	onError  func(k K, v V, err error)
+
+	// write-behind queue, nil for write-through
+	queue chan writeOp[K, V]
+	// guards closed; held for reading while sending to the queue, so that it is not closed meanwhile
+	mu     sync.RWMutex
+	closed bool
+	// closed once the write-behind goroutine has returned
+	done chan struct{}
+}
+
+// writeOp is a write queued for write-behind, or a flush marker if flushed is set.
+type writeOp[K comparable, V any] struct {
+	key     K
+	value   V
+	flushed chan struct{}
+}
+
+// persist persists the value written for k with the writer of the map. If the write-behind queue is full
+// and the writer has an error handler, the value is reported to it once the lock is released, and no error
+// is returned, so that the write is still made to the map. Otherwise, ErrWriteQueueFull rejects the write.
+// It assumes that the caller holds the write lock.
+func (m *SyncMap[K, V]) persist(k K, v V) error {
+	err := m.writer.persist(k, v)
+	onError := m.writer.onError
+	if !errors.Is(err, ErrWriteQueueFull) || onError == nil {
+		return err
+	}
+
+	m.deferCallback(
+		func() {
+			onError(k, v, err)
+		},
+	)
+	return nil
+}
+
+// persist persists the value written for k, or queues it for write-behind.
+// It is called under the write lock of the map.
+func (w *writer[K, V]) persist(k K, v V) error {
+	if w.queue == nil {
+		return w.write(k, v)
+	}
+
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	if w.closed {
+		return ErrWriterClosed
+	}
+	select {
+	case w.queue <- writeOp[K, V]{key: k, value: v}:
+		return nil
+	default:
+		return ErrWriteQueueFull
+	}
+}
+
+// run persists the queued writes until the queue is closed.
+func (w *writer[K, V]) run() {
+	defer close(w.done)
+
+	for op := range w.queue {
+		if op.flushed != nil {
+			close(op.flushed)
+			continue
+		}
+
+		err := w.write(op.key, op.value)
+		for attempt, backoff := 1, w.backoff; err != nil && attempt < w.attempts; attempt, backoff = attempt+1, 2*backoff {
+			time.Sleep(backoff)
+			err = w.write(op.key, op.value)
+		}
+		if err != nil && w.onError != nil {
+			w.onError(op.key, op.value, err)
+		}
+	}
+}
+
+// close persists the queued writes, then stops the write-behind goroutine.
+// Writes made afterwards are rejected with ErrWriterClosed.
+func (w *writer[K, V]) close() {
+	if w.queue == nil {
+		return
+	}
+
+	w.mu.Lock()
+	if !w.closed {
+		w.closed = true
+		close(w.queue)
+	}
+	w.mu.Unlock()
+	<-w.done
+}
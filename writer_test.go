@@ -0,0 +1,224 @@
+package syncmap
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordingStore is a fake persistent store for WithWriter.
+type recordingStore struct {
+	mu     sync.Mutex
+	data   map[string]int
+	writes int
+	// number of writes to fail before succeeding
+	failures int
+	// if set, writes block until it is closed
+	gate chan struct{}
+}
+
+func newRecordingStore() *recordingStore {
+	return &recordingStore{data: make(map[string]int)}
+}
+
+func (s *recordingStore) write(k string, v int) error {
+	if s.gate != nil {
+		<-s.gate
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.writes++
+	if s.failures > 0 {
+		s.failures--
+		return errors.New("store unavailable")
+	}
+	s.data[k] = v
+	return nil
+}
+
+func (s *recordingStore) snapshot() map[string]int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return maps.Clone(s.data)
+}
+
+func TestWithWriter(t *testing.T) {
+	t.Run(
+		"WriteThrough", func(t *testing.T) {
+			store := newRecordingStore()
+			sm := New[string, int](10, WithWriter[string, int](store.write))
+			sm.Store("a", 1)
+			sm.LoadOrStore("b", 2)
+			sm.DoLocked(
+				func(m LockedMap[string, int]) {
+					m.Store("c", 3)
+				},
+			)
+
+			expected := map[string]int{"a": 1, "b": 2, "c": 3}
+			if got := store.snapshot(); !maps.Equal(got, expected) {
+				t.Errorf("Expected %v, got %v", expected, got)
+			}
+
+			store.failures = 1
+			if err := sm.TryStore("a", 10); err == nil {
+				t.Error("Expected the failed write to be reported")
+			}
+			if v, _ := sm.Load("a"); v != 1 {
+				t.Errorf("Expected the map to be unchanged after a failed write, got %d", v)
+			}
+		},
+	)
+
+	t.Run(
+		"LoadedValuesAreNotPersisted", func(t *testing.T) {
+			store := newRecordingStore()
+			sm := New[string, int](
+				10,
+				WithWriter[string, int](store.write),
+				WithLoader[string, int](
+					func(k string) (int, error) {
+						return 1, nil
+					},
+				),
+			)
+			sm.Load("a")
+			if store.writes != 0 {
+				t.Errorf("Expected no write, got %d", store.writes)
+			}
+		},
+	)
+
+	t.Run(
+		"WriteBehind", func(t *testing.T) {
+			store := newRecordingStore()
+			store.gate = make(chan struct{})
+			var dropped []string
+			sm := New[string, int](
+				10,
+				WithWriter(
+					store.write,
+					WithWriteBehind[string, int](2),
+					WithWriteErrorHandler(
+						func(k string, v int, err error) {
+							if errors.Is(err, ErrWriteQueueFull) {
+								dropped = append(dropped, k)
+							}
+						},
+					),
+				),
+			)
+
+			// the first write is taken by the writer, which blocks on the gate; two more fill the queue
+			sm.Store("a", 1)
+			deadline := time.Now().Add(time.Second)
+			for len(sm.writer.queue) != 0 && time.Now().Before(deadline) {
+				time.Sleep(time.Millisecond)
+			}
+			sm.Store("b", 2)
+			sm.Store("c", 3)
+			// the queue is full: the write is made to the map, and reported as not persisted
+			if err := sm.TryStore("d", 4); err != nil {
+				t.Errorf("Expected no error, got %v", err)
+			}
+			if sm.Len() != 4 {
+				t.Errorf("Expected the map not to wait for the store, got %d entries", sm.Len())
+			}
+			if !slices.Equal(dropped, []string{"d"}) {
+				t.Errorf("Expected d to be reported with ErrWriteQueueFull, got %v", dropped)
+			}
+
+			close(store.gate)
+			if err := sm.Flush(context.Background()); err != nil {
+				t.Errorf("Expected no error, got %v", err)
+			}
+			expected := map[string]int{"a": 1, "b": 2, "c": 3}
+			if got := store.snapshot(); !maps.Equal(got, expected) {
+				t.Errorf("Expected %v, got %v", expected, got)
+			}
+
+			sm.Store("d", 4)
+			sm.Close()
+			if got := store.snapshot(); got["d"] != 4 {
+				t.Error("Expected Close to persist the queued writes")
+			}
+			if err := sm.TryStore("e", 5); !errors.Is(err, ErrWriterClosed) {
+				t.Errorf("Expected ErrWriterClosed, got %v", err)
+			}
+			if err := sm.Flush(context.Background()); err != nil {
+				t.Errorf("Expected no error, got %v", err)
+			}
+		},
+	)
+
+	t.Run(
+		"QueueFull", func(t *testing.T) {
+			store := newRecordingStore()
+			store.gate = make(chan struct{})
+			sm := New[string, int](10, WithWriter(store.write, WithWriteBehind[string, int](1)))
+			defer sm.Close()
+
+			sm.Store("a", 1)
+			deadline := time.Now().Add(time.Second)
+			for len(sm.writer.queue) != 0 && time.Now().Before(deadline) {
+				time.Sleep(time.Millisecond)
+			}
+			sm.Store("b", 2)
+			// without an error handler, the write is rejected rather than lost
+			if err := sm.TryStore("c", 3); !errors.Is(err, ErrWriteQueueFull) {
+				t.Errorf("Expected ErrWriteQueueFull, got %v", err)
+			}
+			if _, ok := sm.Load("c"); ok {
+				t.Error("Expected the rejected write not to be made to the map")
+			}
+
+			close(store.gate)
+			if err := sm.Flush(context.Background()); err != nil {
+				t.Errorf("Expected no error, got %v", err)
+			}
+			if err := sm.TryStore("c", 3); err != nil {
+				t.Errorf("Expected the write to be accepted once the queue drained, got %v", err)
+			}
+		},
+	)
+
+	t.Run(
+		"Retry", func(t *testing.T) {
+			store := newRecordingStore()
+			store.failures = 4
+			var failed []string
+			sm := New[string, int](
+				10,
+				WithWriter(
+					store.write,
+					WithWriteBehind[string, int](10),
+					WithWriteRetry[string, int](3, time.Millisecond),
+					WithWriteErrorHandler(
+						func(k string, v int, err error) {
+							failed = append(failed, k)
+						},
+					),
+				),
+			)
+			defer sm.Close()
+
+			sm.Store("a", 1) // fails 3 times
+			sm.Store("b", 2) // fails once, then succeeds
+			if err := sm.Flush(context.Background()); err != nil {
+				t.Errorf("Expected no error, got %v", err)
+			}
+			if len(failed) != 1 || failed[0] != "a" {
+				t.Errorf("Expected [a] to fail, got %v", failed)
+			}
+			if got := store.snapshot(); !maps.Equal(got, map[string]int{"b": 2}) {
+				t.Errorf("Expected map[b:2], got %v", got)
+			}
+		},
+	)
+}
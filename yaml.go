@@ -0,0 +1,36 @@
+package syncmap
+
+import (
+	"maps"
+)
+
+// MarshalYAML implements the Marshaler interface of gopkg.in/yaml.v2 and gopkg.in/yaml.v3,
+// so that a SyncMap can be used directly in YAML configuration structs.
+// It returns a copy of the entries of the map, made under a read lock, which the YAML encoder encodes as a mapping.
+// This package does not depend on a YAML library.
+func (m *SyncMap[K, V]) MarshalYAML() (any, error) {
+	m.rlock()
+	defer m.runlock()
+
+	return maps.Collect(m.entries()), nil
+}
+
+// UnmarshalYAML implements the Unmarshaler interface of gopkg.in/yaml.v2, which gopkg.in/yaml.v3 also supports,
+// replacing the contents of the map with the entries of a YAML mapping, e.g. when a configuration is reloaded.
+// The mapping is decoded with unmarshal before the map is locked, and the contents are then replaced atomically,
+// under a single write lock, as with ReplaceAll: if the data is not valid, the map is left unchanged.
+// The pairs rejected by the map are skipped and reported in the returned error. A YAML null leaves the map unchanged.
+func (m *SyncMap[K, V]) UnmarshalYAML(unmarshal func(any) error) error {
+	var data map[K]V
+	if err := unmarshal(&data); err != nil {
+		return err
+	}
+	if data == nil {
+		return nil
+	}
+
+	m.lock()
+	defer m.unlock()
+
+	return m.replace(data)
+}
@@ -0,0 +1,56 @@
+package syncmap
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// The YAML libraries are not dependencies of this package: the tests call the methods as the libraries do,
+// with JSON, which is a subset of YAML, standing in for the decoder.
+
+func TestSyncMapYAML(t *testing.T) {
+	t.Run(
+		"Marshal", func(t *testing.T) {
+			sm := New[string, int](10)
+			sm.Store("a", 1)
+			sm.Store("b", 2)
+
+			v, err := sm.MarshalYAML()
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if !reflect.DeepEqual(v, map[string]int{"a": 1, "b": 2}) {
+				t.Errorf("Expected the entries as a map, got %#v", v)
+			}
+		},
+	)
+
+	t.Run(
+		"Unmarshal", func(t *testing.T) {
+			sm := New[string, int](10)
+			sm.Store("stale", 1)
+
+			doc := `{"read": 10, "write": 5}`
+			if err := sm.UnmarshalYAML(func(v any) error { return json.Unmarshal([]byte(doc), v) }); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if sm.Len() != 2 {
+				t.Errorf("Expected the contents to be replaced, got %d entries", sm.Len())
+			}
+			if v, ok := sm.Load("write"); !ok || v != 5 {
+				t.Errorf("Expected 5, got (%v, %v)", v, ok)
+			}
+
+			if err := sm.UnmarshalYAML(func(v any) error { return json.Unmarshal([]byte("null"), v) }); err != nil || sm.Len() != 2 {
+				t.Errorf("Expected null to leave the map unchanged, got %d entries and %v", sm.Len(), err)
+			}
+
+			invalid := errors.New("invalid")
+			if err := sm.UnmarshalYAML(func(any) error { return invalid }); !errors.Is(err, invalid) || sm.Len() != 2 {
+				t.Errorf("Expected the decoding error and the map unchanged, got %d entries and %v", sm.Len(), err)
+			}
+		},
+	)
+}